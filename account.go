@@ -20,6 +20,7 @@ package tesra_go_sdk
 import (
 	"crypto/elliptic"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"github.com/TesraSupernet/tesracrypto/ec"
 	"github.com/TesraSupernet/tesracrypto/keypair"
@@ -126,12 +127,15 @@ func (this *Account) GetSigScheme() s.SignatureScheme {
 type AccountData struct {
 	keypair.ProtectedKey
 
-	Label     string `json:"label"`
-	PubKey    string `json:"publicKey"`
-	SigSch    string `json:"signatureScheme"`
-	IsDefault bool   `json:"isDefault"`
-	Lock      bool   `json:"lock"`
-	scrypt    *keypair.ScryptParam
+	Label          string `json:"label"`
+	PubKey         string `json:"publicKey"`
+	SigSch         string `json:"signatureScheme"`
+	IsDefault      bool   `json:"isDefault"`
+	Lock           bool   `json:"lock"`
+	DerivationPath string `json:"derivationPath,omitempty"` //BIP44 path of HD account, such as m/44'/1024'/0'/0/0
+	WatchOnly      bool   `json:"watchOnly,omitempty"`      //watch-only account has public key only, cannot sign
+	scrypt         *keypair.ScryptParam
+	extraFields    map[string]json.RawMessage //unknown fields written by other tools, kept on save
 }
 
 func NewAccountData(keyType keypair.KeyType, curveCode byte, sigScheme s.SignatureScheme, passwd []byte, scrypts ...*keypair.ScryptParam) (*AccountData, error) {
@@ -168,7 +172,27 @@ func NewAccountData(keyType keypair.KeyType, curveCode byte, sigScheme s.Signatu
 	return accData, nil
 }
 
+//NewAccountDataFromAccount return the AccountData of acc, private key is encrypted by passwd
+func NewAccountDataFromAccount(acc *Account, passwd []byte, scrypt *keypair.ScryptParam) (*AccountData, error) {
+	if len(passwd) == 0 {
+		return nil, fmt.Errorf("password cannot empty")
+	}
+	prvSecret, err := keypair.EncryptWithCustomScrypt(acc.PrivateKey, acc.Address.ToBase58(), passwd, scrypt)
+	if err != nil {
+		return nil, fmt.Errorf("encryptPrivateKey error:%s", err)
+	}
+	accData := &AccountData{}
+	accData.SetKeyPair(prvSecret)
+	accData.SigSch = acc.SigScheme.Name()
+	accData.PubKey = hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey))
+	accData.SetScript(scrypt)
+	return accData, nil
+}
+
 func (this *AccountData) GetAccount(passwd []byte) (*Account, error) {
+	if this.WatchOnly {
+		return nil, ERR_WATCH_ONLY_ACCOUNT
+	}
 	privateKey, err := keypair.DecryptWithCustomScrypt(&this.ProtectedKey, passwd, this.scrypt)
 	if err != nil {
 		return nil, fmt.Errorf("decrypt privateKey error:%s", err)
@@ -196,12 +220,15 @@ func (this *AccountData) SetScript(scrypt *keypair.ScryptParam) {
 
 func (this *AccountData) Clone() *AccountData {
 	accData := &AccountData{
-		Label:     this.Label,
-		PubKey:    this.PubKey,
-		SigSch:    this.SigSch,
-		IsDefault: this.IsDefault,
-		Lock:      this.Lock,
-		scrypt:    this.scrypt,
+		Label:          this.Label,
+		PubKey:         this.PubKey,
+		SigSch:         this.SigSch,
+		IsDefault:      this.IsDefault,
+		Lock:           this.Lock,
+		DerivationPath: this.DerivationPath,
+		WatchOnly:      this.WatchOnly,
+		scrypt:         this.scrypt,
+		extraFields:    this.extraFields,
 	}
 	accData.SetKeyPair(this.GetKeyPair())
 	return accData
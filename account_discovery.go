@@ -0,0 +1,252 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/smartcontract/service/native/tsr"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	s "github.com/TesraSupernet/tesracrypto/signature"
+	"sync"
+)
+
+var (
+	DEFAULT_DISCOVERY_GAP_LIMIT    = uint32(20)
+	DEFAULT_DISCOVERY_MAX_ACCOUNTS = uint32(20)
+)
+
+const hd_coin_type_hardened = hd_hardened_offset + HD_COIN_TYPE
+
+//AccountActivity is the on-chain activity of address
+type AccountActivity struct {
+	TsrBalance  uint64
+	TsgBalance  uint64
+	HasTransfer bool
+}
+
+//IsUsed return whether address has been used on chain
+func (this *AccountActivity) IsUsed() bool {
+	return this.TsrBalance > 0 || this.TsgBalance > 0 || this.HasTransfer
+}
+
+//ActivityChecker return the on-chain activity of address
+type ActivityChecker func(address common.Address) (*AccountActivity, error)
+
+//DiscoveredAccount is the used account found by AccountDiscovery
+type DiscoveredAccount struct {
+	Account      *Account
+	Path         string
+	AccountIndex uint32
+	AddressIndex uint32
+	Activity     *AccountActivity
+}
+
+//ToAccountData return the AccountData of discovered account to add into Wallet, private key is encrypted by passwd
+func (this *DiscoveredAccount) ToAccountData(wallet *Wallet, passwd []byte) (*AccountData, error) {
+	return NewDerivedAccountData(this.Account, this.Path, passwd, wallet.Scrypt)
+}
+
+//AccountDiscovery discover the used accounts of HD seed by BIP44 account discovery. For every account level,
+//address indexes are walked until GapLimit unused addresses in a row, and discovery stops at the first account
+//level without used address.
+type AccountDiscovery struct {
+	GapLimit    uint32
+	MaxAccounts uint32            //max account levels to scan
+	SigScheme   s.SignatureScheme //sig scheme of derived accounts, default SHA256withECDSA
+	Checker     ActivityChecker   //check on-chain activity of address, default checks balances and transfer events
+	tesraSdk    *TesraSdk
+	transfers   map[common.Address]bool
+	lock        sync.RWMutex
+}
+
+//NewAccountDiscovery return AccountDiscovery which checks native TSR/TSG balances and the transfer events
+//scanned by ScanTransferEvents through tesraSdk
+func NewAccountDiscovery(tesraSdk *TesraSdk) *AccountDiscovery {
+	discovery := &AccountDiscovery{
+		GapLimit:    DEFAULT_DISCOVERY_GAP_LIMIT,
+		MaxAccounts: DEFAULT_DISCOVERY_MAX_ACCOUNTS,
+		SigScheme:   s.SHA256withECDSA,
+		tesraSdk:    tesraSdk,
+		transfers:   make(map[common.Address]bool),
+	}
+	discovery.Checker = discovery.checkActivity
+	return discovery
+}
+
+//ScanTransferEvents collect the addresses of TSR, TSG and TEP1 transfer events in blocks from startHeight to
+//endHeight, so that address with zero balance but historical transfers is regarded as used
+func (this *AccountDiscovery) ScanTransferEvents(startHeight, endHeight uint32) error {
+	for height := startHeight; height >= startHeight && height <= endHeight; height++ {
+		events, err := this.tesraSdk.GetSmartContractEventByBlock(height)
+		if err != nil {
+			return fmt.Errorf("GetSmartContractEventByBlock height:%d error:%s", height, err)
+		}
+		this.lock.Lock()
+		for _, evt := range events {
+			if evt == nil {
+				continue
+			}
+			for _, notify := range evt.Notify {
+				transfer := this.tesraSdk.decodeSimulatedTransfer(notify)
+				if transfer == nil {
+					continue
+				}
+				for _, addr := range []string{transfer.From, transfer.To} {
+					address, err := common.AddressFromBase58(addr)
+					if err == nil {
+						this.transfers[address] = true
+					}
+				}
+			}
+		}
+		this.lock.Unlock()
+	}
+	return nil
+}
+
+func (this *AccountDiscovery) hasTransfer(address common.Address) bool {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.transfers[address]
+}
+
+func (this *AccountDiscovery) checkActivity(address common.Address) (*AccountActivity, error) {
+	activity := &AccountActivity{HasTransfer: this.hasTransfer(address)}
+	var err error
+	activity.TsrBalance, err = this.getNativeBalance(TSR_CONTRACT_ADDRESS, TSR_CONTRACT_VERSION, address)
+	if err != nil {
+		return nil, err
+	}
+	activity.TsgBalance, err = this.getNativeBalance(TSG_CONTRACT_ADDRESS, TSG_CONTRACT_VERSION, address)
+	if err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
+func (this *AccountDiscovery) getNativeBalance(contract common.Address, version byte, address common.Address) (uint64, error) {
+	preResult, err := this.tesraSdk.Native.PreExecInvokeNativeContract(
+		contract,
+		version,
+		tsr.BALANCEOF_NAME,
+		[]interface{}{address[:]},
+	)
+	if err != nil {
+		return 0, err
+	}
+	balance, err := preResult.Result.ToInteger()
+	if err != nil {
+		return 0, err
+	}
+	return balance.Uint64(), nil
+}
+
+//DiscoverFromMnemonic discover the used accounts of mnemonic with BIP39 passphrase, empty if no passphrase
+func (this *AccountDiscovery) DiscoverFromMnemonic(mnemonic, passphrase string) ([]*DiscoveredAccount, error) {
+	seed, err := MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return this.Discover(seed)
+}
+
+//Discover discover the used accounts of seed
+func (this *AccountDiscovery) Discover(seed []byte) ([]*DiscoveredAccount, error) {
+	if this.GapLimit == 0 {
+		return nil, fmt.Errorf("gap limit should be greater than 0")
+	}
+	accounts := make([]*DiscoveredAccount, 0)
+	for accountIndex := uint32(0); accountIndex < this.MaxAccounts; accountIndex++ {
+		used := 0
+		gap := uint32(0)
+		for addressIndex := uint32(0); gap < this.GapLimit; addressIndex++ {
+			path, err := getDiscoveryPath(this.SigScheme, accountIndex, addressIndex)
+			if err != nil {
+				return nil, err
+			}
+			acc, err := DeriveAccountFromSeedWithScheme(seed, path, this.SigScheme)
+			if err != nil {
+				return nil, err
+			}
+			activity, err := this.Checker(acc.Address)
+			if err != nil {
+				return nil, fmt.Errorf("check activity of address:%s error:%s", acc.Address.ToBase58(), err)
+			}
+			if !activity.IsUsed() {
+				gap++
+				continue
+			}
+			gap = 0
+			used++
+			accounts = append(accounts, &DiscoveredAccount{
+				Account:      acc,
+				Path:         path,
+				AccountIndex: accountIndex,
+				AddressIndex: addressIndex,
+				Activity:     activity,
+			})
+		}
+		if used == 0 {
+			break
+		}
+	}
+	return accounts, nil
+}
+
+//getDiscoveryPath return the BIP44 path of address index in account level, all of the levels are hardened for Ed25519
+func getDiscoveryPath(sigScheme s.SignatureScheme, accountIndex, addressIndex uint32) (string, error) {
+	keyType, err := GetKeyTypeOfScheme(sigScheme)
+	if err != nil {
+		return "", err
+	}
+	if keyType == keypair.PK_EDDSA {
+		return fmt.Sprintf("m/44'/%d'/%d'/0'/%d'", HD_COIN_TYPE, accountIndex, addressIndex), nil
+	}
+	return fmt.Sprintf("m/44'/%d'/%d'/0/%d", HD_COIN_TYPE, accountIndex, addressIndex), nil
+}
+
+//AddDiscoveredAccounts add discovered accounts into HD wallet, and move the next address index of
+//HD wallet after the discovered accounts of default path, which are of account level 0 and SHA256withECDSA.
+//The next address index only belongs to the default path, other account levels do not move it.
+func (this *Wallet) AddDiscoveredAccounts(accounts []*DiscoveredAccount, passwd []byte) error {
+	for _, acc := range accounts {
+		accData, err := acc.ToAccountData(this, passwd)
+		if err != nil {
+			return err
+		}
+		err = this.AddAccountData(accData)
+		if err != nil {
+			return fmt.Errorf("add account:%s error:%s", acc.Account.Address.ToBase58(), err)
+		}
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.hdSeed == nil {
+		return nil
+	}
+	for _, acc := range accounts {
+		if acc.AccountIndex != 0 || acc.Account.SigScheme != s.SHA256withECDSA || acc.Path != GetHDAccountPath(acc.AddressIndex) {
+			continue
+		}
+		if acc.AddressIndex >= this.hdSeed.NextIndex {
+			this.hdSeed.NextIndex = acc.AddressIndex + 1
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	s "github.com/TesraSupernet/tesracrypto/signature"
+	"github.com/stretchr/testify/assert"
+	"github.com/tyler-smith/go-bip39"
+	"testing"
+)
+
+func TestAccountDiscovery(t *testing.T) {
+	seed := bip39.NewSeed(testMnemonic, "")
+	used := make(map[common.Address]bool)
+	for _, path := range []string{"m/44'/1024'/0'/0/0", "m/44'/1024'/0'/0/3", "m/44'/1024'/0'/0/25",
+		"m/44'/1024'/1'/0/2", "m/44'/1024'/3'/0/0"} {
+		acc, err := DeriveAccountFromSeed(seed, path)
+		assert.Nil(t, err)
+		used[acc.Address] = true
+	}
+	discovery := NewAccountDiscovery(NewTesraSdk())
+	discovery.Checker = func(address common.Address) (*AccountActivity, error) {
+		return &AccountActivity{HasTransfer: used[address]}, nil
+	}
+	accounts, err := discovery.DiscoverFromMnemonic(testMnemonic, "")
+	assert.Nil(t, err)
+	paths := make([]string, 0)
+	for _, acc := range accounts {
+		paths = append(paths, acc.Path)
+	}
+	assert.Equal(t, []string{"m/44'/1024'/0'/0/0", "m/44'/1024'/0'/0/3", "m/44'/1024'/1'/0/2"}, paths)
+
+	discovery.GapLimit = 30
+	accounts, err = discovery.Discover(seed)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(accounts))
+
+	passwd := []byte("123456")
+	wallet := NewWallet("discovery_wallet.dat")
+	assert.Nil(t, wallet.InitHDFromMnemonic(testMnemonic, passwd))
+	assert.Nil(t, wallet.AddDiscoveredAccounts(accounts, passwd))
+	assert.Equal(t, 4, wallet.GetAccountCount())
+	acc, err := wallet.NewHDAccount(passwd)
+	assert.Nil(t, err)
+	accData, err := wallet.GetAccountDataByAddress(acc.Address.ToBase58())
+	assert.Nil(t, err)
+	assert.Equal(t, GetHDAccountPath(26), accData.DerivationPath)
+
+	//next index is not moved by the discovered accounts of other account levels
+	wallet = NewWallet("discovery_wallet.dat")
+	assert.Nil(t, wallet.InitHDFromMnemonic(testMnemonic, passwd))
+	assert.Nil(t, wallet.AddDiscoveredAccounts(accounts[3:], passwd))
+	assert.Equal(t, "m/44'/1024'/1'/0/2", accounts[3].Path)
+	acc, err = wallet.NewHDAccount(passwd)
+	assert.Nil(t, err)
+	accData, err = wallet.GetAccountDataByAddress(acc.Address.ToBase58())
+	assert.Nil(t, err)
+	assert.Equal(t, GetHDAccountPath(0), accData.DerivationPath)
+
+	edAcc, err := DeriveAccountFromSeedWithScheme(seed, "m/44'/1024'/0'/0'/1'", s.SHA512withEDDSA)
+	assert.Nil(t, err)
+	edDiscovery := NewAccountDiscovery(NewTesraSdk())
+	edDiscovery.SigScheme = s.SHA512withEDDSA
+	edDiscovery.Checker = func(address common.Address) (*AccountActivity, error) {
+		return &AccountActivity{HasTransfer: address == edAcc.Address}, nil
+	}
+	accounts, err = edDiscovery.Discover(seed)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(accounts))
+	assert.Equal(t, "m/44'/1024'/0'/0'/1'", accounts[0].Path)
+	assert.Equal(t, s.SHA512withEDDSA, accounts[0].Account.SigScheme)
+	//Ed25519 path does not move next index either
+	wallet = NewWallet("discovery_wallet.dat")
+	assert.Nil(t, wallet.InitHDFromMnemonic(testMnemonic, passwd))
+	assert.Nil(t, wallet.AddDiscoveredAccounts(accounts, passwd))
+	assert.Equal(t, uint32(0), wallet.hdSeed.NextIndex)
+
+	discovery.Checker = func(address common.Address) (*AccountActivity, error) {
+		return nil, fmt.Errorf("network error")
+	}
+	_, err = discovery.Discover(seed)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,390 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/Tesra/smartcontract/service/native/tsr"
+	"sync"
+)
+
+const (
+	PAYOUT_PENDING   = "pending"   //not sent yet
+	PAYOUT_SENT      = "sent"      //tx has been sent, waiting to be confirmed
+	PAYOUT_CONFIRMED = "confirmed" //tx has been confirmed on chain
+	PAYOUT_FAILED    = "failed"    //tx failed, or cannot be built, signed or sent
+	PAYOUT_DROPPED   = "dropped"   //tx was broadcast but not packed before expire time, it may still be packed if rebroadcast
+)
+
+const (
+	PAYOUT_METADATA_BATCH_ID = "batchId" //outbox metadata of batch id
+	PAYOUT_METADATA_DIGEST   = "digest"  //outbox metadata of the digest of asset, funder and items of chunk
+)
+
+var (
+	DEFAULT_PAYOUT_TRANSFERS_PER_TX = 500
+	DEFAULT_PAYOUT_GAS_PER_TRANSFER = uint64(20000)
+	DEFAULT_PAYOUT_MAX_GAS_LIMIT    = uint64(20000000)
+	DEFAULT_PAYOUT_MAX_TX_SIZE      = MAX_TX_SIZE / 4
+	DEFAULT_PAYOUT_CONCURRENCY      = 4
+)
+
+const (
+	payout_state_size       = 64  //estimated size of a transfer state in invoke code
+	payout_tx_overhead_size = 512 //estimated size of tx header, method and signatures
+)
+
+//PayoutItem is a transfer to recipient in batch payout
+type PayoutItem struct {
+	To     common.Address
+	Amount uint64
+}
+
+//PayoutStatus is the status of a PayoutItem
+type PayoutStatus struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Amount uint64 `json:"amount"`
+	TxHash string `json:"txHash,omitempty"`
+	State  string `json:"state"`
+	Error  string `json:"error,omitempty"`
+	key    string
+}
+
+//PayoutChunk is a group of PayoutItem paid in one transaction
+type PayoutChunk struct {
+	Index    int
+	From     common.Address
+	Items    []int //index of items
+	GasLimit uint64
+}
+
+//BatchPayout split a large payout list of TSR or TSG into multiple transactions under size and gas
+//limits, and send them with bounded concurrency. With Outbox, every chunk is recorded with key
+//"batchId:index" before broadcast, so Pay with the same batchId, funders and items can be called
+//again after partial failure, and the chunks which have been sent are never paid twice. The chunks
+//expired before broadcast or failed on chain are safe to retry, and are rebuilt with a new nonce under
+//key "batchId:index#attempt". The txs of a batch should not be pruned from Outbox until it is done.
+type BatchPayout struct {
+	Asset             common.Address
+	GasPrice          uint64
+	GasPerTransfer    uint64
+	MaxGasLimit       uint64
+	MaxTxSize         int
+	MaxTransfersPerTx int
+	Concurrency       int
+	Outbox            *Outbox
+	tesraSdk          *TesraSdk
+}
+
+//NewBatchPayout return a BatchPayout of asset, which is TSR_CONTRACT_ADDRESS or TSG_CONTRACT_ADDRESS
+func NewBatchPayout(tesraSdk *TesraSdk, asset common.Address, gasPrice uint64, outbox *Outbox) *BatchPayout {
+	return &BatchPayout{
+		Asset:             asset,
+		GasPrice:          gasPrice,
+		GasPerTransfer:    DEFAULT_PAYOUT_GAS_PER_TRANSFER,
+		MaxGasLimit:       DEFAULT_PAYOUT_MAX_GAS_LIMIT,
+		MaxTxSize:         DEFAULT_PAYOUT_MAX_TX_SIZE,
+		MaxTransfersPerTx: DEFAULT_PAYOUT_TRANSFERS_PER_TX,
+		Concurrency:       DEFAULT_PAYOUT_CONCURRENCY,
+		Outbox:            outbox,
+		tesraSdk:          tesraSdk,
+	}
+}
+
+func (this *BatchPayout) getVersion() (byte, error) {
+	switch this.Asset {
+	case TSR_CONTRACT_ADDRESS:
+		return TSR_CONTRACT_VERSION, nil
+	case TSG_CONTRACT_ADDRESS:
+		return TSG_CONTRACT_VERSION, nil
+	default:
+		return 0, fmt.Errorf("unsupport asset:%s", this.Asset.ToHexString())
+	}
+}
+
+func (this *BatchPayout) getGasLimit(count int) uint64 {
+	gasLimit := this.GasPerTransfer * uint64(count)
+	if gasLimit < MIN_TX_GAS_LIMIT {
+		gasLimit = MIN_TX_GAS_LIMIT
+	}
+	return gasLimit
+}
+
+//Plan split items into chunks, chunks are assigned to funders in turn. Plan is deterministic, the same
+//funders and items always get the same chunks.
+func (this *BatchPayout) Plan(funders []common.Address, items []*PayoutItem) ([]*PayoutChunk, error) {
+	if len(funders) == 0 {
+		return nil, fmt.Errorf("no funding account")
+	}
+	maxCount := this.MaxTransfersPerTx
+	if maxCount <= 0 {
+		maxCount = DEFAULT_PAYOUT_TRANSFERS_PER_TX
+	}
+	if this.MaxTxSize > 0 {
+		sizeCount := (this.MaxTxSize - payout_tx_overhead_size) / payout_state_size
+		if sizeCount < maxCount {
+			maxCount = sizeCount
+		}
+	}
+	if this.MaxGasLimit > 0 && this.GasPerTransfer > 0 {
+		gasCount := int(this.MaxGasLimit / this.GasPerTransfer)
+		if gasCount < maxCount {
+			maxCount = gasCount
+		}
+	}
+	if maxCount <= 0 {
+		return nil, fmt.Errorf("size and gas limits are too small for one transfer")
+	}
+	chunks := make([]*PayoutChunk, 0, len(items)/maxCount+1)
+	for start := 0; start < len(items); start += maxCount {
+		end := start + maxCount
+		if end > len(items) {
+			end = len(items)
+		}
+		index := len(chunks)
+		chunk := &PayoutChunk{
+			Index:    index,
+			From:     funders[index%len(funders)],
+			Items:    make([]int, 0, end-start),
+			GasLimit: this.getGasLimit(end - start),
+		}
+		for i := start; i < end; i++ {
+			if items[i].Amount == 0 {
+				return nil, fmt.Errorf("amount of item %d is zero", i)
+			}
+			chunk.Items = append(chunk.Items, i)
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
+
+//NewChunkTransaction return the unsigned multi transfer transaction of chunk
+func (this *BatchPayout) NewChunkTransaction(chunk *PayoutChunk, items []*PayoutItem) (*types.MutableTransaction, error) {
+	version, err := this.getVersion()
+	if err != nil {
+		return nil, err
+	}
+	states := make([]*tsr.State, 0, len(chunk.Items))
+	for _, i := range chunk.Items {
+		states = append(states, &tsr.State{From: chunk.From, To: items[i].To, Value: items[i].Amount})
+	}
+	tx, err := this.tesraSdk.Native.NewNativeInvokeTransaction(this.GasPrice, chunk.GasLimit, version, this.Asset,
+		tsr.TRANSFER_NAME, []interface{}{states})
+	if err != nil {
+		return nil, err
+	}
+	this.tesraSdk.SetPayer(tx, chunk.From)
+	return tx, nil
+}
+
+//Pay send items from funders, and return the status of every item in the order of items.
+//batchId identifies this payout in Outbox, and should be unique for different payouts.
+func (this *BatchPayout) Pay(batchId string, funders []Signer, items []*PayoutItem) ([]*PayoutStatus, error) {
+	fundAddrs := make([]common.Address, 0, len(funders))
+	signers := make(map[common.Address]Signer, len(funders))
+	for _, funder := range funders {
+		addr := types.AddressFromPubKey(funder.GetPublicKey())
+		fundAddrs = append(fundAddrs, addr)
+		signers[addr] = funder
+	}
+	chunks, err := this.Plan(fundAddrs, items)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]*PayoutStatus, len(items))
+	for _, chunk := range chunks {
+		for _, i := range chunk.Items {
+			statuses[i] = &PayoutStatus{
+				From:   chunk.From.ToBase58(),
+				To:     items[i].To.ToBase58(),
+				Amount: items[i].Amount,
+				State:  PAYOUT_PENDING,
+				key:    fmt.Sprintf("%s:%d", batchId, chunk.Index),
+			}
+		}
+	}
+	concurrency := this.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	wg := &sync.WaitGroup{}
+	for _, chunk := range chunks {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(chunk *PayoutChunk) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			txHash, state, err := this.payChunk(batchId, chunk, items, signers[chunk.From])
+			for _, i := range chunk.Items {
+				statuses[i].TxHash = txHash
+				statuses[i].State = state
+				if err != nil {
+					statuses[i].Error = err.Error()
+				}
+			}
+		}(chunk)
+	}
+	wg.Wait()
+	return statuses, nil
+}
+
+//GetChunkDigest return the digest of asset, funder and items of chunk, which is recorded in Outbox to
+//make sure that the resumed chunk pays the same transfers
+func (this *BatchPayout) GetChunkDigest(chunk *PayoutChunk, items []*PayoutItem) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s:%s", this.Asset.ToHexString(), chunk.From.ToBase58())
+	for _, i := range chunk.Items {
+		fmt.Fprintf(h, ";%s:%d", items[i].To.ToBase58(), items[i].Amount)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+//getChunkTx return the tx of the latest attempt of chunk key in Outbox, and the key of next attempt
+func (this *BatchPayout) getChunkTx(key string) (*OutboxTx, string) {
+	var last *OutboxTx
+	for attempt := 0; ; attempt++ {
+		attemptKey := key
+		if attempt > 0 {
+			attemptKey = fmt.Sprintf("%s#%d", key, attempt)
+		}
+		otx := this.Outbox.GetTxByKey(attemptKey)
+		if otx == nil {
+			return last, attemptKey
+		}
+		last = otx
+	}
+}
+
+//isPayoutRetryable return whether chunk of otx is never paid and can be sent again by a new tx
+func isPayoutRetryable(otx *OutboxTx) bool {
+	return otx.State == OUTBOX_TX_EXPIRED || (otx.State == OUTBOX_TX_FAILED && otx.Height > 0)
+}
+
+func (this *BatchPayout) payChunk(batchId string, chunk *PayoutChunk, items []*PayoutItem, signer Signer) (string, string, error) {
+	key := fmt.Sprintf("%s:%d", batchId, chunk.Index)
+	digest := this.GetChunkDigest(chunk, items)
+	if this.Outbox != nil {
+		otx, nextKey := this.getChunkTx(key)
+		if otx != nil {
+			if otx.Metadata[PAYOUT_METADATA_DIGEST] != digest {
+				return otx.TxHash, PAYOUT_FAILED, fmt.Errorf("chunk:%d of batch:%s is recorded with different funder or items by tx:%s",
+					chunk.Index, batchId, otx.TxHash)
+			}
+			if !isPayoutRetryable(otx) {
+				return otx.TxHash, getPayoutState(otx), getOutboxTxError(otx)
+			}
+		}
+		key = nextKey
+	}
+	tx, err := this.NewChunkTransaction(chunk, items)
+	if err != nil {
+		return "", PAYOUT_FAILED, err
+	}
+	if this.Outbox != nil {
+		err = this.Outbox.AssignNonce(tx)
+		if err != nil {
+			return "", PAYOUT_PENDING, err
+		}
+	}
+	if this.MaxTxSize > 0 {
+		immutTx, err := tx.IntoImmutable()
+		if err != nil {
+			return "", PAYOUT_FAILED, err
+		}
+		if len(immutTx.Raw)+payout_tx_overhead_size > this.MaxTxSize {
+			return "", PAYOUT_FAILED, fmt.Errorf("tx size:%d exceed max size:%d", len(immutTx.Raw), this.MaxTxSize)
+		}
+	}
+	err = this.tesraSdk.SignToTransaction(tx, signer)
+	if err != nil {
+		return "", PAYOUT_FAILED, err
+	}
+	txHash := tx.Hash()
+	if this.Outbox != nil {
+		otx, err := this.Outbox.Send(key, tx, map[string]string{
+			PAYOUT_METADATA_BATCH_ID: batchId,
+			PAYOUT_METADATA_DIGEST:   digest,
+		})
+		if err != nil {
+			return txHash.ToHexString(), PAYOUT_PENDING, err
+		}
+		return otx.TxHash, getPayoutState(otx), getOutboxTxError(otx)
+	}
+	_, err = this.tesraSdk.SendTransaction(tx)
+	if err != nil {
+		return txHash.ToHexString(), PAYOUT_FAILED, err
+	}
+	return txHash.ToHexString(), PAYOUT_SENT, nil
+}
+
+//Refresh update statuses returned by Pay with the state of txs in Outbox
+func (this *BatchPayout) Refresh(statuses []*PayoutStatus) {
+	if this.Outbox == nil {
+		return
+	}
+	for _, status := range statuses {
+		if status == nil || status.key == "" {
+			continue
+		}
+		otx, _ := this.getChunkTx(status.key)
+		if otx == nil {
+			continue
+		}
+		status.TxHash = otx.TxHash
+		status.State = getPayoutState(otx)
+		status.Error = ""
+		if err := getOutboxTxError(otx); err != nil {
+			status.Error = err.Error()
+		}
+	}
+}
+
+func getPayoutState(otx *OutboxTx) string {
+	switch otx.State {
+	case OUTBOX_TX_CONFIRMED:
+		return PAYOUT_CONFIRMED
+	case OUTBOX_TX_FAILED, OUTBOX_TX_EXPIRED:
+		return PAYOUT_FAILED
+	case OUTBOX_TX_DROPPED:
+		return PAYOUT_DROPPED
+	default:
+		if otx.SendCount > 0 {
+			return PAYOUT_SENT
+		}
+		return PAYOUT_PENDING
+	}
+}
+
+func getOutboxTxError(otx *OutboxTx) error {
+	if otx.State == OUTBOX_TX_EXPIRED {
+		return fmt.Errorf("tx expired before broadcast")
+	}
+	if otx.LastError != "" {
+		return fmt.Errorf("%s", otx.LastError)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	sdkcom "github.com/TesraSupernet/tesrasdk/common"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBatchPayout_Plan(t *testing.T) {
+	sdk := NewTesraSdk()
+	bp := NewBatchPayout(sdk, TSG_CONTRACT_ADDRESS, 500, nil)
+	bp.MaxTransfersPerTx = 100
+	funder1 := NewAccount()
+	funder2 := NewAccount()
+	items := make([]*PayoutItem, 0, 1050)
+	for i := 0; i < 1050; i++ {
+		items = append(items, &PayoutItem{To: NewAccount().Address, Amount: uint64(i + 1)})
+	}
+	chunks, err := bp.Plan([]common.Address{funder1.Address, funder2.Address}, items)
+	assert.Nil(t, err)
+	assert.Equal(t, 11, len(chunks))
+	assert.Equal(t, funder1.Address, chunks[0].From)
+	assert.Equal(t, funder2.Address, chunks[1].From)
+	assert.Equal(t, 50, len(chunks[10].Items))
+	assert.Equal(t, 1049, chunks[10].Items[49])
+
+	tx, err := bp.NewChunkTransaction(chunks[3], items)
+	assert.Nil(t, err)
+	assert.Equal(t, funder2.Address, tx.Payer)
+	assert.Equal(t, uint64(100)*DEFAULT_PAYOUT_GAS_PER_TRANSFER, tx.GasLimit)
+	decoded, err := DecodeTransaction(tx)
+	assert.Nil(t, err)
+	states := decoded.Invoke.GetArg("states").Items
+	assert.Equal(t, 100, len(states))
+	assert.Equal(t, "301", states[0].GetItem("value").Value)
+
+	//gas limit bounds the transfer count
+	bp.MaxGasLimit = 10 * DEFAULT_PAYOUT_GAS_PER_TRANSFER
+	chunks, err = bp.Plan([]common.Address{funder1.Address}, items[:25])
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(chunks))
+
+	items[0].Amount = 0
+	_, err = bp.Plan([]common.Address{funder1.Address}, items[:25])
+	assert.NotNil(t, err)
+}
+
+//failSigner is a Signer of account which always fails to sign
+type failSigner struct {
+	*Account
+}
+
+func (this *failSigner) Sign(data []byte) ([]byte, error) {
+	return nil, fmt.Errorf("signer is offline")
+}
+
+func TestBatchPayout_PayResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "batch_payout_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	client := newStubOutboxClient()
+	outbox, err := OpenOutbox(filepath.Join(dir, "outbox.json"), NewTesraSdk())
+	assert.Nil(t, err)
+	outbox.SetClient(client)
+	bp := NewBatchPayout(NewTesraSdk(), TSG_CONTRACT_ADDRESS, 500, outbox)
+	bp.MaxTransfersPerTx = 10
+	funder1 := NewAccount()
+	funder2 := NewAccount()
+	items := make([]*PayoutItem, 0, 40)
+	for i := 0; i < 40; i++ {
+		items = append(items, &PayoutItem{To: NewAccount().Address, Amount: uint64(i + 1)})
+	}
+
+	//chunks of funder2 fail in the first run
+	statuses, err := bp.Pay("batch1", []Signer{funder1, &failSigner{funder2}}, items)
+	assert.Nil(t, err)
+	assert.Equal(t, 40, len(statuses))
+	for i, status := range statuses {
+		if (i/10)%2 == 0 {
+			assert.Equal(t, PAYOUT_SENT, status.State)
+			assert.Equal(t, "", status.Error)
+		} else {
+			assert.Equal(t, PAYOUT_FAILED, status.State)
+			assert.NotEqual(t, "", status.Error)
+		}
+	}
+	assert.Equal(t, 2, client.getTotalSendCount())
+	sentHashes := []string{statuses[0].TxHash, statuses[20].TxHash}
+
+	//resume with the same batchId, sent chunks are not sent again
+	statuses, err = bp.Pay("batch1", []Signer{funder1, funder2}, items)
+	assert.Nil(t, err)
+	for _, status := range statuses {
+		assert.Equal(t, PAYOUT_SENT, status.State)
+	}
+	assert.Equal(t, sentHashes[0], statuses[0].TxHash)
+	assert.Equal(t, sentHashes[1], statuses[20].TxHash)
+	assert.Equal(t, 4, client.getTotalSendCount())
+	for _, i := range []int{0, 10, 20, 30} {
+		assert.Equal(t, 1, client.getSendCount(statuses[i].TxHash))
+	}
+
+	statuses, err = bp.Pay("batch1", []Signer{funder1, funder2}, items)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, client.getTotalSendCount())
+
+	client.heights[statuses[0].TxHash] = 10
+	client.events[statuses[0].TxHash] = &sdkcom.SmartContactEvent{TxHash: statuses[0].TxHash, State: 1}
+	assert.Nil(t, outbox.Process())
+	bp.Refresh(statuses)
+	assert.Equal(t, PAYOUT_CONFIRMED, statuses[0].State)
+	assert.Equal(t, PAYOUT_SENT, statuses[10].State)
+
+	//chunk broadcast but never packed is shown as dropped
+	otx := outbox.GetTx(statuses[10].TxHash)
+	outbox.lock.Lock()
+	outbox.txHashMap[otx.TxHash].ExpireTime = otx.LastSendTime - 1
+	outbox.lock.Unlock()
+	outbox.ResendTimeout = 0
+	outbox.DropGrace = 0
+	assert.Nil(t, outbox.Process())
+	bp.Refresh(statuses)
+	assert.Equal(t, PAYOUT_DROPPED, statuses[10].State)
+	assert.NotEqual(t, "", statuses[10].Error)
+}
+
+func TestBatchPayout_PayRetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "batch_payout_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	client := newStubOutboxClient()
+	outbox, err := OpenOutbox(filepath.Join(dir, "outbox.json"), NewTesraSdk())
+	assert.Nil(t, err)
+	outbox.SetClient(client)
+	bp := NewBatchPayout(NewTesraSdk(), TSG_CONTRACT_ADDRESS, 500, outbox)
+	bp.MaxTransfersPerTx = 10
+	funder1 := NewAccount()
+	funder2 := NewAccount()
+	items := make([]*PayoutItem, 0, 30)
+	for i := 0; i < 30; i++ {
+		items = append(items, &PayoutItem{To: NewAccount().Address, Amount: uint64(i + 1)})
+	}
+	statuses, err := bp.Pay("batch1", []Signer{funder1, funder2}, items)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, client.getTotalSendCount())
+	hashes := []string{statuses[0].TxHash, statuses[10].TxHash, statuses[20].TxHash}
+
+	//the same batchId with other items or funder order is refused
+	changed := make([]*PayoutItem, 0, len(items))
+	for _, item := range items {
+		changed = append(changed, &PayoutItem{To: item.To, Amount: item.Amount})
+	}
+	changed[0].Amount++
+	statuses, err = bp.Pay("batch1", []Signer{funder1, funder2}, changed)
+	assert.Nil(t, err)
+	assert.Equal(t, PAYOUT_FAILED, statuses[0].State)
+	assert.Contains(t, statuses[0].Error, "different funder or items")
+	assert.Equal(t, PAYOUT_SENT, statuses[10].State)
+	statuses, err = bp.Pay("batch1", []Signer{funder2, funder1}, items)
+	assert.Nil(t, err)
+	for _, i := range []int{0, 10, 20} {
+		assert.Equal(t, PAYOUT_FAILED, statuses[i].State)
+	}
+	assert.Equal(t, 3, client.getTotalSendCount())
+
+	//chunk failed on chain and chunk expired before broadcast are paid again by new txs
+	client.heights[hashes[0]] = 10
+	client.events[hashes[0]] = &sdkcom.SmartContactEvent{TxHash: hashes[0], State: 0}
+	assert.Nil(t, outbox.Process())
+	assert.Equal(t, OUTBOX_TX_FAILED, outbox.GetTx(hashes[0]).State)
+	outbox.lock.Lock()
+	outbox.txHashMap[hashes[1]].State = OUTBOX_TX_EXPIRED
+	outbox.lock.Unlock()
+	statuses, err = bp.Pay("batch1", []Signer{funder1, funder2}, items)
+	assert.Nil(t, err)
+	for _, i := range []int{0, 10, 20} {
+		assert.Equal(t, PAYOUT_SENT, statuses[i].State)
+	}
+	assert.NotEqual(t, hashes[0], statuses[0].TxHash)
+	assert.NotEqual(t, hashes[1], statuses[10].TxHash)
+	assert.Equal(t, hashes[2], statuses[20].TxHash)
+	assert.Equal(t, 5, client.getTotalSendCount())
+	retried := outbox.GetTxByKey("batch1:0#1")
+	assert.NotNil(t, retried)
+	assert.Equal(t, statuses[0].TxHash, retried.TxHash)
+
+	//retried chunks are not paid again
+	statuses, err = bp.Pay("batch1", []Signer{funder1, funder2}, items)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, client.getTotalSendCount())
+	client.heights[retried.TxHash] = 11
+	client.events[retried.TxHash] = &sdkcom.SmartContactEvent{TxHash: retried.TxHash, State: 1}
+	assert.Nil(t, outbox.Process())
+	bp.Refresh(statuses)
+	assert.Equal(t, PAYOUT_CONFIRMED, statuses[0].State)
+	assert.Equal(t, retried.TxHash, statuses[0].TxHash)
+}
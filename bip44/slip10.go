@@ -0,0 +1,153 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package bip44
+
+import (
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"github.com/TesraSupernet/tesracrypto/sm2"
+	"golang.org/x/crypto/ed25519"
+	"math/big"
+)
+
+//https://github.com/satoshilabs/slips/blob/master/slip-0010.md
+
+const FirstHardenedIndex uint32 = 0x80000000
+
+//Slip10Curve is the curve of SLIP-0010 derivation, Curve is nil for ed25519 which supports hardened derivation only
+type Slip10Curve struct {
+	Seed  string //hmac key of master key
+	Curve elliptic.Curve
+}
+
+var (
+	//Nist256p1 is the same as the go-bip32 of TesraSupernet, which is used by NewKeyFromMasterKey
+	Nist256p1 = &Slip10Curve{Seed: "Nist256p1 seed", Curve: elliptic.P256()}
+	//Sm2p256v1 is not defined by SLIP-0010, it follows the derivation of Nist256p1 on SM2 curve
+	Sm2p256v1 = &Slip10Curve{Seed: "sm2p256v1 seed", Curve: sm2.SM2P256V1()}
+	Ed25519   = &Slip10Curve{Seed: "ed25519 seed"}
+)
+
+//Slip10Key is the private key and chain code of SLIP-0010 node
+type Slip10Key struct {
+	Key       []byte
+	ChainCode []byte
+	Depth     byte
+	curve     *Slip10Curve
+}
+
+//NewSlip10MasterKey return the master key of seed on curve
+func NewSlip10MasterKey(curve *Slip10Curve, seed []byte) (*Slip10Key, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("invalid seed length:%d", len(seed))
+	}
+	data := seed
+	for {
+		mac := hmac.New(sha512.New, []byte(curve.Seed))
+		mac.Write(data)
+		digest := mac.Sum(nil)
+		if curve.Curve == nil || curve.isValidKey(digest[:32]) {
+			return &Slip10Key{Key: digest[:32], ChainCode: digest[32:], curve: curve}, nil
+		}
+		data = digest
+	}
+}
+
+//NewSlip10KeyFromPath derive the key of indexes from seed on curve
+func NewSlip10KeyFromPath(curve *Slip10Curve, seed []byte, indexes []uint32) (*Slip10Key, error) {
+	key, err := NewSlip10MasterKey(curve, seed)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range indexes {
+		key, err = key.NewChildKey(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+//NewChildKey return the child private key of index, index below FirstHardenedIndex is not allowed on ed25519
+func (this *Slip10Key) NewChildKey(index uint32) (*Slip10Key, error) {
+	hardened := index >= FirstHardenedIndex
+	if !hardened && this.curve.Curve == nil {
+		return nil, fmt.Errorf("%s only supports hardened derivation", this.curve.Seed)
+	}
+	indexBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(indexBytes, index)
+	var data []byte
+	if hardened {
+		data = append([]byte{0}, this.Key...)
+	} else {
+		data = this.PublicKeyBytes()
+	}
+	data = append(data, indexBytes...)
+	for {
+		mac := hmac.New(sha512.New, this.ChainCode)
+		mac.Write(data)
+		digest := mac.Sum(nil)
+		child := &Slip10Key{ChainCode: digest[32:], Depth: this.Depth + 1, curve: this.curve}
+		if this.curve.Curve == nil {
+			child.Key = digest[:32]
+			return child, nil
+		}
+		if this.curve.isValidKey(digest[:32]) {
+			n := this.curve.Curve.Params().N
+			k := new(big.Int).SetBytes(digest[:32])
+			k.Add(k, new(big.Int).SetBytes(this.Key))
+			k.Mod(k, n)
+			if k.Sign() != 0 {
+				child.Key = paddedBytes(k.Bytes(), 32)
+				return child, nil
+			}
+		}
+		data = append(append([]byte{1}, digest[32:]...), indexBytes...)
+	}
+}
+
+//PublicKeyBytes return the compressed public key, or 0x00 and the public key on ed25519
+func (this *Slip10Key) PublicKeyBytes() []byte {
+	if this.curve.Curve == nil {
+		pubKey := ed25519.NewKeyFromSeed(this.Key).Public().(ed25519.PublicKey)
+		return append([]byte{0}, pubKey...)
+	}
+	x, y := this.curve.Curve.ScalarBaseMult(this.Key)
+	prefix := byte(2)
+	if y.Bit(0) == 1 {
+		prefix = 3
+	}
+	return append([]byte{prefix}, paddedBytes(x.Bytes(), 32)...)
+}
+
+func (this *Slip10Curve) isValidKey(key []byte) bool {
+	k := new(big.Int).SetBytes(key)
+	return k.Sign() != 0 && k.Cmp(this.Curve.Params().N) < 0
+}
+
+func paddedBytes(data []byte, size int) []byte {
+	if len(data) >= size {
+		return data
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(data):], data)
+	return padded
+}
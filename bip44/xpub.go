@@ -0,0 +1,103 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package bip44
+
+import (
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/go-bip32"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+)
+
+//AccountKeyDepth is the depth of account level key m/44'/coin'/account'
+const AccountKeyDepth = 3
+
+//NewAccountKeyFromMasterKey return the account level key m/44'/coin'/account', coin and account are hardened index
+func NewAccountKeyFromMasterKey(masterKey *bip32.Key, coin, account uint32) (*bip32.Key, error) {
+	child, err := masterKey.NewChildKey(Purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	child, err = child.NewChildKey(coin)
+	if err != nil {
+		return nil, err
+	}
+
+	return child.NewChildKey(account)
+}
+
+//ExportAccountXPub return the base58 extended public key of account level key m/44'/coin'/account'
+func ExportAccountXPub(masterKey *bip32.Key, coin, account uint32) (string, error) {
+	key, err := NewAccountKeyFromMasterKey(masterKey, coin, account)
+	if err != nil {
+		return "", err
+	}
+	return key.PublicKey().B58Serialize(), nil
+}
+
+//ImportXPub parse base58 extended public key of account level, extended private key is refused
+func ImportXPub(xpub string) (*bip32.Key, error) {
+	key, err := bip32.B58Deserialize(xpub)
+	if err != nil {
+		return nil, fmt.Errorf("deserialize xpub error:%s", err)
+	}
+	if key.IsPrivate {
+		return nil, fmt.Errorf("extended private key is not allowed")
+	}
+	if key.Depth != AccountKeyDepth {
+		return nil, fmt.Errorf("xpub depth:%d is not account level", key.Depth)
+	}
+	return key, nil
+}
+
+//NewPublicKeyFromXPub derive the public key of chain/address under account level xpub, chain and address cannot be hardened
+func NewPublicKeyFromXPub(xpub *bip32.Key, chain, address uint32) (*bip32.Key, error) {
+	if xpub.IsPrivate {
+		xpub = xpub.PublicKey()
+	}
+	child, err := xpub.NewChildKey(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	return child.NewChildKey(address)
+}
+
+//GetPublicKey return the ECDSA P-256 public key of bip32 key. The go-bip32 of TesraSupernet works on P-256,
+//so it is the same as the public key of the private key derived by NewKeyFromMasterKey
+func GetPublicKey(key *bip32.Key) (keypair.PublicKey, error) {
+	if key.IsPrivate {
+		key = key.PublicKey()
+	}
+	pubKey, err := keypair.DeserializePublicKey(key.Key)
+	if err != nil {
+		return nil, fmt.Errorf("deserialize public key error:%s", err)
+	}
+	return pubKey, nil
+}
+
+//GetAddress return the Tesra address of bip32 key
+func GetAddress(key *bip32.Key) (common.Address, error) {
+	pubKey, err := GetPublicKey(key)
+	if err != nil {
+		return common.ADDRESS_EMPTY, err
+	}
+	return types.AddressFromPubKey(pubKey), nil
+}
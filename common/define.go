@@ -55,17 +55,20 @@ type PreExecResult struct {
 	State  byte
 	Gas    uint64
 	Result *ResultItem
+	Notify []*NotifyEventInfo
 }
 
 func (this *PreExecResult) UnmarshalJSON(data []byte) (err error) {
 	var state byte
 	var gas uint64
 	var resultItem *ResultItem
+	var notify []*NotifyEventInfo
 	defer func() {
 		if err == nil {
 			this.State = state
 			this.Gas = gas
 			this.Result = resultItem
+			this.Notify = notify
 		}
 	}()
 
@@ -87,6 +90,17 @@ func (this *PreExecResult) UnmarshalJSON(data []byte) (err error) {
 		return
 	}
 	gas = uint64(gasField)
+	if objects["Notify"] != nil {
+		notifyField := &struct {
+			Notify []*NotifyEventInfo
+		}{}
+		err = json.Unmarshal(data, notifyField)
+		if err != nil {
+			err = fmt.Errorf("Parse Notify field failed, %s", err)
+			return
+		}
+		notify = notifyField.Notify
+	}
 	resultField, ok := objects["Result"]
 	if !ok {
 		return nil
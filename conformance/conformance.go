@@ -0,0 +1,483 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+//Package conformance runs the cross-SDK test vectors of address, native invoke code and transaction encoding.
+//The same vectors file is shared with other SDKs, so that encoding regressions can be caught without a node.
+package conformance
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/core/payload"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	s "github.com/TesraSupernet/tesracrypto/signature"
+	tesra_go_sdk "github.com/TesraSupernet/tesrasdk"
+	"io/ioutil"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+const (
+	PARAM_TYPE_ADDRESS = "address" //base58 address
+	PARAM_TYPE_INTEGER = "integer" //decimal unsigned integer
+	PARAM_TYPE_STRING  = "string"
+	PARAM_TYPE_BYTES   = "bytes" //hex string
+	PARAM_TYPE_BOOL    = "bool"  //true or false
+	PARAM_TYPE_ARRAY   = "array"
+	PARAM_TYPE_STRUCT  = "struct"
+)
+
+//Param is the language neutral description of invoke param
+type Param struct {
+	Type  string   `json:"type"`
+	Value string   `json:"value,omitempty"`
+	Items []*Param `json:"items,omitempty"`
+}
+
+//AddressVector is the vector of address from public key, and public key from private key if PrivateKey is not empty
+type AddressVector struct {
+	Name       string `json:"name"`
+	PrivateKey string `json:"privateKey,omitempty"`
+	PublicKey  string `json:"publicKey"`
+	Address    string `json:"address"`
+	AddressHex string `json:"addressHex"`
+}
+
+//MultiAddressVector is the vector of multi-sign address, Program is the multi-sign verification program
+type MultiAddressVector struct {
+	Name       string   `json:"name"`
+	PublicKeys []string `json:"publicKeys"`
+	M          int      `json:"m"`
+	Program    string   `json:"program"`
+	Address    string   `json:"address"`
+}
+
+//NativeInvokeVector is the vector of native invoke code. Contract is the contract address in hex.
+type NativeInvokeVector struct {
+	Name     string   `json:"name"`
+	Contract string   `json:"contract"`
+	Version  byte     `json:"version"`
+	Method   string   `json:"method"`
+	Params   []*Param `json:"params"`
+	Code     string   `json:"code"`
+}
+
+//TxVector is the vector of invoke transaction. Invoke is the name of NativeInvokeVector as payload, RawTx is the
+//unsigned transaction returned by GetTxData. Signers are private keys to sign the transaction, since ECDSA signature
+//is not deterministic, signed transactions are checked by verifying signatures.
+type TxVector struct {
+	Name     string   `json:"name"`
+	Invoke   string   `json:"invoke"`
+	Nonce    uint32   `json:"nonce"`
+	GasPrice uint64   `json:"gasPrice"`
+	GasLimit uint64   `json:"gasLimit"`
+	Payer    string   `json:"payer"`
+	RawTx    string   `json:"rawTx"`
+	TxHash   string   `json:"txHash"`
+	Signers  []string `json:"signers,omitempty"`
+}
+
+//Vectors is the content of vectors file
+type Vectors struct {
+	Version        int                   `json:"version"`
+	Addresses      []*AddressVector      `json:"addresses"`
+	MultiAddresses []*MultiAddressVector `json:"multiAddresses"`
+	NativeInvokes  []*NativeInvokeVector `json:"nativeInvokes"`
+	Transactions   []*TxVector           `json:"transactions"`
+}
+
+//Failure is a mismatch between vector and the result of sdk
+type Failure struct {
+	Vector   string
+	Field    string
+	Expected string
+	Actual   string
+}
+
+func (this *Failure) String() string {
+	return fmt.Sprintf("vector:%s field:%s expected:%s actual:%s", this.Vector, this.Field, this.Expected, this.Actual)
+}
+
+//LoadVectors load vectors from file
+func LoadVectors(file string) (*Vectors, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read vectors file:%s error:%s", file, err)
+	}
+	vectors := &Vectors{}
+	err = json.Unmarshal(data, vectors)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal vectors error:%s", err)
+	}
+	return vectors, nil
+}
+
+//Save save vectors to file
+func (this *Vectors) Save(file string) error {
+	data, err := json.MarshalIndent(this, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json.Marshal vectors error:%s", err)
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+//Runner runs vectors against TesraSdk
+type Runner struct {
+	sdk      *tesra_go_sdk.TesraSdk
+	failures []*Failure
+}
+
+//NewRunner return a Runner
+func NewRunner(sdk *tesra_go_sdk.TesraSdk) *Runner {
+	return &Runner{sdk: sdk}
+}
+
+func (this *Runner) check(vector, field, expected, actual string) {
+	if expected != actual {
+		this.failures = append(this.failures, &Failure{Vector: vector, Field: field, Expected: expected, Actual: actual})
+	}
+}
+
+func (this *Runner) fail(vector, field string, err error) {
+	this.failures = append(this.failures, &Failure{Vector: vector, Field: field, Actual: fmt.Sprintf("error:%s", err)})
+}
+
+//Run run all the vectors and return the failures, empty failures means all vectors pass
+func (this *Runner) Run(vectors *Vectors) []*Failure {
+	this.failures = make([]*Failure, 0)
+	for _, vector := range vectors.Addresses {
+		this.runAddress(vector)
+	}
+	for _, vector := range vectors.MultiAddresses {
+		this.runMultiAddress(vector)
+	}
+	invokes := make(map[string]*NativeInvokeVector, len(vectors.NativeInvokes))
+	for _, vector := range vectors.NativeInvokes {
+		invokes[vector.Name] = vector
+		this.runNativeInvoke(vector)
+	}
+	for _, vector := range vectors.Transactions {
+		invoke, ok := invokes[vector.Invoke]
+		if !ok {
+			this.fail(vector.Name, "invoke", fmt.Errorf("cannot find invoke vector:%s", vector.Invoke))
+			continue
+		}
+		this.runTx(vector, invoke)
+	}
+	return this.failures
+}
+
+func (this *Runner) runAddress(vector *AddressVector) {
+	pubKey, err := parsePublicKey(vector.PublicKey)
+	if err != nil {
+		this.fail(vector.Name, "publicKey", err)
+		return
+	}
+	address := types.AddressFromPubKey(pubKey)
+	this.check(vector.Name, "address", vector.Address, address.ToBase58())
+	this.check(vector.Name, "addressHex", vector.AddressHex, address.ToHexString())
+	this.check(vector.Name, "GetAdddrByPubKey", vector.Address, this.sdk.GetAdddrByPubKey(pubKey))
+	if vector.PrivateKey == "" {
+		return
+	}
+	acc, err := newAccount(vector.PrivateKey)
+	if err != nil {
+		this.fail(vector.Name, "privateKey", err)
+		return
+	}
+	this.check(vector.Name, "privateKey.publicKey", vector.PublicKey, hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey)))
+	this.check(vector.Name, "privateKey.address", vector.Address, acc.Address.ToBase58())
+}
+
+func (this *Runner) runMultiAddress(vector *MultiAddressVector) {
+	pubKeys, err := parsePublicKeys(vector.PublicKeys)
+	if err != nil {
+		this.fail(vector.Name, "publicKeys", err)
+		return
+	}
+	address, err := types.AddressFromMultiPubKeys(pubKeys, vector.M)
+	if err != nil {
+		this.fail(vector.Name, "AddressFromMultiPubKeys", err)
+		return
+	}
+	this.check(vector.Name, "address", vector.Address, address.ToBase58())
+	multiAddr, err := this.sdk.GetMultiAddr(pubKeys, vector.M)
+	if err != nil {
+		this.fail(vector.Name, "GetMultiAddr", err)
+		return
+	}
+	this.check(vector.Name, "GetMultiAddr", vector.Address, multiAddr)
+	program, err := hex.DecodeString(vector.Program)
+	if err != nil {
+		this.fail(vector.Name, "program", err)
+		return
+	}
+	this.check(vector.Name, "program.address", vector.Address, common.AddressFromVmCode(program).ToBase58())
+}
+
+func (this *Runner) runNativeInvoke(vector *NativeInvokeVector) {
+	tx, err := this.newNativeInvokeTransaction(vector, 0, 0)
+	if err != nil {
+		this.fail(vector.Name, "NewNativeInvokeTransaction", err)
+		return
+	}
+	this.check(vector.Name, "code", vector.Code, getInvokeCode(tx))
+}
+
+func (this *Runner) runTx(vector *TxVector, invoke *NativeInvokeVector) {
+	tx, err := this.newNativeInvokeTransaction(invoke, vector.GasPrice, vector.GasLimit)
+	if err != nil {
+		this.fail(vector.Name, "NewNativeInvokeTransaction", err)
+		return
+	}
+	payer, err := common.AddressFromBase58(vector.Payer)
+	if err != nil {
+		this.fail(vector.Name, "payer", err)
+		return
+	}
+	tx.Nonce = vector.Nonce
+	this.sdk.SetPayer(tx, payer)
+	rawTx, err := this.sdk.GetTxData(tx)
+	if err != nil {
+		this.fail(vector.Name, "GetTxData", err)
+		return
+	}
+	this.check(vector.Name, "rawTx", vector.RawTx, rawTx)
+	txHash := tx.Hash()
+	this.check(vector.Name, "txHash", vector.TxHash, txHash.ToHexString())
+
+	decodedTx, err := this.sdk.GetMutableTx(vector.RawTx)
+	if err != nil {
+		this.fail(vector.Name, "GetMutableTx", err)
+	} else {
+		decodedHash := decodedTx.Hash()
+		this.check(vector.Name, "GetMutableTx.txHash", vector.TxHash, decodedHash.ToHexString())
+	}
+
+	if len(vector.Signers) == 0 {
+		return
+	}
+	for _, privateKey := range vector.Signers {
+		acc, err := newAccount(privateKey)
+		if err != nil {
+			this.fail(vector.Name, "signers", err)
+			return
+		}
+		err = this.sdk.SignToTransaction(tx, acc)
+		if err != nil {
+			this.fail(vector.Name, "SignToTransaction", err)
+			return
+		}
+	}
+	signedHash := tx.Hash()
+	this.check(vector.Name, "signed.txHash", vector.TxHash, signedHash.ToHexString())
+	this.check(vector.Name, "signed.sigCount", strconv.Itoa(len(vector.Signers)), strconv.Itoa(len(tx.Sigs)))
+	err = tesra_go_sdk.VerifyTransaction(tx)
+	if err != nil {
+		this.fail(vector.Name, "VerifyTransaction", err)
+	}
+}
+
+func (this *Runner) newNativeInvokeTransaction(vector *NativeInvokeVector, gasPrice, gasLimit uint64) (*types.MutableTransaction, error) {
+	contract, err := common.AddressFromHexString(vector.Contract)
+	if err != nil {
+		return nil, fmt.Errorf("invalid contract:%s", vector.Contract)
+	}
+	params, err := BuildParams(vector.Params)
+	if err != nil {
+		return nil, err
+	}
+	return this.sdk.Native.NewNativeInvokeTransaction(gasPrice, gasLimit, vector.Version, contract, vector.Method, params)
+}
+
+//Update fill the expected fields of vectors with the result of sdk. It is used to generate vectors
+//after adding new inputs, the generated vectors must be reviewed and cross checked with other SDKs.
+func (this *Runner) Update(vectors *Vectors) error {
+	for _, vector := range vectors.Addresses {
+		if vector.PrivateKey != "" {
+			acc, err := newAccount(vector.PrivateKey)
+			if err != nil {
+				return fmt.Errorf("vector:%s error:%s", vector.Name, err)
+			}
+			vector.PublicKey = hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey))
+		}
+		pubKey, err := parsePublicKey(vector.PublicKey)
+		if err != nil {
+			return fmt.Errorf("vector:%s error:%s", vector.Name, err)
+		}
+		address := types.AddressFromPubKey(pubKey)
+		vector.Address = address.ToBase58()
+		vector.AddressHex = address.ToHexString()
+	}
+	for _, vector := range vectors.MultiAddresses {
+		pubKeys, err := parsePublicKeys(vector.PublicKeys)
+		if err != nil {
+			return fmt.Errorf("vector:%s error:%s", vector.Name, err)
+		}
+		address, err := types.AddressFromMultiPubKeys(pubKeys, vector.M)
+		if err != nil {
+			return fmt.Errorf("vector:%s AddressFromMultiPubKeys error:%s", vector.Name, err)
+		}
+		vector.Address = address.ToBase58()
+	}
+	invokes := make(map[string]*NativeInvokeVector, len(vectors.NativeInvokes))
+	for _, vector := range vectors.NativeInvokes {
+		tx, err := this.newNativeInvokeTransaction(vector, 0, 0)
+		if err != nil {
+			return fmt.Errorf("vector:%s error:%s", vector.Name, err)
+		}
+		vector.Code = getInvokeCode(tx)
+		invokes[vector.Name] = vector
+	}
+	for _, vector := range vectors.Transactions {
+		invoke, ok := invokes[vector.Invoke]
+		if !ok {
+			return fmt.Errorf("vector:%s cannot find invoke vector:%s", vector.Name, vector.Invoke)
+		}
+		tx, err := this.newNativeInvokeTransaction(invoke, vector.GasPrice, vector.GasLimit)
+		if err != nil {
+			return fmt.Errorf("vector:%s error:%s", vector.Name, err)
+		}
+		payer, err := common.AddressFromBase58(vector.Payer)
+		if err != nil {
+			return fmt.Errorf("vector:%s invalid payer:%s", vector.Name, vector.Payer)
+		}
+		tx.Nonce = vector.Nonce
+		this.sdk.SetPayer(tx, payer)
+		vector.RawTx, err = this.sdk.GetTxData(tx)
+		if err != nil {
+			return fmt.Errorf("vector:%s error:%s", vector.Name, err)
+		}
+		txHash := tx.Hash()
+		vector.TxHash = txHash.ToHexString()
+	}
+	return nil
+}
+
+func getInvokeCode(tx *types.MutableTransaction) string {
+	invokeCode, ok := tx.Payload.(*payload.InvokeCode)
+	if !ok {
+		return ""
+	}
+	return hex.EncodeToString(invokeCode.Code)
+}
+
+func parsePublicKey(pk string) (keypair.PublicKey, error) {
+	data, err := hex.DecodeString(pk)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key:%s", err)
+	}
+	pubKey, err := keypair.DeserializePublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("DeserializePublicKey error:%s", err)
+	}
+	return pubKey, nil
+}
+
+func parsePublicKeys(pks []string) ([]keypair.PublicKey, error) {
+	pubKeys := make([]keypair.PublicKey, 0, len(pks))
+	for _, pk := range pks {
+		pubKey, err := parsePublicKey(pk)
+		if err != nil {
+			return nil, err
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+	return pubKeys, nil
+}
+
+func newAccount(privateKey string) (*tesra_go_sdk.Account, error) {
+	data, err := hex.DecodeString(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key:%s", err)
+	}
+	return tesra_go_sdk.NewAccountFromPrivateKey(data, s.SHA256withECDSA)
+}
+
+//BuildParams convert Params to the params of NewNativeInvokeTransaction. Integer is converted to uint64 if possible,
+//otherwise *big.Int, struct is converted to a struct value with the items as fields in order.
+func BuildParams(params []*Param) ([]interface{}, error) {
+	values := make([]interface{}, 0, len(params))
+	for _, param := range params {
+		value, err := buildParam(param)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func buildParam(param *Param) (interface{}, error) {
+	switch param.Type {
+	case PARAM_TYPE_ADDRESS:
+		addr, err := common.AddressFromBase58(param.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address:%s", param.Value)
+		}
+		return addr, nil
+	case PARAM_TYPE_INTEGER:
+		value, ok := new(big.Int).SetString(param.Value, 10)
+		if !ok || value.Sign() < 0 {
+			return nil, fmt.Errorf("invalid integer:%s", param.Value)
+		}
+		if value.IsUint64() {
+			return value.Uint64(), nil
+		}
+		return value, nil
+	case PARAM_TYPE_STRING:
+		return param.Value, nil
+	case PARAM_TYPE_BYTES:
+		data, err := hex.DecodeString(param.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bytes:%s", param.Value)
+		}
+		return data, nil
+	case PARAM_TYPE_BOOL:
+		value, err := strconv.ParseBool(param.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bool:%s", param.Value)
+		}
+		return value, nil
+	case PARAM_TYPE_ARRAY:
+		return BuildParams(param.Items)
+	case PARAM_TYPE_STRUCT:
+		items, err := BuildParams(param.Items)
+		if err != nil {
+			return nil, err
+		}
+		fields := make([]reflect.StructField, 0, len(items))
+		for i, item := range items {
+			fields = append(fields, reflect.StructField{
+				Name: fmt.Sprintf("Field%d", i),
+				Type: reflect.TypeOf(item),
+			})
+		}
+		value := reflect.New(reflect.StructOf(fields)).Elem()
+		for i, item := range items {
+			value.Field(i).Set(reflect.ValueOf(item))
+		}
+		return value.Interface(), nil
+	default:
+		return nil, fmt.Errorf("unknown param type:%s", param.Type)
+	}
+}
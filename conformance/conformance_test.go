@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package conformance
+
+import (
+	"flag"
+	tesra_go_sdk "github.com/TesraSupernet/tesrasdk"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the expected values of vectors.json")
+
+const vectorsFile = "vectors.json"
+
+func TestVectors(t *testing.T) {
+	vectors, err := LoadVectors(vectorsFile)
+	assert.Nil(t, err)
+	runner := NewRunner(tesra_go_sdk.NewTesraSdk())
+	if *update {
+		assert.Nil(t, runner.Update(vectors))
+		assert.Nil(t, vectors.Save(vectorsFile))
+		return
+	}
+	failures := runner.Run(vectors)
+	for _, failure := range failures {
+		t.Error(failure.String())
+	}
+}
+
+func TestBuildParams(t *testing.T) {
+	params, err := BuildParams([]*Param{
+		{Type: PARAM_TYPE_INTEGER, Value: "18446744073709551616"},
+		{Type: PARAM_TYPE_STRUCT, Items: []*Param{{Type: PARAM_TYPE_STRING, Value: "a"}, {Type: PARAM_TYPE_BOOL, Value: "true"}}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "18446744073709551616", params[0].(interface{ String() string }).String())
+
+	_, err = BuildParams([]*Param{{Type: PARAM_TYPE_INTEGER, Value: "-1"}})
+	assert.NotNil(t, err)
+	_, err = BuildParams([]*Param{{Type: "float", Value: "1.5"}})
+	assert.NotNil(t, err)
+}
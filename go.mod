@@ -12,6 +12,7 @@ require (
 	github.com/stretchr/testify v1.4.0
 	github.com/tyler-smith/go-bip39 v1.0.2
 	golang.org/x/crypto v0.0.0-20191219195013-becbf705a915
+	golang.org/x/text v0.3.2
 )
 
 replace github.com/go-interpreter/wagon => github.com/TesraSupernet/wagon v0.3.1-0.20191012103353-ef8d35ecd300
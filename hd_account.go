@@ -0,0 +1,158 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"crypto/elliptic"
+	"fmt"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/tesracrypto/ec"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	s "github.com/TesraSupernet/tesracrypto/signature"
+	"github.com/TesraSupernet/tesracrypto/sm2"
+	"github.com/TesraSupernet/tesrasdk/bip44"
+	"golang.org/x/crypto/ed25519"
+)
+
+//HD_ED25519_ACCOUNT_PATH_FORMAT is the default path of Ed25519 HD account, Ed25519 only supports hardened derivation
+var HD_ED25519_ACCOUNT_PATH_FORMAT = "m/44'/1024'/0'/0'/%d'"
+
+//GetSlip10Curve return the SLIP-0010 curve of key type, ECDSA is on P-256
+func GetSlip10Curve(keyType keypair.KeyType) (*bip44.Slip10Curve, error) {
+	switch keyType {
+	case keypair.PK_ECDSA:
+		return bip44.Nist256p1, nil
+	case keypair.PK_SM2:
+		return bip44.Sm2p256v1, nil
+	case keypair.PK_EDDSA:
+		return bip44.Ed25519, nil
+	default:
+		return nil, fmt.Errorf("unsupport key type:%d", keyType)
+	}
+}
+
+//GetKeyTypeOfScheme return the key type of signature scheme
+func GetKeyTypeOfScheme(sigScheme s.SignatureScheme) (keypair.KeyType, error) {
+	for _, keyType := range []keypair.KeyType{keypair.PK_ECDSA, keypair.PK_SM2, keypair.PK_EDDSA} {
+		if CheckSigScheme(keyType, sigScheme) {
+			return keyType, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown signature scheme:%s", sigScheme.Name())
+}
+
+//GetHDAccountPathOfKeyType return the default path of address index for key type
+func GetHDAccountPathOfKeyType(keyType keypair.KeyType, index uint32) string {
+	if keyType == keypair.PK_EDDSA {
+		return fmt.Sprintf(HD_ED25519_ACCOUNT_PATH_FORMAT, index)
+	}
+	return GetHDAccountPath(index)
+}
+
+//NewAccountFromPrivateKeyWithKeyType return account of the raw private key of keyType. ECDSA key is on P-256,
+//Ed25519 private key is the 32 bytes seed
+func NewAccountFromPrivateKeyWithKeyType(privateKey []byte, keyType keypair.KeyType, sigScheme s.SignatureScheme) (*Account, error) {
+	if len(privateKey) != 32 {
+		return nil, fmt.Errorf("the length of privatekey should be 32")
+	}
+	if !CheckSigScheme(keyType, sigScheme) {
+		return nil, fmt.Errorf("sigScheme:%s does not match with KeyType:%s", sigScheme.Name(), GetKeyTypeString(keyType))
+	}
+	var priKey keypair.PrivateKey
+	switch keyType {
+	case keypair.PK_ECDSA:
+		priKey = &ec.PrivateKey{
+			Algorithm:  ec.ECDSA,
+			PrivateKey: ec.ConstructPrivateKey(privateKey, elliptic.P256()),
+		}
+	case keypair.PK_SM2:
+		priKey = &ec.PrivateKey{
+			Algorithm:  ec.SM2,
+			PrivateKey: ec.ConstructPrivateKey(privateKey, sm2.SM2P256V1()),
+		}
+	case keypair.PK_EDDSA:
+		priKey = ed25519.NewKeyFromSeed(privateKey)
+	default:
+		return nil, fmt.Errorf("unsupport key type:%d", keyType)
+	}
+	pubKey := priKey.Public()
+	return &Account{
+		PrivateKey: priKey,
+		PublicKey:  pubKey,
+		Address:    types.AddressFromPubKey(pubKey),
+		SigScheme:  sigScheme,
+	}, nil
+}
+
+//DeriveAccountFromSeedWithScheme derive the account of path from seed by SLIP-0010 on the curve of sigScheme's key type.
+//For SHA256withECDSA it is the same as DeriveAccountFromSeed, Ed25519 path should be all hardened
+func DeriveAccountFromSeedWithScheme(seed []byte, path string, sigScheme s.SignatureScheme) (*Account, error) {
+	keyType, err := GetKeyTypeOfScheme(sigScheme)
+	if err != nil {
+		return nil, err
+	}
+	curve, err := GetSlip10Curve(keyType)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := bip44.NewSlip10KeyFromPath(curve, seed, indexes)
+	if err != nil {
+		return nil, fmt.Errorf("derive path:%s error:%s", path, err)
+	}
+	return NewAccountFromPrivateKeyWithKeyType(key.Key, keyType, sigScheme)
+}
+
+//GetAccountFromMnemonic return the account of path derived from mnemonic with BIP39 passphrase by SLIP-0010 on the
+//curve of sigScheme, such as GetAccountFromMnemonic(mnemonic, "", "m/44'/1024'/0'/0'/0'", s.SHA512withEDDSA)
+func (this *TesraSdk) GetAccountFromMnemonic(mnemonic, passphrase, path string, sigScheme s.SignatureScheme) (*Account, error) {
+	seed, err := MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return DeriveAccountFromSeedWithScheme(seed, path, sigScheme)
+}
+
+//DeriveHDAccountWithScheme derive account of path with sigScheme from HD seed, and add it into wallet encrypted by passwd
+func (this *Wallet) DeriveHDAccountWithScheme(path string, sigScheme s.SignatureScheme, passwd []byte) (*Account, error) {
+	seed, err := this.GetHDSeed(passwd)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	path = FormatDerivationPath(indexes)
+	acc, err := DeriveAccountFromSeedWithScheme(seed, path, sigScheme)
+	if err != nil {
+		return nil, err
+	}
+	accData, err := NewDerivedAccountData(acc, path, passwd, this.Scrypt)
+	if err != nil {
+		return nil, err
+	}
+	err = this.AddAccountData(accData)
+	if err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
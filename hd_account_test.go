@@ -0,0 +1,88 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"encoding/hex"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	s "github.com/TesraSupernet/tesracrypto/signature"
+	"github.com/TesraSupernet/tesrasdk/bip44"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSlip10Vectors(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	key, err := bip44.NewSlip10MasterKey(bip44.Nist256p1, seed)
+	assert.Nil(t, err)
+	assert.Equal(t, "612091aaa12e22dd2abef664f8a01a82cae99ad7441b7ef8110424915c268bc2", hex.EncodeToString(key.Key))
+	assert.Equal(t, "0266874dc6ade47b3ecd096745ca09bcd29638dd52c2c12117b11ed3e458cfa9e8", hex.EncodeToString(key.PublicKeyBytes()))
+	key, err = bip44.NewSlip10KeyFromPath(bip44.Nist256p1, seed, []uint32{0x80000000, 1})
+	assert.Nil(t, err)
+	assert.Equal(t, "284e9d38d07d21e4e281b645089a94f4cf5a5a81369acf151a1c3a57f18b2129", hex.EncodeToString(key.Key))
+
+	key, err = bip44.NewSlip10MasterKey(bip44.Ed25519, seed)
+	assert.Nil(t, err)
+	assert.Equal(t, "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7", hex.EncodeToString(key.Key))
+	assert.Equal(t, "00a4b2856bfec510abab89753fac1ac0e1112364e7d250545963f135f2a33188ed", hex.EncodeToString(key.PublicKeyBytes()))
+	key, err = bip44.NewSlip10KeyFromPath(bip44.Ed25519, seed, []uint32{0x80000000, 0x80000001})
+	assert.Nil(t, err)
+	assert.Equal(t, "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2", hex.EncodeToString(key.Key))
+	_, err = bip44.NewSlip10KeyFromPath(bip44.Ed25519, seed, []uint32{0x80000000, 1})
+	assert.NotNil(t, err)
+}
+
+func TestDeriveAccountFromSeedWithScheme(t *testing.T) {
+	seed, err := MnemonicToSeed(testMnemonic, "")
+	assert.Nil(t, err)
+	for i := uint32(0); i < 3; i++ {
+		expected, err := DeriveAccountFromSeed(seed, GetHDAccountPath(i))
+		assert.Nil(t, err)
+		acc, err := DeriveAccountFromSeedWithScheme(seed, GetHDAccountPath(i), s.SHA256withECDSA)
+		assert.Nil(t, err)
+		assert.Equal(t, expected.Address, acc.Address)
+	}
+
+	for _, scheme := range []s.SignatureScheme{s.SHA256withECDSA, s.SM3withSM2, s.SHA512withEDDSA} {
+		keyType, err := GetKeyTypeOfScheme(scheme)
+		assert.Nil(t, err)
+		acc, err := DeriveAccountFromSeedWithScheme(seed, GetHDAccountPathOfKeyType(keyType, 0), scheme)
+		assert.Nil(t, err)
+		assert.Equal(t, keyType, keypair.GetKeyType(acc.PublicKey))
+		sig, err := acc.Sign([]byte("test"))
+		assert.Nil(t, err)
+		assert.True(t, s.Verify(acc.PublicKey, []byte("test"), sig))
+	}
+	_, err = DeriveAccountFromSeedWithScheme(seed, GetHDAccountPath(0), s.SHA512withEDDSA)
+	assert.NotNil(t, err)
+
+	passwd := []byte("123456")
+	wallet := NewWallet("slip10_wallet.dat")
+	assert.Nil(t, wallet.InitHDFromMnemonic(testMnemonic, passwd))
+	sm2Acc, err := wallet.DeriveHDAccountWithScheme("m/44'/1024'/0'/0/0", s.SM3withSM2, passwd)
+	assert.Nil(t, err)
+	edAcc, err := wallet.DeriveHDAccountWithScheme(GetHDAccountPathOfKeyType(keypair.PK_EDDSA, 0), s.SHA512withEDDSA, passwd)
+	assert.Nil(t, err)
+	acc, err := wallet.GetAccountByAddress(edAcc.Address.ToBase58(), passwd)
+	assert.Nil(t, err)
+	assert.Equal(t, s.SHA512withEDDSA, acc.SigScheme)
+	_, err = wallet.RegenerateHDAccounts(passwd, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, wallet.GetAccountCount())
+	assert.NotEqual(t, sm2Acc.Address, edAcc.Address)
+}
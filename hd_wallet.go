@@ -0,0 +1,509 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"github.com/TesraSupernet/go-bip32"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	s "github.com/TesraSupernet/tesracrypto/signature"
+	"golang.org/x/crypto/scrypt"
+	"strconv"
+	"strings"
+)
+
+const (
+	HD_SEED_TYPE_MNEMONIC = "mnemonic" //encrypted data is the mnemonic words
+	HD_SEED_TYPE_SEED     = "seed"     //encrypted data is the BIP39 seed
+)
+
+//HD_COIN_TYPE is the BIP44 coin type of Tesra
+const HD_COIN_TYPE = 1024
+
+//HD_ACCOUNT_PATH_FORMAT is the default BIP44 path of HD account, the only argument is address index
+var HD_ACCOUNT_PATH_FORMAT = "m/44'/1024'/0'/0/%d"
+
+const (
+	hd_seed_enc_alg    = "aes-256-gcm"
+	hd_hardened_offset = 0x80000000
+)
+
+//HDSeedData is the scrypt encrypted mnemonic or seed of HD wallet, saved in wallet file
+type HDSeedData struct {
+	Type           string `json:"type"`
+	EncAlg         string `json:"enc-alg"`
+	Key            []byte `json:"key"`
+	Salt           []byte `json:"salt"`
+	Passphrase     []byte `json:"passphrase,omitempty"`     //encrypted BIP39 passphrase of mnemonic, empty if no passphrase
+	PassphraseSalt []byte `json:"passphraseSalt,omitempty"` //salt of passphrase, different from salt of mnemonic
+	NextIndex      uint32 `json:"nextIndex"`                //next address index used by NewHDAccount
+}
+
+func (this *HDSeedData) Clone() *HDSeedData {
+	seedData := *this
+	seedData.Key = make([]byte, len(this.Key))
+	copy(seedData.Key, this.Key)
+	seedData.Salt = make([]byte, len(this.Salt))
+	copy(seedData.Salt, this.Salt)
+	if this.Passphrase != nil {
+		seedData.Passphrase = make([]byte, len(this.Passphrase))
+		copy(seedData.Passphrase, this.Passphrase)
+		seedData.PassphraseSalt = make([]byte, len(this.PassphraseSalt))
+		copy(seedData.PassphraseSalt, this.PassphraseSalt)
+	}
+	return &seedData
+}
+
+//NewHDSeedData encrypt mnemonic or seed with passwd
+func NewHDSeedData(seedType string, data, passwd []byte, scrypt *keypair.ScryptParam) (*HDSeedData, error) {
+	if len(passwd) == 0 {
+		return nil, fmt.Errorf("password cannot empty")
+	}
+	if seedType != HD_SEED_TYPE_MNEMONIC && seedType != HD_SEED_TYPE_SEED {
+		return nil, fmt.Errorf("unknown seed type:%s", seedType)
+	}
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, fmt.Errorf("generate salt error:%s", err)
+	}
+	key, err := scryptEncrypt(data, passwd, salt, scrypt)
+	if err != nil {
+		return nil, err
+	}
+	return &HDSeedData{
+		Type:   seedType,
+		EncAlg: hd_seed_enc_alg,
+		Key:    key,
+		Salt:   salt,
+	}, nil
+}
+
+//SetPassphrase encrypt the BIP39 passphrase of mnemonic with passwd, empty passphrase removes it
+func (this *HDSeedData) SetPassphrase(passphrase string, passwd []byte, scrypt *keypair.ScryptParam) error {
+	if this.Type != HD_SEED_TYPE_MNEMONIC {
+		return fmt.Errorf("passphrase is only for mnemonic")
+	}
+	if passphrase == "" {
+		this.Passphrase = nil
+		this.PassphraseSalt = nil
+		return nil
+	}
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return fmt.Errorf("generate salt error:%s", err)
+	}
+	encrypted, err := scryptEncrypt([]byte(passphrase), passwd, salt, scrypt)
+	if err != nil {
+		return err
+	}
+	this.Passphrase = encrypted
+	this.PassphraseSalt = salt
+	return nil
+}
+
+//GetPassphrase return the BIP39 passphrase of mnemonic, empty if no passphrase
+func (this *HDSeedData) GetPassphrase(passwd []byte, scrypt *keypair.ScryptParam) (string, error) {
+	if len(this.Passphrase) == 0 {
+		return "", nil
+	}
+	passphrase, err := scryptDecrypt(this.Passphrase, passwd, this.PassphraseSalt, scrypt)
+	if err != nil {
+		return "", err
+	}
+	return string(passphrase), nil
+}
+
+//Reencrypt return the seed data encrypted by newPasswd and newScrypt, passphrase and next index are kept
+func (this *HDSeedData) Reencrypt(oldPasswd, newPasswd []byte, oldScrypt, newScrypt *keypair.ScryptParam) (*HDSeedData, error) {
+	data, err := this.Decrypt(oldPasswd, oldScrypt)
+	if err != nil {
+		return nil, err
+	}
+	passphrase, err := this.GetPassphrase(oldPasswd, oldScrypt)
+	if err != nil {
+		return nil, err
+	}
+	seedData, err := NewHDSeedData(this.Type, data, newPasswd, newScrypt)
+	if err != nil {
+		return nil, err
+	}
+	if passphrase != "" {
+		err = seedData.SetPassphrase(passphrase, newPasswd, newScrypt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	seedData.NextIndex = this.NextIndex
+	return seedData, nil
+}
+
+//Decrypt return the mnemonic or seed
+func (this *HDSeedData) Decrypt(passwd []byte, scrypt *keypair.ScryptParam) ([]byte, error) {
+	if this.EncAlg != hd_seed_enc_alg {
+		return nil, fmt.Errorf("unsupport enc-alg:%s", this.EncAlg)
+	}
+	return scryptDecrypt(this.Key, passwd, this.Salt, scrypt)
+}
+
+//GetSeed return the BIP39 seed
+func (this *HDSeedData) GetSeed(passwd []byte, scrypt *keypair.ScryptParam) ([]byte, error) {
+	data, err := this.Decrypt(passwd, scrypt)
+	if err != nil {
+		return nil, err
+	}
+	switch this.Type {
+	case HD_SEED_TYPE_MNEMONIC:
+		passphrase, err := this.GetPassphrase(passwd, scrypt)
+		if err != nil {
+			return nil, err
+		}
+		return MnemonicToSeed(string(data), passphrase)
+	case HD_SEED_TYPE_SEED:
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unknown seed type:%s", this.Type)
+	}
+}
+
+//scryptEncrypt encrypt data by aes-256-gcm, with the key and nonce derived from passwd by scrypt
+func scryptEncrypt(data, passwd, salt []byte, param *keypair.ScryptParam) ([]byte, error) {
+	aesgcm, nonce, err := newScryptGCM(passwd, salt, param)
+	if err != nil {
+		return nil, err
+	}
+	return aesgcm.Seal(nil, nonce, data, nil), nil
+}
+
+//scryptDecrypt decrypt the data encrypted by scryptEncrypt
+func scryptDecrypt(encrypted, passwd, salt []byte, param *keypair.ScryptParam) ([]byte, error) {
+	aesgcm, nonce, err := newScryptGCM(passwd, salt, param)
+	if err != nil {
+		return nil, err
+	}
+	data, err := aesgcm.Open(nil, nonce, encrypted, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt error, maybe wrong password")
+	}
+	return data, nil
+}
+
+func newScryptGCM(passwd, salt []byte, param *keypair.ScryptParam) (cipher.AEAD, []byte, error) {
+	if param.DKLen < 44 {
+		return nil, nil, fmt.Errorf("scrypt dkLen:%d is too short", param.DKLen)
+	}
+	dk, err := scrypt.Key(passwd, salt, param.N, param.R, param.P, param.DKLen)
+	if err != nil {
+		return nil, nil, fmt.Errorf("scrypt error:%s", err)
+	}
+	block, err := aes.NewCipher(dk[len(dk)-32:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("aes.NewCipher error:%s", err)
+	}
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cipher.NewGCM error:%s", err)
+	}
+	return aesgcm, dk[:aesgcm.NonceSize()], nil
+}
+
+//ParseDerivationPath parse BIP32 path such as m/44'/1024'/0'/0/0, ' or h means hardened index
+func ParseDerivationPath(path string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if len(parts) == 0 || parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path:%s", path)
+	}
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || index >= hd_hardened_offset {
+			return nil, fmt.Errorf("invalid derivation path:%s", path)
+		}
+		if hardened {
+			index += hd_hardened_offset
+		}
+		indexes = append(indexes, uint32(index))
+	}
+	return indexes, nil
+}
+
+//FormatDerivationPath format indexes to BIP32 path
+func FormatDerivationPath(indexes []uint32) string {
+	path := "m"
+	for _, index := range indexes {
+		if index >= hd_hardened_offset {
+			path += fmt.Sprintf("/%d'", index-hd_hardened_offset)
+		} else {
+			path += fmt.Sprintf("/%d", index)
+		}
+	}
+	return path
+}
+
+//GetHDAccountPath return the default BIP44 path of address index
+func GetHDAccountPath(index uint32) string {
+	return fmt.Sprintf(HD_ACCOUNT_PATH_FORMAT, index)
+}
+
+//DeriveAccountFromSeed derive the ECDSA P-256 account of BIP44 path from seed,
+//the same as GetPrivateKeyFromMnemonicCodesStrBip44 for the default path
+func DeriveAccountFromSeed(seed []byte, path string) (*Account, error) {
+	indexes, err := ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := bip32.NewMasterKey(seed)
+	if err != nil {
+		return nil, fmt.Errorf("NewMasterKey error:%s", err)
+	}
+	for _, index := range indexes {
+		key, err = key.NewChildKey(index)
+		if err != nil {
+			return nil, fmt.Errorf("NewChildKey error:%s", err)
+		}
+	}
+	keyBytes, err := key.Serialize()
+	if err != nil {
+		return nil, fmt.Errorf("key serialize error:%s", err)
+	}
+	return NewAccountFromPrivateKey(keyBytes[46:78], s.SHA256withECDSA)
+}
+
+//IsHD return whether wallet has HD seed
+func (this *Wallet) IsHD() bool {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.hdSeed != nil
+}
+
+//InitHD generate a new 12 words English mnemonic as HD seed of wallet, and return the mnemonic for backup
+func (this *Wallet) InitHD(passwd []byte) (string, error) {
+	return this.InitHDWithOptions(DEFAULT_MNEMONIC_WORD_COUNT, MNEMONIC_LANG_ENGLISH, "", passwd)
+}
+
+//InitHDWithOptions generate a new mnemonic of wordCount words in lang as HD seed of wallet with BIP39 passphrase,
+//and return the mnemonic for backup. The passphrase is also needed to restore wallet from mnemonic
+func (this *Wallet) InitHDWithOptions(wordCount int, lang, passphrase string, passwd []byte) (string, error) {
+	mnemonic, err := NewMnemonic(wordCount, lang)
+	if err != nil {
+		return "", err
+	}
+	err = this.InitHDFromMnemonicWithPassphrase(mnemonic, passphrase, passwd)
+	if err != nil {
+		return "", err
+	}
+	return mnemonic, nil
+}
+
+//InitHDFromMnemonic set mnemonic as HD seed of wallet, mnemonic is saved encrypted by passwd
+func (this *Wallet) InitHDFromMnemonic(mnemonic string, passwd []byte) error {
+	return this.InitHDFromMnemonicWithPassphrase(mnemonic, "", passwd)
+}
+
+//InitHDFromMnemonicWithPassphrase set mnemonic with BIP39 passphrase as HD seed of wallet, both are saved encrypted
+//by passwd. Mnemonic of any supported language is validated and saved NFKD normalized
+func (this *Wallet) InitHDFromMnemonicWithPassphrase(mnemonic, passphrase string, passwd []byte) error {
+	err := ValidateMnemonic(mnemonic)
+	if err != nil {
+		return err
+	}
+	seedData, err := NewHDSeedData(HD_SEED_TYPE_MNEMONIC, []byte(NormalizeMnemonic(mnemonic)), passwd, this.Scrypt)
+	if err != nil {
+		return err
+	}
+	err = seedData.SetPassphrase(passphrase, passwd, this.Scrypt)
+	if err != nil {
+		return err
+	}
+	return this.setHDSeed(seedData)
+}
+
+//InitHDFromSeed set BIP39 seed as HD seed of wallet, seed is saved encrypted by passwd
+func (this *Wallet) InitHDFromSeed(seed []byte, passwd []byte) error {
+	if len(seed) < 16 || len(seed) > 64 {
+		return fmt.Errorf("invalid seed length:%d", len(seed))
+	}
+	seedData, err := NewHDSeedData(HD_SEED_TYPE_SEED, seed, passwd, this.Scrypt)
+	if err != nil {
+		return err
+	}
+	return this.setHDSeed(seedData)
+}
+
+func (this *Wallet) setHDSeed(seedData *HDSeedData) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.hdSeed != nil {
+		return fmt.Errorf("wallet already has HD seed")
+	}
+	this.hdSeed = seedData
+	return nil
+}
+
+func (this *Wallet) getHDSeedData() (*HDSeedData, error) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	if this.hdSeed == nil {
+		return nil, fmt.Errorf("wallet is not HD wallet")
+	}
+	return this.hdSeed.Clone(), nil
+}
+
+//GetHDMnemonic return the mnemonic of HD wallet for backup
+func (this *Wallet) GetHDMnemonic(passwd []byte) (string, error) {
+	seedData, err := this.getHDSeedData()
+	if err != nil {
+		return "", err
+	}
+	if seedData.Type != HD_SEED_TYPE_MNEMONIC {
+		return "", fmt.Errorf("HD wallet is created from seed, no mnemonic")
+	}
+	mnemonic, err := seedData.Decrypt(passwd, this.Scrypt)
+	if err != nil {
+		return "", err
+	}
+	return string(mnemonic), nil
+}
+
+//GetHDSeed return the BIP39 seed of HD wallet
+func (this *Wallet) GetHDSeed(passwd []byte) ([]byte, error) {
+	seedData, err := this.getHDSeedData()
+	if err != nil {
+		return nil, err
+	}
+	return seedData.GetSeed(passwd, this.Scrypt)
+}
+
+//DeriveHDAccount derive account of BIP44 path from HD seed, and add it into wallet encrypted by passwd
+func (this *Wallet) DeriveHDAccount(path string, passwd []byte) (*Account, error) {
+	seed, err := this.GetHDSeed(passwd)
+	if err != nil {
+		return nil, err
+	}
+	return this.addHDAccount(seed, path, passwd)
+}
+
+//NewHDAccount derive account of next address index from HD seed, and add it into wallet
+func (this *Wallet) NewHDAccount(passwd []byte) (*Account, error) {
+	seed, err := this.GetHDSeed(passwd)
+	if err != nil {
+		return nil, err
+	}
+	this.lock.RLock()
+	index := this.hdSeed.NextIndex
+	this.lock.RUnlock()
+	acc, err := this.addHDAccount(seed, GetHDAccountPath(index), passwd)
+	if err != nil {
+		return nil, err
+	}
+	//next index is only moved after the account is added, so a failure does not leave a gap
+	this.lock.Lock()
+	if this.hdSeed.NextIndex <= index {
+		this.hdSeed.NextIndex = index + 1
+	}
+	this.lock.Unlock()
+	return acc, nil
+}
+
+func (this *Wallet) addHDAccount(seed []byte, path string, passwd []byte) (*Account, error) {
+	indexes, err := ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	path = FormatDerivationPath(indexes)
+	acc, err := DeriveAccountFromSeed(seed, path)
+	if err != nil {
+		return nil, err
+	}
+	accData, err := NewDerivedAccountData(acc, path, passwd, this.Scrypt)
+	if err != nil {
+		return nil, err
+	}
+	err = this.AddAccountData(accData)
+	if err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+//NewDerivedAccountData return the AccountData of account derived by path, private key is encrypted by passwd
+func NewDerivedAccountData(acc *Account, path string, passwd []byte, scrypt *keypair.ScryptParam) (*AccountData, error) {
+	accData, err := NewAccountDataFromAccount(acc, passwd, scrypt)
+	if err != nil {
+		return nil, err
+	}
+	accData.DerivationPath = path
+	return accData, nil
+}
+
+//RegenerateHDAccounts derive the accounts of address index from 0 to count-1 from HD seed, and add the missing ones
+//into wallet. The accounts in wallet with derivation path are checked against the seed. It is used to restore wallet
+//from mnemonic. If count is less than the next address index, the next address index is used.
+func (this *Wallet) RegenerateHDAccounts(passwd []byte, count uint32) ([]*Account, error) {
+	seed, err := this.GetHDSeed(passwd)
+	if err != nil {
+		return nil, err
+	}
+	this.lock.RLock()
+	if count < this.hdSeed.NextIndex {
+		count = this.hdSeed.NextIndex
+	}
+	derived := make([]*AccountData, 0)
+	for _, accData := range this.accounts {
+		if accData.DerivationPath != "" && !accData.WatchOnly {
+			derived = append(derived, accData)
+		}
+	}
+	this.lock.RUnlock()
+	for _, accData := range derived {
+		address, path := accData.Address, accData.DerivationPath
+		sigScheme, err := s.GetScheme(accData.SigSch)
+		if err != nil {
+			return nil, fmt.Errorf("account:%s signature scheme error:%s", address, err)
+		}
+		acc, err := DeriveAccountFromSeedWithScheme(seed, path, sigScheme)
+		if err != nil {
+			return nil, fmt.Errorf("derive account:%s error:%s", address, err)
+		}
+		if acc.Address.ToBase58() != address {
+			return nil, fmt.Errorf("account:%s does not match the derivation path:%s of HD seed", address, path)
+		}
+	}
+	accounts := make([]*Account, 0, count)
+	for index := uint32(0); index < count; index++ {
+		acc, err := this.addHDAccount(seed, GetHDAccountPath(index), passwd)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, acc)
+	}
+	this.lock.Lock()
+	if this.hdSeed.NextIndex < count {
+		this.hdSeed.NextIndex = count
+	}
+	this.lock.Unlock()
+	return accounts, nil
+}
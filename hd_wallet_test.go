@@ -0,0 +1,117 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestParseDerivationPath(t *testing.T) {
+	indexes, err := ParseDerivationPath("m/44'/1024'/0'/0/7")
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{0x8000002C, 0x80000400, 0x80000000, 0, 7}, indexes)
+	assert.Equal(t, "m/44'/1024'/0'/0/7", FormatDerivationPath(indexes))
+	indexes, err = ParseDerivationPath("m/44h/1024h")
+	assert.Nil(t, err)
+	assert.Equal(t, "m/44'/1024'", FormatDerivationPath(indexes))
+
+	for _, path := range []string{"", "44'/0", "m/", "m/-1", "m/2147483648", "m/a'"} {
+		_, err = ParseDerivationPath(path)
+		assert.NotNil(t, err, path)
+	}
+}
+
+func TestHDWallet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hd_wallet_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "wallet.dat")
+	passwd := []byte("123456")
+	wallet := NewWallet(path)
+	assert.Nil(t, wallet.InitHDFromMnemonic(testMnemonic, passwd))
+	assert.True(t, wallet.IsHD())
+	assert.NotNil(t, wallet.InitHDFromMnemonic(testMnemonic, passwd))
+
+	acc0, err := wallet.NewHDAccount(passwd)
+	assert.Nil(t, err)
+	acc1, err := wallet.NewHDAccount(passwd)
+	assert.Nil(t, err)
+	sdk := NewTesraSdk()
+	privateKey, err := sdk.GetPrivateKeyFromMnemonicCodesStrBip44(testMnemonic, 1)
+	assert.Nil(t, err)
+	expected, err := NewAccountFromPrivateKey(privateKey, acc1.SigScheme)
+	assert.Nil(t, err)
+	assert.Equal(t, expected.Address, acc1.Address)
+
+	accData, err := wallet.GetAccountDataByAddress(acc1.Address.ToBase58())
+	assert.Nil(t, err)
+	assert.Equal(t, "m/44'/1024'/0'/0/1", accData.DerivationPath)
+	_, err = wallet.GetHDMnemonic([]byte("wrong"))
+	assert.NotNil(t, err)
+	mnemonic, err := wallet.GetHDMnemonic(passwd)
+	assert.Nil(t, err)
+	assert.Equal(t, testMnemonic, mnemonic)
+	assert.Nil(t, wallet.Save())
+
+	wallet, err = OpenWallet(path)
+	assert.Nil(t, err)
+	assert.True(t, wallet.IsHD())
+	acc2, err := wallet.NewHDAccount(passwd)
+	assert.Nil(t, err)
+	accData, err = wallet.GetAccountDataByAddress(acc2.Address.ToBase58())
+	assert.Nil(t, err)
+	assert.Equal(t, "m/44'/1024'/0'/0/2", accData.DerivationPath)
+
+	//restore from mnemonic
+	restored := NewWallet(path + ".restored")
+	assert.Nil(t, restored.InitHDFromMnemonic(testMnemonic, passwd))
+	accounts, err := restored.RegenerateHDAccounts(passwd, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(accounts))
+	assert.Equal(t, acc0.Address, accounts[0].Address)
+	assert.Equal(t, acc2.Address, accounts[2].Address)
+	assert.Equal(t, 3, restored.GetAccountCount())
+	acc3, err := restored.NewHDAccount(passwd)
+	assert.Nil(t, err)
+	accData, err = restored.GetAccountDataByAddress(acc3.Address.ToBase58())
+	assert.Nil(t, err)
+	assert.Equal(t, "m/44'/1024'/0'/0/3", accData.DerivationPath)
+
+	//failed NewHDAccount does not move next index
+	restored.hdSeed.NextIndex = hd_hardened_offset
+	_, err = restored.NewHDAccount(passwd)
+	assert.NotNil(t, err)
+	assert.Equal(t, uint32(hd_hardened_offset), restored.hdSeed.NextIndex)
+	restored.hdSeed.NextIndex = 4
+	_, err = restored.NewHDAccount(passwd)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(5), restored.hdSeed.NextIndex)
+
+	other := NewWallet(path + ".other")
+	_, err = other.InitHD(passwd)
+	assert.Nil(t, err)
+	assert.Nil(t, other.AddAccountData(accData))
+	_, err = other.RegenerateHDAccounts(passwd, 1)
+	assert.NotNil(t, err)
+}
@@ -22,6 +22,7 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	base58 "github.com/itchyny/base58-go"
 	"github.com/TesraSupernet/tesracrypto/keypair"
@@ -73,7 +74,8 @@ type ControllerData struct {
 	Public string `json:"publicKey,omitemtpy"`
 	SigSch string `json:"signatureScheme"`
 	keypair.ProtectedKey
-	scrypt *keypair.ScryptParam
+	scrypt      *keypair.ScryptParam
+	extraFields map[string]json.RawMessage
 }
 
 func NewControllerData(id string, keyType keypair.KeyType, curveCode byte, sigScheme s.SignatureScheme, passwd []byte, scrypts ...*keypair.ScryptParam) (*ControllerData, error) {
@@ -168,10 +170,11 @@ func (this *ControllerData) GetKeyPair() *keypair.ProtectedKey {
 
 func (this *ControllerData) Clone() *ControllerData {
 	ctrData := &ControllerData{
-		ID:     this.ID,
-		Public: this.Public,
-		scrypt: this.scrypt,
-		SigSch: this.SigSch,
+		ID:          this.ID,
+		Public:      this.Public,
+		scrypt:      this.scrypt,
+		SigSch:      this.SigSch,
+		extraFields: this.extraFields,
 	}
 	ctrData.SetKeyPair(this.GetKeyPair())
 	return ctrData
@@ -191,6 +194,7 @@ type Identity struct {
 	ctrsPubMap  map[string]*ControllerData
 	Extra       interface{}
 	scrypt      *keypair.ScryptParam
+	extraFields map[string]json.RawMessage
 }
 
 func NewIdentity(scrypt *keypair.ScryptParam) (*Identity, error) {
@@ -217,7 +221,9 @@ func NewIdentityFromIdentityData(identityData *IdentityData) (*Identity, error)
 		controllers: make([]*ControllerData, 0, len(identityData.Control)),
 		ctrsIdMap:   make(map[string]*ControllerData),
 		ctrsPubMap:  make(map[string]*ControllerData),
+		Extra:       identityData.Extra,
 		scrypt:      identityData.scrypt,
+		extraFields: identityData.extraFields,
 	}
 	for _, ctrData := range identityData.Control {
 		_, ok := identity.ctrsIdMap[ctrData.ID]
@@ -346,11 +352,14 @@ func (this *Identity) GetControllerByIndex(index int, passwd []byte) (*Controlle
 
 func (this *Identity) ToIdentityData() *IdentityData {
 	identityData := &IdentityData{
-		ID:      this.ID,
-		Label:   this.Label,
-		Lock:    this.Lock,
-		Extra:   this.Extra,
-		Control: make([]*ControllerData, 0, len(this.controllers)),
+		ID:          this.ID,
+		Label:       this.Label,
+		Lock:        this.Lock,
+		IsDefault:   this.IsDefault,
+		Extra:       this.Extra,
+		Control:     make([]*ControllerData, 0, len(this.controllers)),
+		scrypt:      this.scrypt,
+		extraFields: this.extraFields,
 	}
 	for _, ctr := range this.controllers {
 		identityData.Control = append(identityData.Control, ctr.Clone())
@@ -359,13 +368,14 @@ func (this *Identity) ToIdentityData() *IdentityData {
 }
 
 type IdentityData struct {
-	ID        string            `json:"tsrid"`
-	Label     string            `json:"label,omitempty"`
-	Lock      bool              `json:"lock"`
-	IsDefault bool              `json:"isDefault"`
-	Control   []*ControllerData `json:"controls,omitempty"`
-	Extra     interface{}       `json:"extra,omitempty"`
-	scrypt    *keypair.ScryptParam
+	ID          string            `json:"tsrid"`
+	Label       string            `json:"label,omitempty"`
+	Lock        bool              `json:"lock"`
+	IsDefault   bool              `json:"isDefault"`
+	Control     []*ControllerData `json:"controls,omitempty"`
+	Extra       interface{}       `json:"extra,omitempty"`
+	scrypt      *keypair.ScryptParam
+	extraFields map[string]json.RawMessage
 }
 
 func GenerateID() (string, error) {
@@ -0,0 +1,123 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"errors"
+	"sync"
+)
+
+var ERR_KEY_STORE_EMPTY = errors.New("key store is empty")
+
+//KeyStore is the storage which Wallet is loaded from and saved to
+type KeyStore interface {
+	//Load return the wallet data saved, migrated and validated as ParseWalletData
+	Load() (*WalletData, error)
+	//Save replace the wallet data saved
+	Save(walletData *WalletData) error
+	//Close release the resources held by key store, such as file lock
+	Close() error
+}
+
+//AtomicKeyStore is the KeyStore whose Save replaces all of the wallet data atomically, so that either the old or
+//the new wallet data is saved after failure or crash. Wallet.Rekey requires AtomicKeyStore
+type AtomicKeyStore interface {
+	KeyStore
+	//IsAtomic return whether Save is atomic
+	IsAtomic() bool
+}
+
+//OpenWalletWithKeyStore load wallet from store, wallet is saved to store too
+func OpenWalletWithKeyStore(store KeyStore) (*Wallet, error) {
+	walletData, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	return newWalletFromWalletData(store, walletData)
+}
+
+//GetKeyStore return the key store which wallet is saved to
+func (this *Wallet) GetKeyStore() KeyStore {
+	return this.store
+}
+
+//Close close the key store of wallet, such as release the lock of wallet file held by OpenWalletForWrite
+func (this *Wallet) Close() error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.store.Close()
+}
+
+//MemoryKeyStore is the KeyStore keeping wallet file content in memory, for test, or for services which load wallet
+//from somewhere else such as secrets manager without writing it to disk
+type MemoryKeyStore struct {
+	data []byte
+	lock sync.RWMutex
+}
+
+//NewMemoryKeyStore return MemoryKeyStore with wallet file content, nil for empty store
+func NewMemoryKeyStore(data []byte) *MemoryKeyStore {
+	store := &MemoryKeyStore{}
+	if data != nil {
+		store.data = make([]byte, len(data))
+		copy(store.data, data)
+	}
+	return store
+}
+
+//IsAtomic return true, wallet data is replaced at once
+func (this *MemoryKeyStore) IsAtomic() bool {
+	return true
+}
+
+//Load parse the wallet saved, ERR_KEY_STORE_EMPTY if nothing saved
+func (this *MemoryKeyStore) Load() (*WalletData, error) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	if this.data == nil {
+		return nil, ERR_KEY_STORE_EMPTY
+	}
+	return ParseWalletData(this.data)
+}
+
+func (this *MemoryKeyStore) Save(walletData *WalletData) error {
+	data, err := walletData.marshal()
+	if err != nil {
+		return err
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.data = data
+	return nil
+}
+
+//GetData return the wallet file content saved, nil if nothing saved
+func (this *MemoryKeyStore) GetData() []byte {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	if this.data == nil {
+		return nil
+	}
+	data := make([]byte, len(this.data))
+	copy(data, this.data)
+	return data
+}
+
+func (this *MemoryKeyStore) Close() error {
+	return nil
+}
@@ -0,0 +1,268 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	DIR_KEY_STORE_INDEX_FILE   = "wallet.json" //wallet data except accounts and identities, and the order of key files
+	DIR_KEY_STORE_ACCOUNT_DIR  = "accounts"    //one file of scrypt encrypted key per account
+	DIR_KEY_STORE_IDENTITY_DIR = "identities"  //one file per identity, with the encrypted keys of its controllers
+)
+
+//keyFileNamePattern is the valid name of key file, without path separator or "..", so that key file is always in its dir
+var keyFileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_\-]+(\.[A-Za-z0-9_\-]+)*$`)
+
+type dirKeyStoreIndex struct {
+	Wallet     json.RawMessage `json:"wallet"`
+	Accounts   []string        `json:"accounts"`
+	Identities []string        `json:"identities"`
+}
+
+//DirKeyStore is the KeyStore of a directory, which saves every account and identity to its own file. Private keys
+//in the files are encrypted by scrypt as in wallet file. Key file put in the directory by hand is loaded after the
+//keys listed in index file. Every file is replaced atomically, but the directory is not, so a failed save may leave
+//some keys saved and others not. It is not an AtomicKeyStore, and Wallet.Rekey is refused
+type DirKeyStore struct {
+	dir  string
+	lock sync.Mutex
+}
+
+//NewDirKeyStore return the KeyStore of directory dir, dir is created on save if not exist
+func NewDirKeyStore(dir string) *DirKeyStore {
+	return &DirKeyStore{dir: dir}
+}
+
+//GetDir return the directory of key store
+func (this *DirKeyStore) GetDir() string {
+	return this.dir
+}
+
+//Load read the key files of directory, ERR_KEY_STORE_EMPTY if index file does not exist
+func (this *DirKeyStore) Load() (*WalletData, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	data, err := ioutil.ReadFile(filepath.Join(this.dir, DIR_KEY_STORE_INDEX_FILE))
+	if os.IsNotExist(err) {
+		return nil, ERR_KEY_STORE_EMPTY
+	}
+	if err != nil {
+		return nil, err
+	}
+	index := &dirKeyStoreIndex{}
+	err = json.Unmarshal(data, index)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s error:%s", DIR_KEY_STORE_INDEX_FILE, err)
+	}
+	wallet := make(map[string]json.RawMessage)
+	err = json.Unmarshal(index.Wallet, &wallet)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s error:%s", DIR_KEY_STORE_INDEX_FILE, err)
+	}
+	accounts, err := readKeyFiles(filepath.Join(this.dir, DIR_KEY_STORE_ACCOUNT_DIR), index.Accounts)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := readKeyFiles(filepath.Join(this.dir, DIR_KEY_STORE_IDENTITY_DIR), index.Identities)
+	if err != nil {
+		return nil, err
+	}
+	wallet["accounts"], err = json.Marshal(accounts)
+	if err != nil {
+		return nil, err
+	}
+	wallet["identities"], err = json.Marshal(identities)
+	if err != nil {
+		return nil, err
+	}
+	data, err = json.Marshal(wallet)
+	if err != nil {
+		return nil, err
+	}
+	return ParseWalletData(data)
+}
+
+//readKeyFiles read the key files of names in order, then the other key files of dir in name order
+func readKeyFiles(dir string, names []string) ([]json.RawMessage, error) {
+	keys := make([]json.RawMessage, 0, len(names))
+	listed := make(map[string]bool)
+	for _, name := range names {
+		if !keyFileNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid key file name:%s", name)
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("read key file:%s error:%s", name, err)
+		}
+		if !json.Valid(data) {
+			return nil, fmt.Errorf("key file:%s is not valid json", name)
+		}
+		keys = append(keys, data)
+		listed[name] = true
+	}
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return keys, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || listed[name] || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("read key file:%s error:%s", name, err)
+		}
+		if !json.Valid(data) {
+			return nil, fmt.Errorf("key file:%s is not valid json", name)
+		}
+		keys = append(keys, data)
+	}
+	return keys, nil
+}
+
+//Save write a file for every account and identity, then the index file, and remove the key files no longer in wallet
+func (this *DirKeyStore) Save(walletData *WalletData) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	indexPath := filepath.Join(this.dir, DIR_KEY_STORE_INDEX_FILE)
+	accountDir := filepath.Join(this.dir, DIR_KEY_STORE_ACCOUNT_DIR)
+	identityDir := filepath.Join(this.dir, DIR_KEY_STORE_IDENTITY_DIR)
+	for _, dir := range []string{accountDir, identityDir} {
+		err := os.MkdirAll(dir, 0700)
+		if err != nil {
+			return fmt.Errorf("create dir:%s error:%s", dir, err)
+		}
+	}
+	fileLock, err := lockWalletFile(indexPath)
+	if err != nil {
+		return err
+	}
+	defer fileLock.Unlock()
+
+	index := &dirKeyStoreIndex{
+		Accounts:   make([]string, 0, len(walletData.Accounts)),
+		Identities: make([]string, 0, len(walletData.Identities)),
+	}
+	//check all of the names before writing any file
+	for _, accData := range walletData.Accounts {
+		name, err := getKeyFileName(accData.Address)
+		if err != nil {
+			return err
+		}
+		index.Accounts = append(index.Accounts, name)
+	}
+	for _, identityData := range walletData.Identities {
+		name, err := getKeyFileName(identityData.ID)
+		if err != nil {
+			return err
+		}
+		index.Identities = append(index.Identities, name)
+	}
+	for i, accData := range walletData.Accounts {
+		err = writeKeyFile(accountDir, index.Accounts[i], accData)
+		if err != nil {
+			return err
+		}
+	}
+	for i, identityData := range walletData.Identities {
+		err = writeKeyFile(identityDir, index.Identities[i], identityData)
+		if err != nil {
+			return err
+		}
+	}
+	meta := *walletData
+	meta.Accounts = nil
+	meta.Identities = nil
+	index.Wallet, err = meta.marshal()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	err = writeWalletFile(indexPath, data, 0)
+	if err != nil {
+		return err
+	}
+	err = removeStaleKeyFiles(accountDir, index.Accounts)
+	if err != nil {
+		return err
+	}
+	return removeStaleKeyFiles(identityDir, index.Identities)
+}
+
+//getKeyFileName return the key file name of account address or identity id, ":" of id is replaced by "_"
+func getKeyFileName(id string) (string, error) {
+	name := strings.Replace(id, ":", "_", -1) + ".json"
+	if !keyFileNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid key file name of:%s", id)
+	}
+	return name, nil
+}
+
+func writeKeyFile(dir, name string, key interface{}) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	err = writeWalletFile(filepath.Join(dir, name), data, 0)
+	if err != nil {
+		return fmt.Errorf("write key file:%s error:%s", name, err)
+	}
+	return nil
+}
+
+func removeStaleKeyFiles(dir string, names []string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	keep := make(map[string]bool, len(names))
+	for _, name := range names {
+		keep[name] = true
+	}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || keep[name] || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		err = os.Remove(filepath.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("remove key file:%s error:%s", name, err)
+		}
+	}
+	return nil
+}
+
+func (this *DirKeyStore) Close() error {
+	return nil
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMemoryKeyStore(t *testing.T) {
+	passwd := []byte("123456")
+	store := NewMemoryKeyStore(nil)
+	_, err := OpenWalletWithKeyStore(store)
+	assert.Equal(t, ERR_KEY_STORE_EMPTY, err)
+
+	wallet := NewWalletWithKeyStore(store)
+	acc, err := wallet.NewDefaultSettingAccount(passwd)
+	assert.Nil(t, err)
+	assert.Nil(t, wallet.Save())
+	data := store.GetData()
+	assert.NotNil(t, data)
+
+	wallet, err = OpenWalletWithKeyStore(NewMemoryKeyStore(data))
+	assert.Nil(t, err)
+	acc2, err := wallet.GetDefaultAccount(passwd)
+	assert.Nil(t, err)
+	assert.Equal(t, acc.Address, acc2.Address)
+}
+
+func TestDirKeyStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "key_store_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	passwd := []byte("123456")
+	store := NewDirKeyStore(dir)
+	_, err = OpenWalletWithKeyStore(store)
+	assert.Equal(t, ERR_KEY_STORE_EMPTY, err)
+
+	wallet := NewWalletWithKeyStore(store)
+	acc1, err := wallet.NewDefaultSettingAccount(passwd)
+	assert.Nil(t, err)
+	acc2, err := wallet.NewDefaultSettingAccount(passwd)
+	assert.Nil(t, err)
+	acc3, err := wallet.NewDefaultSettingAccount(passwd)
+	assert.Nil(t, err)
+	identity, err := wallet.NewDefaultSettingIdentity(passwd)
+	assert.Nil(t, err)
+	assert.Nil(t, wallet.Save())
+	accountFile := filepath.Join(dir, DIR_KEY_STORE_ACCOUNT_DIR, acc2.Address.ToBase58()+".json")
+	_, err = os.Stat(accountFile)
+	assert.Nil(t, err)
+
+	assert.Nil(t, wallet.DeleteAccount(acc2.Address.ToBase58()))
+	assert.Nil(t, wallet.Save())
+	_, err = os.Stat(accountFile)
+	assert.True(t, os.IsNotExist(err))
+
+	wallet, err = OpenWalletWithKeyStore(NewDirKeyStore(dir))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, wallet.GetAccountCount())
+	acc, err := wallet.GetAccountByIndex(1, passwd)
+	assert.Nil(t, err)
+	assert.Equal(t, acc1.Address, acc.Address)
+	acc, err = wallet.GetAccountByIndex(2, passwd)
+	assert.Nil(t, err)
+	assert.Equal(t, acc3.Address, acc.Address)
+	identity, err = wallet.GetIdentityById(identity.ID)
+	assert.Nil(t, err)
+	_, err = identity.GetControllerByIndex(1, passwd)
+	assert.Nil(t, err)
+
+	//directory is not saved atomically, rekey is refused
+	assert.NotNil(t, wallet.Rekey(&WalletRekeyParam{OldPassword: passwd, NewPassword: []byte("654321")}))
+	_, err = wallet.GetAccountByIndex(1, passwd)
+	assert.Nil(t, err)
+}
+
+func TestDirKeyStore_KeyFileName(t *testing.T) {
+	name, err := getKeyFileName("did:tsr:AMFrW7hZ5bkKqBqyKuMvPpkfpuxMqwTgWm")
+	assert.Nil(t, err)
+	assert.Equal(t, "did_tsr_AMFrW7hZ5bkKqBqyKuMvPpkfpuxMqwTgWm.json", name)
+	for _, id := range []string{"", "../evil", "did:tsr:../../evil", "a/b", "a\\b", ".hidden", "a..b"} {
+		_, err = getKeyFileName(id)
+		assert.NotNil(t, err, id)
+	}
+
+	dir, err := ioutil.TempDir("", "key_store_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	store := NewDirKeyStore(filepath.Join(dir, "store"))
+	err = store.Save(&WalletData{Identities: []*IdentityData{{ID: "did:tsr:../../evil"}}})
+	assert.NotNil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "evil.json"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,361 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/tesracrypto/ec"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	s "github.com/TesraSupernet/tesracrypto/signature"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	KEYSTORE_V3_VERSION     = 3
+	KEYSTORE_V3_KDF_SCRYPT  = "scrypt"
+	KEYSTORE_V3_KDF_PBKDF2  = "pbkdf2"
+	KEYSTORE_V3_CIPHER      = "aes-128-ctr"
+	KEYSTORE_V3_PBKDF2_PRF  = "hmac-sha256"
+	keystore_v3_dk_len      = 32
+	keystore_v3_salt_length = 32
+)
+
+//KeystoreV3Param is the key derivation param of keystore
+type KeystoreV3Param struct {
+	Kdf string //KEYSTORE_V3_KDF_SCRYPT or KEYSTORE_V3_KDF_PBKDF2
+	N   int    //scrypt CPU/memory cost
+	R   int    //scrypt block size
+	P   int    //scrypt parallelization
+	C   int    //pbkdf2 iteration count
+}
+
+//DefaultKeystoreV3Param return the standard scrypt param used by Ethereum tools, which takes 256MB memory
+func DefaultKeystoreV3Param() *KeystoreV3Param {
+	return &KeystoreV3Param{
+		Kdf: KEYSTORE_V3_KDF_SCRYPT,
+		N:   1 << 18,
+		R:   8,
+		P:   1,
+		C:   1 << 18,
+	}
+}
+
+type KeystoreV3CipherParams struct {
+	IV string `json:"iv"`
+}
+
+type KeystoreV3Crypto struct {
+	Cipher       string                 `json:"cipher"`
+	CipherText   string                 `json:"ciphertext"`
+	CipherParams KeystoreV3CipherParams `json:"cipherparams"`
+	Kdf          string                 `json:"kdf"`
+	KdfParams    map[string]interface{} `json:"kdfparams"`
+	Mac          string                 `json:"mac"`
+}
+
+//KeystoreV3 is the keystore file of a single account, in the structure of Ethereum V3 keystore. Private key is
+//the raw 32 bytes key. Address is the base58 address, and the fields after version keep the account information
+//of wallet, which are ignored by Ethereum tools
+type KeystoreV3 struct {
+	Address    string            `json:"address"`
+	Crypto     KeystoreV3Crypto  `json:"crypto"`
+	ID         string            `json:"id"`
+	Version    int               `json:"version"`
+	Label      string            `json:"label,omitempty"`
+	PublicKey  string            `json:"publicKey,omitempty"`
+	SigSch     string            `json:"signatureScheme,omitempty"`
+	Algorithm  string            `json:"algorithm,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+//ExportKeystoreV3 decrypt account with passwd, and return the keystore of it encrypted by keystorePasswd.
+//param nil means DefaultKeystoreV3Param
+func ExportKeystoreV3(accData *AccountData, passwd, keystorePasswd []byte, param *KeystoreV3Param) ([]byte, error) {
+	if len(keystorePasswd) == 0 {
+		return nil, fmt.Errorf("keystore password cannot empty")
+	}
+	if param == nil {
+		param = DefaultKeystoreV3Param()
+	}
+	acc, err := accData.GetAccount(passwd)
+	if err != nil {
+		return nil, err
+	}
+	defer WipePrivateKey(acc.PrivateKey)
+	privateKey, err := getRawPrivateKey(acc.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(privateKey)
+
+	salt := make([]byte, keystore_v3_salt_length)
+	iv := make([]byte, aes.BlockSize)
+	id := make([]byte, 16)
+	for _, buf := range [][]byte{salt, iv, id} {
+		_, err = rand.Read(buf)
+		if err != nil {
+			return nil, fmt.Errorf("rand error:%s", err)
+		}
+	}
+	kdfParams := make(map[string]interface{})
+	var dk []byte
+	switch param.Kdf {
+	case KEYSTORE_V3_KDF_SCRYPT:
+		kdfParams["n"] = param.N
+		kdfParams["r"] = param.R
+		kdfParams["p"] = param.P
+		dk, err = scrypt.Key(keystorePasswd, salt, param.N, param.R, param.P, keystore_v3_dk_len)
+		if err != nil {
+			return nil, fmt.Errorf("scrypt error:%s", err)
+		}
+	case KEYSTORE_V3_KDF_PBKDF2:
+		if param.C <= 0 {
+			return nil, fmt.Errorf("invalid pbkdf2 iteration count:%d", param.C)
+		}
+		kdfParams["c"] = param.C
+		kdfParams["prf"] = KEYSTORE_V3_PBKDF2_PRF
+		dk = pbkdf2.Key(keystorePasswd, salt, param.C, keystore_v3_dk_len, sha256.New)
+	default:
+		return nil, fmt.Errorf("unsupport kdf:%s", param.Kdf)
+	}
+	defer wipeBytes(dk)
+	kdfParams["dklen"] = keystore_v3_dk_len
+	kdfParams["salt"] = hex.EncodeToString(salt)
+
+	cipherText, err := aesCTRXOR(dk[:16], iv, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+	keystore := &KeystoreV3{
+		Address: accData.Address,
+		Crypto: KeystoreV3Crypto{
+			Cipher:       KEYSTORE_V3_CIPHER,
+			CipherText:   hex.EncodeToString(cipherText),
+			CipherParams: KeystoreV3CipherParams{IV: hex.EncodeToString(iv)},
+			Kdf:          param.Kdf,
+			KdfParams:    kdfParams,
+			Mac:          hex.EncodeToString(keystoreV3Mac(dk, cipherText)),
+		},
+		ID:         fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:]),
+		Version:    KEYSTORE_V3_VERSION,
+		Label:      accData.Label,
+		PublicKey:  accData.PubKey,
+		SigSch:     accData.SigSch,
+		Algorithm:  accData.Alg,
+		Parameters: accData.Param,
+	}
+	return json.Marshal(keystore)
+}
+
+//ImportKeystoreV3 decrypt keystore with keystorePasswd, and return AccountData encrypted by passwd with scrypt.
+//Keystore without signature scheme, such as written by Ethereum tools, is imported as SHA256withECDSA account
+func ImportKeystoreV3(data, keystorePasswd, passwd []byte, scrypt *keypair.ScryptParam) (*AccountData, error) {
+	keystore := &KeystoreV3{}
+	err := json.Unmarshal(data, keystore)
+	if err != nil {
+		return nil, fmt.Errorf("parse keystore error:%s", err)
+	}
+	if keystore.Version != KEYSTORE_V3_VERSION {
+		return nil, fmt.Errorf("unsupport keystore version:%d", keystore.Version)
+	}
+	privateKey, err := keystore.decrypt(keystorePasswd)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(privateKey)
+	sigScheme := s.SHA256withECDSA
+	if keystore.SigSch != "" {
+		sigScheme, err = s.GetScheme(keystore.SigSch)
+		if err != nil {
+			return nil, fmt.Errorf("unknown signature scheme:%s", keystore.SigSch)
+		}
+	}
+	keyType, err := GetKeyTypeOfScheme(sigScheme)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := NewAccountFromPrivateKeyWithKeyType(privateKey, keyType, sigScheme)
+	if err != nil {
+		return nil, err
+	}
+	defer WipePrivateKey(acc.PrivateKey)
+	if keystore.PublicKey != "" && keystore.PublicKey != hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey)) {
+		return nil, fmt.Errorf("public key of keystore does not match private key")
+	}
+	if addr, err := common.AddressFromBase58(keystore.Address); err == nil && addr != acc.Address {
+		return nil, fmt.Errorf("address of keystore does not match private key")
+	}
+	accData, err := NewAccountDataFromAccount(acc, passwd, scrypt)
+	if err != nil {
+		return nil, err
+	}
+	accData.Label = keystore.Label
+	return accData, nil
+}
+
+func (this *KeystoreV3) decrypt(keystorePasswd []byte) ([]byte, error) {
+	crypto := this.Crypto
+	if crypto.Cipher != KEYSTORE_V3_CIPHER {
+		return nil, fmt.Errorf("unsupport cipher:%s", crypto.Cipher)
+	}
+	cipherText, err := hex.DecodeString(crypto.CipherText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext:%s", err)
+	}
+	iv, err := hex.DecodeString(crypto.CipherParams.IV)
+	if err != nil || len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid iv:%s", crypto.CipherParams.IV)
+	}
+	mac, err := hex.DecodeString(crypto.Mac)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mac:%s", err)
+	}
+	dk, err := keystoreV3DeriveKey(keystorePasswd, crypto.Kdf, crypto.KdfParams)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(dk)
+	if !hmac.Equal(keystoreV3Mac(dk, cipherText), mac) {
+		return nil, fmt.Errorf("mac mismatch, maybe wrong password")
+	}
+	return aesCTRXOR(dk[:16], iv, cipherText)
+}
+
+func keystoreV3DeriveKey(passwd []byte, kdf string, params map[string]interface{}) ([]byte, error) {
+	salt, err := hex.DecodeString(getKdfString(params, "salt"))
+	if err != nil || len(salt) == 0 {
+		return nil, fmt.Errorf("invalid kdf salt")
+	}
+	dkLen := getKdfInt(params, "dklen")
+	if dkLen < keystore_v3_dk_len {
+		return nil, fmt.Errorf("invalid kdf dklen:%d", dkLen)
+	}
+	switch kdf {
+	case KEYSTORE_V3_KDF_SCRYPT:
+		dk, err := scrypt.Key(passwd, salt, getKdfInt(params, "n"), getKdfInt(params, "r"), getKdfInt(params, "p"), dkLen)
+		if err != nil {
+			return nil, fmt.Errorf("scrypt error:%s", err)
+		}
+		return dk, nil
+	case KEYSTORE_V3_KDF_PBKDF2:
+		if prf := getKdfString(params, "prf"); prf != KEYSTORE_V3_PBKDF2_PRF {
+			return nil, fmt.Errorf("unsupport pbkdf2 prf:%s", prf)
+		}
+		c := getKdfInt(params, "c")
+		if c <= 0 {
+			return nil, fmt.Errorf("invalid pbkdf2 iteration count:%d", c)
+		}
+		return pbkdf2.Key(passwd, salt, c, dkLen, sha256.New), nil
+	default:
+		return nil, fmt.Errorf("unsupport kdf:%s", kdf)
+	}
+}
+
+func getKdfString(params map[string]interface{}, key string) string {
+	value, _ := params[key].(string)
+	return value
+}
+
+func getKdfInt(params map[string]interface{}, key string) int {
+	value, _ := params[key].(float64)
+	return int(value)
+}
+
+//keystoreV3Mac return keccak256 of the second 16 bytes of derived key and cipher text
+func keystoreV3Mac(dk, cipherText []byte) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(dk[16:32])
+	hash.Write(cipherText)
+	return hash.Sum(nil)
+}
+
+func aesCTRXOR(key, iv, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("aes.NewCipher error:%s", err)
+	}
+	out := make([]byte, len(data))
+	cipher.NewCTR(block, iv).XORKeyStream(out, data)
+	return out, nil
+}
+
+//getRawPrivateKey return the 32 bytes private key, which is the scalar of P-256 or SM2 key, or the seed of
+//ed25519 key
+func getRawPrivateKey(privateKey keypair.PrivateKey) ([]byte, error) {
+	switch key := privateKey.(type) {
+	case *ec.PrivateKey:
+		if key.Params().BitSize != 256 {
+			return nil, fmt.Errorf("unsupport curve:%s", key.Params().Name)
+		}
+		raw := make([]byte, 32)
+		d := key.D.Bytes()
+		copy(raw[32-len(d):], d)
+		wipeBytes(d)
+		return raw, nil
+	case ed25519.PrivateKey:
+		raw := make([]byte, ed25519.SeedSize)
+		copy(raw, key[:ed25519.SeedSize])
+		return raw, nil
+	default:
+		return nil, fmt.Errorf("unsupport private key type")
+	}
+}
+
+func wipeBytes(data []byte) {
+	for i := range data {
+		data[i] = 0
+	}
+}
+
+//ImportKeystoreV3 import keystore into wallet, private key is encrypted by passwd
+func (this *Wallet) ImportKeystoreV3(data, keystorePasswd, passwd []byte) (*AccountData, error) {
+	accData, err := ImportKeystoreV3(data, keystorePasswd, passwd, this.Scrypt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = this.GetAccountDataByAddress(accData.Address); err == nil {
+		return nil, fmt.Errorf("account:%s already exist", accData.Address)
+	}
+	err = this.AddAccountData(accData)
+	if err != nil {
+		return nil, err
+	}
+	return accData, nil
+}
+
+//ExportKeystoreV3 return the keystore of account of address in wallet, see ExportKeystoreV3
+func (this *Wallet) ExportKeystoreV3(address string, passwd, keystorePasswd []byte, param *KeystoreV3Param) ([]byte, error) {
+	accData, err := this.GetAccountDataByAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	return ExportKeystoreV3(accData, passwd, keystorePasswd, param)
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"encoding/hex"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	s "github.com/TesraSupernet/tesracrypto/signature"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestImportKeystoreV3_Ethereum(t *testing.T) {
+	//test vector of Ethereum V3 keystore, password is testpassword
+	keystore := `{"crypto":{"cipher":"aes-128-ctr","cipherparams":{"iv":"6087dab2f9fdbbfaddc31a909735c1e6"},"ciphertext":"5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46","kdf":"pbkdf2","kdfparams":{"c":262144,"dklen":32,"prf":"hmac-sha256","salt":"ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"},"mac":"517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"},"id":"3198bc9c-6672-5ab3-d995-4942343ae5b6","version":3}`
+	passwd := []byte("123456")
+	_, err := ImportKeystoreV3([]byte(keystore), []byte("wrong"), passwd, keypair.GetScryptParameters())
+	assert.NotNil(t, err)
+	accData, err := ImportKeystoreV3([]byte(keystore), []byte("testpassword"), passwd, keypair.GetScryptParameters())
+	assert.Nil(t, err)
+	acc, err := accData.GetAccount(passwd)
+	assert.Nil(t, err)
+	privateKey, err := hex.DecodeString("7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d")
+	assert.Nil(t, err)
+	expected, err := NewAccountFromPrivateKey(privateKey, s.SHA256withECDSA)
+	assert.Nil(t, err)
+	assert.Equal(t, expected.Address, acc.Address)
+}
+
+func TestKeystoreV3(t *testing.T) {
+	passwd := []byte("123456")
+	keystorePasswd := []byte("keystore password")
+	wallet := NewWalletWithKeyStore(NewMemoryKeyStore(nil))
+	acc1, err := wallet.NewDefaultSettingAccount(passwd)
+	assert.Nil(t, err)
+	assert.Nil(t, wallet.SetLabel(acc1.Address.ToBase58(), "main"))
+	acc2, err := wallet.NewAccount(keypair.PK_EDDSA, keypair.ED25519, s.SHA512withEDDSA, passwd)
+	assert.Nil(t, err)
+
+	params := []*KeystoreV3Param{
+		{Kdf: KEYSTORE_V3_KDF_SCRYPT, N: 4096, R: 8, P: 1},
+		{Kdf: KEYSTORE_V3_KDF_PBKDF2, C: 10240},
+	}
+	other := NewWalletWithKeyStore(NewMemoryKeyStore(nil))
+	for i, acc := range []*Account{acc1, acc2} {
+		address := acc.Address.ToBase58()
+		_, err = wallet.ExportKeystoreV3(address, []byte("wrong"), keystorePasswd, params[i])
+		assert.NotNil(t, err)
+		keystore, err := wallet.ExportKeystoreV3(address, passwd, keystorePasswd, params[i])
+		assert.Nil(t, err)
+
+		newPasswd := []byte("654321")
+		accData, err := other.ImportKeystoreV3(keystore, keystorePasswd, newPasswd)
+		assert.Nil(t, err)
+		_, err = other.ImportKeystoreV3(keystore, keystorePasswd, newPasswd)
+		assert.NotNil(t, err)
+		imported, err := other.GetAccountByAddress(address, newPasswd)
+		assert.Nil(t, err)
+		assert.Equal(t, acc.PrivateKey, imported.PrivateKey)
+		assert.Equal(t, acc.SigScheme, imported.SigScheme)
+		if i == 0 {
+			assert.Equal(t, "main", accData.Label)
+		}
+	}
+}
@@ -0,0 +1,426 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"github.com/tyler-smith/go-bip39/wordlists"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+	"sort"
+	"strings"
+)
+
+const (
+	MNEMONIC_LANG_ENGLISH             = "english"
+	MNEMONIC_LANG_CHINESE_SIMPLIFIED  = "chinese_simplified"
+	MNEMONIC_LANG_CHINESE_TRADITIONAL = "chinese_traditional"
+	MNEMONIC_LANG_FRENCH              = "french"
+	MNEMONIC_LANG_ITALIAN             = "italian"
+	MNEMONIC_LANG_JAPANESE            = "japanese"
+	MNEMONIC_LANG_KOREAN              = "korean"
+	MNEMONIC_LANG_SPANISH             = "spanish"
+)
+
+//DEFAULT_MNEMONIC_WORD_COUNT is the word count of mnemonic generated by default, 128 bits entropy
+var DEFAULT_MNEMONIC_WORD_COUNT = 12
+
+//MNEMONIC_MAX_SUGGESTIONS is the max typo suggestions of an invalid word
+var MNEMONIC_MAX_SUGGESTIONS = 5
+
+const (
+	MNEMONIC_ERR_WORD_COUNT   = "invalid word count"
+	MNEMONIC_ERR_UNKNOWN_WORD = "unknown word"
+	MNEMONIC_ERR_CHECKSUM     = "checksum incorrect"
+)
+
+//mnemonicLanguages is in the order of language detection, the first one wins if words are in several wordlists
+var mnemonicLanguages = []string{
+	MNEMONIC_LANG_ENGLISH,
+	MNEMONIC_LANG_CHINESE_SIMPLIFIED,
+	MNEMONIC_LANG_CHINESE_TRADITIONAL,
+	MNEMONIC_LANG_FRENCH,
+	MNEMONIC_LANG_ITALIAN,
+	MNEMONIC_LANG_JAPANESE,
+	MNEMONIC_LANG_KOREAN,
+	MNEMONIC_LANG_SPANISH,
+}
+
+var mnemonicWordLists = map[string][]string{
+	MNEMONIC_LANG_ENGLISH:             wordlists.English,
+	MNEMONIC_LANG_CHINESE_SIMPLIFIED:  wordlists.ChineseSimplified,
+	MNEMONIC_LANG_CHINESE_TRADITIONAL: wordlists.ChineseTraditional,
+	MNEMONIC_LANG_FRENCH:              wordlists.French,
+	MNEMONIC_LANG_ITALIAN:             wordlists.Italian,
+	MNEMONIC_LANG_JAPANESE:            wordlists.Japanese,
+	MNEMONIC_LANG_KOREAN:              wordlists.Korean,
+	MNEMONIC_LANG_SPANISH:             wordlists.Spanish,
+}
+
+var mnemonicWordIndexes = make(map[string]map[string]int)
+
+func init() {
+	for lang, words := range mnemonicWordLists {
+		indexes := make(map[string]int, len(words))
+		for i, word := range words {
+			indexes[word] = i
+		}
+		mnemonicWordIndexes[lang] = indexes
+	}
+}
+
+//MnemonicFix is a suggested replacement of the word at Position(start from 0)
+type MnemonicFix struct {
+	Position    int
+	Word        string
+	Replacement string
+}
+
+//MnemonicError is the error of invalid mnemonic, with the typo suggestions if any
+type MnemonicError struct {
+	Reason   string //MNEMONIC_ERR_WORD_COUNT, MNEMONIC_ERR_UNKNOWN_WORD or MNEMONIC_ERR_CHECKSUM
+	Position int    //position of unknown word, -1 if error is not caused by a single word
+	Word     string
+	Fixes    []*MnemonicFix
+}
+
+func (this *MnemonicError) Error() string {
+	msg := "invalid mnemonic, " + this.Reason
+	if this.Position >= 0 {
+		msg += fmt.Sprintf(" %q at position %d", this.Word, this.Position+1)
+	}
+	if len(this.Fixes) > 0 {
+		fixes := make([]string, 0, len(this.Fixes))
+		for _, fix := range this.Fixes {
+			fixes = append(fixes, fmt.Sprintf("%q->%q at position %d", fix.Word, fix.Replacement, fix.Position+1))
+		}
+		msg += ", did you mean: " + strings.Join(fixes, ", ")
+	}
+	return msg
+}
+
+//GetMnemonicLanguages return the supported mnemonic languages
+func GetMnemonicLanguages() []string {
+	langs := make([]string, len(mnemonicLanguages))
+	copy(langs, mnemonicLanguages)
+	return langs
+}
+
+//GetMnemonicWordList return the BIP39 wordlist of language
+func GetMnemonicWordList(lang string) ([]string, error) {
+	words, ok := mnemonicWordLists[lang]
+	if !ok {
+		return nil, fmt.Errorf("unsupport mnemonic language:%s", lang)
+	}
+	wordList := make([]string, len(words))
+	copy(wordList, words)
+	return wordList, nil
+}
+
+//GetMnemonicEntropyBits return the entropy bits of mnemonic word count, which is one of 12, 15, 18, 21 and 24
+func GetMnemonicEntropyBits(wordCount int) (int, error) {
+	if wordCount < 12 || wordCount > 24 || wordCount%3 != 0 {
+		return 0, fmt.Errorf("invalid mnemonic word count:%d, should be 12, 15, 18, 21 or 24", wordCount)
+	}
+	return wordCount * 11 * 32 / 33, nil
+}
+
+//NewMnemonic generate a random mnemonic of wordCount words in lang
+func NewMnemonic(wordCount int, lang string) (string, error) {
+	bits, err := GetMnemonicEntropyBits(wordCount)
+	if err != nil {
+		return "", err
+	}
+	entropy := make([]byte, bits/8)
+	_, err = rand.Read(entropy)
+	if err != nil {
+		return "", fmt.Errorf("generate entropy error:%s", err)
+	}
+	return EntropyToMnemonic(entropy, lang)
+}
+
+//EntropyToMnemonic return the mnemonic of entropy in lang. Japanese words are separated by ideographic space
+func EntropyToMnemonic(entropy []byte, lang string) (string, error) {
+	words, ok := mnemonicWordLists[lang]
+	if !ok {
+		return "", fmt.Errorf("unsupport mnemonic language:%s", lang)
+	}
+	bits := len(entropy) * 8
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return "", fmt.Errorf("invalid entropy length:%d", len(entropy))
+	}
+	checksum := sha256.Sum256(entropy)
+	data := append(append([]byte{}, entropy...), checksum[0])
+	wordCount := (bits + bits/32) / 11
+	mnemonic := make([]string, 0, wordCount)
+	for i := 0; i < wordCount; i++ {
+		mnemonic = append(mnemonic, words[getMnemonicBits(data, i*11)])
+	}
+	sep := " "
+	if lang == MNEMONIC_LANG_JAPANESE {
+		sep = "　"
+	}
+	return strings.Join(mnemonic, sep), nil
+}
+
+//MnemonicToEntropy return the entropy of mnemonic after validation
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words, lang, err := parseMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	entropy, ok := getMnemonicEntropy(getMnemonicWordIndexes(words, lang))
+	if !ok {
+		return nil, &MnemonicError{
+			Reason:   MNEMONIC_ERR_CHECKSUM,
+			Position: -1,
+			Fixes:    getChecksumFixes(words, lang),
+		}
+	}
+	return entropy, nil
+}
+
+//ValidateMnemonic check the words and checksum of mnemonic. The returned error is *MnemonicError with the
+//suggested replacements of unknown word, or the single word replacements which make checksum valid
+func ValidateMnemonic(mnemonic string) error {
+	_, err := MnemonicToEntropy(mnemonic)
+	return err
+}
+
+//DetectMnemonicLanguage return the language of mnemonic, which has the most words in its wordlist
+func DetectMnemonicLanguage(mnemonic string) (string, error) {
+	words := splitMnemonicWords(mnemonic)
+	if len(words) == 0 {
+		return "", fmt.Errorf("mnemonic is empty")
+	}
+	lang, count := detectMnemonicLanguage(words)
+	if count == 0 {
+		return "", fmt.Errorf("unknown mnemonic language")
+	}
+	return lang, nil
+}
+
+//NormalizeMnemonic return the NFKD normalized mnemonic with words separated by single space
+func NormalizeMnemonic(mnemonic string) string {
+	return strings.Join(splitMnemonicWords(mnemonic), " ")
+}
+
+//MnemonicToSeed validate mnemonic and return the BIP39 seed with passphrase, both are NFKD normalized.
+//Empty passphrase is the same as bip39.NewSeed(mnemonic, "")
+func MnemonicToSeed(mnemonic, passphrase string) ([]byte, error) {
+	err := ValidateMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	salt := "mnemonic" + norm.NFKD.String(passphrase)
+	return pbkdf2.Key([]byte(NormalizeMnemonic(mnemonic)), []byte(salt), 2048, 64, sha512.New), nil
+}
+
+//splitMnemonicWords split NFKD normalized mnemonic by space. Chinese mnemonic without space is split to characters
+func splitMnemonicWords(mnemonic string) []string {
+	words := strings.Fields(strings.ToLower(norm.NFKD.String(mnemonic)))
+	if len(words) != 1 {
+		return words
+	}
+	chars := []rune(words[0])
+	if len(chars) < 12 {
+		return words
+	}
+	for _, char := range chars {
+		_, simplified := mnemonicWordIndexes[MNEMONIC_LANG_CHINESE_SIMPLIFIED][string(char)]
+		_, traditional := mnemonicWordIndexes[MNEMONIC_LANG_CHINESE_TRADITIONAL][string(char)]
+		if !simplified && !traditional {
+			return words
+		}
+	}
+	words = make([]string, 0, len(chars))
+	for _, char := range chars {
+		words = append(words, string(char))
+	}
+	return words
+}
+
+func detectMnemonicLanguage(words []string) (string, int) {
+	bestLang, bestCount := MNEMONIC_LANG_ENGLISH, 0
+	for _, lang := range mnemonicLanguages {
+		count := 0
+		for _, word := range words {
+			if _, ok := mnemonicWordIndexes[lang][word]; ok {
+				count++
+			}
+		}
+		if count > bestCount {
+			bestLang, bestCount = lang, count
+		}
+	}
+	return bestLang, bestCount
+}
+
+//parseMnemonic split mnemonic to words and detect language, return *MnemonicError if word count is invalid or
+//there is unknown word
+func parseMnemonic(mnemonic string) ([]string, string, error) {
+	words := splitMnemonicWords(mnemonic)
+	if _, err := GetMnemonicEntropyBits(len(words)); err != nil {
+		return nil, "", &MnemonicError{Reason: MNEMONIC_ERR_WORD_COUNT, Position: -1}
+	}
+	lang, _ := detectMnemonicLanguage(words)
+	for i, word := range words {
+		if _, ok := mnemonicWordIndexes[lang][word]; ok {
+			continue
+		}
+		fixes := make([]*MnemonicFix, 0)
+		for _, suggestion := range suggestMnemonicWords(word, lang) {
+			fixes = append(fixes, &MnemonicFix{Position: i, Word: word, Replacement: suggestion})
+		}
+		return nil, "", &MnemonicError{
+			Reason:   MNEMONIC_ERR_UNKNOWN_WORD,
+			Position: i,
+			Word:     word,
+			Fixes:    fixes,
+		}
+	}
+	return words, lang, nil
+}
+
+func getMnemonicWordIndexes(words []string, lang string) []int {
+	indexes := make([]int, 0, len(words))
+	for _, word := range words {
+		indexes = append(indexes, mnemonicWordIndexes[lang][word])
+	}
+	return indexes
+}
+
+//getMnemonicEntropy return the entropy of word indexes and whether the checksum is correct
+func getMnemonicEntropy(indexes []int) ([]byte, bool) {
+	data := make([]byte, (len(indexes)*11+7)/8)
+	for i, index := range indexes {
+		for j := 0; j < 11; j++ {
+			if index&(1<<uint(10-j)) != 0 {
+				pos := i*11 + j
+				data[pos/8] |= 1 << uint(7-pos%8)
+			}
+		}
+	}
+	bits := len(indexes) * 11 * 32 / 33
+	entropy := data[:bits/8]
+	checksumBits := uint(bits / 32)
+	checksum := sha256.Sum256(entropy)
+	return entropy, data[bits/8]>>(8-checksumBits) == checksum[0]>>(8-checksumBits)
+}
+
+//getMnemonicBits return the 11 bits from bit offset of data
+func getMnemonicBits(data []byte, offset int) int {
+	index := 0
+	for i := 0; i < 11; i++ {
+		pos := offset + i
+		index <<= 1
+		if data[pos/8]&(1<<uint(7-pos%8)) != 0 {
+			index |= 1
+		}
+	}
+	return index
+}
+
+//getChecksumFixes return the replacements of a single word by similar word, which make the checksum valid
+func getChecksumFixes(words []string, lang string) []*MnemonicFix {
+	indexes := getMnemonicWordIndexes(words, lang)
+	fixes := make([]*MnemonicFix, 0)
+	for i, word := range words {
+		for _, suggestion := range suggestMnemonicWords(word, lang) {
+			fixed := make([]int, len(indexes))
+			copy(fixed, indexes)
+			fixed[i] = mnemonicWordIndexes[lang][suggestion]
+			if _, ok := getMnemonicEntropy(fixed); ok {
+				fixes = append(fixes, &MnemonicFix{Position: i, Word: word, Replacement: suggestion})
+			}
+		}
+	}
+	return fixes
+}
+
+//suggestMnemonicWords return the words in wordlist of lang within edit distance 2 of word, or with the same first
+//4 characters, nearest first. word itself is excluded. Single character words such as Chinese have no suggestion
+func suggestMnemonicWords(word, lang string) []string {
+	type candidate struct {
+		word     string
+		distance int
+	}
+	chars := []rune(word)
+	if len(chars) < 2 {
+		return nil
+	}
+	candidates := make([]*candidate, 0)
+	for _, w := range mnemonicWordLists[lang] {
+		if w == word {
+			continue
+		}
+		distance := getEditDistance(chars, []rune(w))
+		if distance > 2 && !hasSamePrefix(chars, []rune(w), 4) {
+			continue
+		}
+		candidates = append(candidates, &candidate{word: w, distance: distance})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+	suggestions := make([]string, 0, MNEMONIC_MAX_SUGGESTIONS)
+	for _, c := range candidates {
+		if len(suggestions) >= MNEMONIC_MAX_SUGGESTIONS {
+			break
+		}
+		suggestions = append(suggestions, c.word)
+	}
+	return suggestions
+}
+
+func hasSamePrefix(a, b []rune, n int) bool {
+	if len(a) < n || len(b) < n {
+		return false
+	}
+	return string(a[:n]) == string(b[:n])
+}
+
+//getEditDistance return the Levenshtein distance of a and b
+func getEditDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
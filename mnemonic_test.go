@@ -0,0 +1,153 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/tyler-smith/go-bip39"
+	"strings"
+	"testing"
+)
+
+func TestNewMnemonic(t *testing.T) {
+	for _, lang := range GetMnemonicLanguages() {
+		for _, wordCount := range []int{12, 15, 18, 21, 24} {
+			mnemonic, err := NewMnemonic(wordCount, lang)
+			assert.Nil(t, err)
+			assert.Equal(t, wordCount, len(splitMnemonicWords(mnemonic)))
+			assert.Nil(t, ValidateMnemonic(mnemonic))
+			detected, err := DetectMnemonicLanguage(mnemonic)
+			assert.Nil(t, err)
+			if lang != MNEMONIC_LANG_CHINESE_TRADITIONAL {
+				assert.Equal(t, lang, detected)
+			}
+		}
+	}
+	_, err := NewMnemonic(13, MNEMONIC_LANG_ENGLISH)
+	assert.NotNil(t, err)
+	_, err = NewMnemonic(12, "klingon")
+	assert.NotNil(t, err)
+}
+
+func TestEntropyToMnemonic(t *testing.T) {
+	entropy := make([]byte, 32)
+	for i := range entropy {
+		entropy[i] = byte(i)
+	}
+	mnemonic, err := EntropyToMnemonic(entropy, MNEMONIC_LANG_ENGLISH)
+	assert.Nil(t, err)
+	assert.Equal(t, "abandon amount liar amount expire adjust cage candy arch gather drum bullet absurd math era live "+
+		"bid rhythm alien crouch range attend journey unaware", mnemonic)
+	mnemonic, err = EntropyToMnemonic(entropy[:20], MNEMONIC_LANG_ENGLISH)
+	assert.Nil(t, err)
+	assert.Equal(t, "abandon amount liar amount expire adjust cage candy arch gather drum bullet absurd math exhibit", mnemonic)
+	bip39Mnemonic, err := bip39.NewMnemonic(entropy[:20])
+	assert.Nil(t, err)
+	assert.Equal(t, bip39Mnemonic, mnemonic)
+	restored, err := MnemonicToEntropy(mnemonic)
+	assert.Nil(t, err)
+	assert.Equal(t, entropy[:20], restored)
+
+	mnemonic, err = EntropyToMnemonic(entropy[:16], MNEMONIC_LANG_CHINESE_SIMPLIFIED)
+	assert.Nil(t, err)
+	assert.Equal(t, "的 三 欧 三 考 于 据 保 量 损 破 战", mnemonic)
+	restored, err = MnemonicToEntropy(strings.Replace(mnemonic, " ", "", -1))
+	assert.Nil(t, err)
+	assert.Equal(t, entropy[:16], restored)
+}
+
+func TestMnemonicToSeed(t *testing.T) {
+	seed, err := MnemonicToSeed(testMnemonic, "TREZOR")
+	assert.Nil(t, err)
+	assert.Equal(t, "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04", hex.EncodeToString(seed))
+	seed, err = MnemonicToSeed("  ABANDON abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about ", "")
+	assert.Nil(t, err)
+	assert.Equal(t, bip39.NewSeed(testMnemonic, ""), seed)
+
+	seed, err = MnemonicToSeed("的 三 欧 三 考 于 据 保 量 损 破 战", "密码")
+	assert.Nil(t, err)
+	assert.Equal(t, "e224352084f215be106ad71889327ae71a4c7add56ebf68379cad50fcfc24180425d808d6737c74032985f934ec4457362e9ea9e1fcf0f8d5a80cd6407806abf", hex.EncodeToString(seed))
+	noSpace, err := MnemonicToSeed("的三欧三考于据保量损破战", "密码")
+	assert.Nil(t, err)
+	assert.Equal(t, seed, noSpace)
+}
+
+func TestValidateMnemonic(t *testing.T) {
+	words := strings.Fields(testMnemonic)
+	words[11] = "abuot"
+	err := ValidateMnemonic(strings.Join(words, " "))
+	mnemonicErr, ok := err.(*MnemonicError)
+	assert.True(t, ok)
+	assert.Equal(t, MNEMONIC_ERR_UNKNOWN_WORD, mnemonicErr.Reason)
+	assert.Equal(t, 11, mnemonicErr.Position)
+	assert.Equal(t, "about", mnemonicErr.Fixes[0].Replacement)
+
+	words[11] = "above"
+	err = ValidateMnemonic(strings.Join(words, " "))
+	mnemonicErr, ok = err.(*MnemonicError)
+	assert.True(t, ok)
+	assert.Equal(t, MNEMONIC_ERR_CHECKSUM, mnemonicErr.Reason)
+	found := false
+	for _, fix := range mnemonicErr.Fixes {
+		if fix.Position == 11 && fix.Replacement == "about" {
+			found = true
+		}
+	}
+	assert.True(t, found)
+
+	err = ValidateMnemonic(strings.Join(words[:11], " "))
+	mnemonicErr, ok = err.(*MnemonicError)
+	assert.True(t, ok)
+	assert.Equal(t, MNEMONIC_ERR_WORD_COUNT, mnemonicErr.Reason)
+
+	sdk := NewTesraSdk()
+	_, err = sdk.GetPrivateKeyFromMnemonicCodesStrBip44WithPassphrase(strings.Join(words, " "), "", 0)
+	assert.NotNil(t, err)
+	//legacy api keeps deriving from the raw mnemonic without validation
+	_, err = sdk.GetPrivateKeyFromMnemonicCodesStrBip44(strings.Join(words, " "), 0)
+	assert.Nil(t, err)
+	privateKey, err := sdk.GetPrivateKeyFromMnemonicCodesStrBip44WithPassphrase(testMnemonic, "TREZOR", 0)
+	assert.Nil(t, err)
+	seed, err := MnemonicToSeed(testMnemonic, "TREZOR")
+	assert.Nil(t, err)
+	acc, err := DeriveAccountFromSeed(seed, GetHDAccountPath(0))
+	assert.Nil(t, err)
+	passphraseAcc, err := NewAccountFromPrivateKey(privateKey, acc.SigScheme)
+	assert.Nil(t, err)
+	assert.Equal(t, acc.Address, passphraseAcc.Address)
+}
+
+func TestWallet_InitHDWithPassphrase(t *testing.T) {
+	passwd := []byte("123456")
+	wallet := NewWallet("passphrase_wallet.dat")
+	mnemonic, err := wallet.InitHDWithOptions(24, MNEMONIC_LANG_CHINESE_SIMPLIFIED, "TREZOR", passwd)
+	assert.Nil(t, err)
+	assert.Equal(t, 24, len(strings.Fields(mnemonic)))
+	seed, err := wallet.GetHDSeed(passwd)
+	assert.Nil(t, err)
+	expected, err := MnemonicToSeed(mnemonic, "TREZOR")
+	assert.Nil(t, err)
+	assert.Equal(t, expected, seed)
+
+	other := NewWallet("passphrase_wallet.dat")
+	assert.Nil(t, other.InitHDFromMnemonic(mnemonic, passwd))
+	otherSeed, err := other.GetHDSeed(passwd)
+	assert.Nil(t, err)
+	assert.NotEqual(t, seed, otherSeed)
+}
@@ -0,0 +1,305 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/common/constants"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/Tesra/smartcontract/service/native/tsr"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	"github.com/TesraSupernet/tesrasdk/utils"
+)
+
+//MultiSigAccountData is the m-of-n multi-signature account saved in wallet
+type MultiSigAccountData struct {
+	Label     string   `json:"label"`
+	Address   string   `json:"address"`
+	PubKeys   []string `json:"publicKeys"` //public keys in hex
+	M         uint16   `json:"m"`
+	CoSigners []string `json:"coSigners,omitempty"` //addresses of the accounts in wallet which are co-signers
+}
+
+//NewMultiSigAccountData return the m-of-n multi-signature account of pubKeys
+func NewMultiSigAccountData(label string, m uint16, pubKeys []keypair.PublicKey) (*MultiSigAccountData, error) {
+	if m == 0 || int(m) > len(pubKeys) || len(pubKeys) > constants.MULTI_SIG_MAX_PUBKEY_SIZE {
+		return nil, fmt.Errorf("both m and number of pub key must larger than 0, and small than %d, and m must smaller than pub key number", constants.MULTI_SIG_MAX_PUBKEY_SIZE)
+	}
+	address, err := types.AddressFromMultiPubKeys(pubKeys, int(m))
+	if err != nil {
+		return nil, fmt.Errorf("AddressFromMultiPubKeys error:%s", err)
+	}
+	accData := &MultiSigAccountData{
+		Label:     label,
+		Address:   address.ToBase58(),
+		PubKeys:   make([]string, 0, len(pubKeys)),
+		M:         m,
+		CoSigners: make([]string, 0),
+	}
+	for _, pubKey := range pubKeys {
+		accData.PubKeys = append(accData.PubKeys, hex.EncodeToString(keypair.SerializePublicKey(pubKey)))
+	}
+	return accData, nil
+}
+
+//GetPubKeys return the public keys of multi-signature account
+func (this *MultiSigAccountData) GetPubKeys() ([]keypair.PublicKey, error) {
+	pubKeys := make([]keypair.PublicKey, 0, len(this.PubKeys))
+	for _, pk := range this.PubKeys {
+		data, err := hex.DecodeString(pk)
+		if err != nil {
+			return nil, fmt.Errorf("decode public key:%s error:%s", pk, err)
+		}
+		pubKey, err := keypair.DeserializePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("deserialize public key:%s error:%s", pk, err)
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+	return pubKeys, nil
+}
+
+//GetAddress return the address of multi-signature account
+func (this *MultiSigAccountData) GetAddress() (common.Address, error) {
+	return common.AddressFromBase58(this.Address)
+}
+
+//Validate check the address matches the public keys and m
+func (this *MultiSigAccountData) Validate() error {
+	pubKeys, err := this.GetPubKeys()
+	if err != nil {
+		return err
+	}
+	accData, err := NewMultiSigAccountData(this.Label, this.M, pubKeys)
+	if err != nil {
+		return err
+	}
+	if accData.Address != this.Address {
+		return fmt.Errorf("address:%s does not match public keys and m, expect:%s", this.Address, accData.Address)
+	}
+	return nil
+}
+
+//IsCoSigner return whether address is co-signer of multi-signature account in wallet
+func (this *MultiSigAccountData) IsCoSigner(address string) bool {
+	for _, coSigner := range this.CoSigners {
+		if coSigner == address {
+			return true
+		}
+	}
+	return false
+}
+
+func (this *MultiSigAccountData) Clone() *MultiSigAccountData {
+	accData := *this
+	accData.PubKeys = make([]string, len(this.PubKeys))
+	copy(accData.PubKeys, this.PubKeys)
+	accData.CoSigners = make([]string, len(this.CoSigners))
+	copy(accData.CoSigners, this.CoSigners)
+	return &accData
+}
+
+//updateCoSigners set co-signers to the addresses of public keys which have account in accAddressMap
+func (this *MultiSigAccountData) updateCoSigners(accAddressMap map[string]*AccountData) {
+	coSigners := make([]string, 0)
+	pubKeys, err := this.GetPubKeys()
+	if err != nil {
+		return
+	}
+	for _, pubKey := range pubKeys {
+		address := types.AddressFromPubKey(pubKey).ToBase58()
+		accData, ok := accAddressMap[address]
+		if ok && !accData.WatchOnly {
+			coSigners = append(coSigners, address)
+		}
+	}
+	this.CoSigners = coSigners
+}
+
+//AddMultiSigAccount add the m-of-n multi-signature account of pubKeys into wallet, the accounts of pubKeys in wallet
+//are recorded as co-signers
+func (this *Wallet) AddMultiSigAccount(label string, m uint16, pubKeys []keypair.PublicKey) (*MultiSigAccountData, error) {
+	accData, err := NewMultiSigAccountData(label, m, pubKeys)
+	if err != nil {
+		return nil, err
+	}
+	err = this.AddMultiSigAccountData(accData)
+	if err != nil {
+		return nil, err
+	}
+	return this.GetMultiSigAccountByAddress(accData.Address)
+}
+
+//AddMultiSigAccountData add a copy of multi-signature account into wallet
+func (this *Wallet) AddMultiSigAccountData(accData *MultiSigAccountData) error {
+	err := accData.Validate()
+	if err != nil {
+		return err
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for _, msAcc := range this.multiSigAccounts {
+		if msAcc.Address == accData.Address {
+			return fmt.Errorf("duplicate multi-sig account:%s", accData.Address)
+		}
+		if accData.Label != "" && msAcc.Label == accData.Label {
+			return fmt.Errorf("duplicate multi-sig account label:%s", accData.Label)
+		}
+	}
+	accData = accData.Clone()
+	accData.updateCoSigners(this.accAddressMap)
+	this.multiSigAccounts = append(this.multiSigAccounts, accData)
+	return nil
+}
+
+//DeleteMultiSigAccount delete multi-signature account from wallet
+func (this *Wallet) DeleteMultiSigAccount(address string) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for i, msAcc := range this.multiSigAccounts {
+		if msAcc.Address == address {
+			this.multiSigAccounts = append(this.multiSigAccounts[:i], this.multiSigAccounts[i+1:]...)
+			return nil
+		}
+	}
+	return ERR_ACCOUNT_NOT_FOUND
+}
+
+//GetMultiSigAccountByAddress return multi-signature account of address
+func (this *Wallet) GetMultiSigAccountByAddress(address string) (*MultiSigAccountData, error) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	for _, msAcc := range this.multiSigAccounts {
+		if msAcc.Address == address {
+			return msAcc.Clone(), nil
+		}
+	}
+	return nil, ERR_ACCOUNT_NOT_FOUND
+}
+
+//GetMultiSigAccountByLabel return multi-signature account of label
+func (this *Wallet) GetMultiSigAccountByLabel(label string) (*MultiSigAccountData, error) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	for _, msAcc := range this.multiSigAccounts {
+		if msAcc.Label == label {
+			return msAcc.Clone(), nil
+		}
+	}
+	return nil, ERR_ACCOUNT_NOT_FOUND
+}
+
+//GetMultiSigAccounts return all the multi-signature accounts in wallet
+func (this *Wallet) GetMultiSigAccounts() []*MultiSigAccountData {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	accounts := make([]*MultiSigAccountData, 0, len(this.multiSigAccounts))
+	for _, msAcc := range this.multiSigAccounts {
+		accounts = append(accounts, msAcc.Clone())
+	}
+	return accounts
+}
+
+//updateMultiSigCoSigners refresh the co-signers of multi-signature accounts after accounts changed, lock should be held
+func (this *Wallet) updateMultiSigCoSigners() {
+	for _, msAcc := range this.multiSigAccounts {
+		msAcc.updateCoSigners(this.accAddressMap)
+	}
+}
+
+//NewMultiSigTransferTransaction return the unsigned TSR or TSG transfer transaction from multi-signature account,
+//which is also the payer
+func (this *TesraSdk) NewMultiSigTransferTransaction(gasPrice, gasLimit uint64, msAcc *MultiSigAccountData, asset, to common.Address, amount uint64) (*types.MutableTransaction, error) {
+	var version byte
+	switch asset {
+	case TSR_CONTRACT_ADDRESS:
+		version = TSR_CONTRACT_VERSION
+	case TSG_CONTRACT_ADDRESS:
+		version = TSG_CONTRACT_VERSION
+	default:
+		return nil, fmt.Errorf("unsupport asset:%s", asset.ToHexString())
+	}
+	from, err := msAcc.GetAddress()
+	if err != nil {
+		return nil, err
+	}
+	state := &tsr.State{
+		From:  from,
+		To:    to,
+		Value: amount,
+	}
+	tx, err := this.Native.NewNativeInvokeTransaction(gasPrice, gasLimit, version, asset, tsr.TRANSFER_NAME, []interface{}{[]*tsr.State{state}})
+	if err != nil {
+		return nil, err
+	}
+	this.SetPayer(tx, from)
+	return tx, nil
+}
+
+//SignToTransactionByMultiSigAccount add the signature of signer to tx for multi-signature account
+func (this *TesraSdk) SignToTransactionByMultiSigAccount(tx *types.MutableTransaction, msAcc *MultiSigAccountData, signer Signer) error {
+	pubKeys, err := msAcc.GetPubKeys()
+	if err != nil {
+		return err
+	}
+	return this.MultiSignToTransaction(tx, msAcc.M, pubKeys, signer)
+}
+
+//SignToTransactionByWallet sign tx for multi-signature account of address by its co-signers in wallet, whose private
+//keys are decrypted by passwd, until there are m signatures. Locked co-signers are skipped. Return the signature
+//count of multi-signature account in tx
+func (this *TesraSdk) SignToTransactionByWallet(tx *types.MutableTransaction, wallet *Wallet, address string, passwd []byte) (int, error) {
+	//msAcc is a copy taken under wallet lock, so co-signers are not changed by other goroutines while signing
+	msAcc, err := wallet.GetMultiSigAccountByAddress(address)
+	if err != nil {
+		return 0, err
+	}
+	pubKeys, err := msAcc.GetPubKeys()
+	if err != nil {
+		return 0, err
+	}
+	for _, coSigner := range msAcc.CoSigners {
+		if getMultiSigCount(tx, pubKeys) >= int(msAcc.M) {
+			break
+		}
+		accData, err := wallet.GetAccountDataByAddress(coSigner)
+		if err != nil || accData.Lock {
+			continue
+		}
+		signer, err := accData.GetAccount(passwd)
+		if err != nil {
+			return getMultiSigCount(tx, pubKeys), fmt.Errorf("co-signer:%s error:%s", coSigner, err)
+		}
+		err = this.MultiSignToTransaction(tx, msAcc.M, pubKeys, signer)
+		if err != nil {
+			return getMultiSigCount(tx, pubKeys), fmt.Errorf("co-signer:%s sign error:%s", coSigner, err)
+		}
+	}
+	return getMultiSigCount(tx, pubKeys), nil
+}
+
+func getMultiSigCount(tx *types.MutableTransaction, pubKeys []keypair.PublicKey) int {
+	for _, sig := range tx.Sigs {
+		if utils.PubKeysEqual(sig.PubKeys, pubKeys) {
+			return len(sig.SigData)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"github.com/TesraSupernet/Tesra/core/validation"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWallet_MultiSigAccount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "multisig_account_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "wallet.dat")
+	passwd := []byte("123456")
+	wallet := NewWallet(path)
+	acc1, err := wallet.NewDefaultSettingAccount(passwd)
+	assert.Nil(t, err)
+	acc2, err := wallet.NewDefaultSettingAccount(passwd)
+	assert.Nil(t, err)
+	acc3 := NewAccount()
+	pubKeys := []keypair.PublicKey{acc1.PublicKey, acc2.PublicKey, acc3.PublicKey}
+
+	msAcc, err := wallet.AddMultiSigAccount("treasury", 2, pubKeys)
+	assert.Nil(t, err)
+	sdk := NewTesraSdk()
+	address, err := sdk.GetMultiAddr(pubKeys, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, address, msAcc.Address)
+	assert.Equal(t, []string{acc1.Address.ToBase58(), acc2.Address.ToBase58()}, msAcc.CoSigners)
+	_, err = wallet.AddMultiSigAccount("treasury", 1, pubKeys)
+	assert.NotNil(t, err)
+	_, err = wallet.AddMultiSigAccount("bad", 4, pubKeys)
+	assert.NotNil(t, err)
+	assert.Nil(t, wallet.Save())
+
+	wallet, err = OpenWallet(path)
+	assert.Nil(t, err)
+	msAcc, err = wallet.GetMultiSigAccountByLabel("treasury")
+	assert.Nil(t, err)
+	assert.Equal(t, address, msAcc.Address)
+	assert.True(t, msAcc.IsCoSigner(acc2.Address.ToBase58()))
+	assert.False(t, msAcc.IsCoSigner(acc3.Address.ToBase58()))
+
+	tx, err := sdk.NewMultiSigTransferTransaction(500, 20000, msAcc, TSG_CONTRACT_ADDRESS, acc3.Address, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, address, tx.Payer.ToBase58())
+	count, err := sdk.SignToTransactionByWallet(tx, wallet, msAcc.Address, passwd)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+	immutTx, err := tx.IntoImmutable()
+	assert.Nil(t, err)
+	assert.Equal(t, "not an error", validation.VerifyTransaction(immutTx).Error())
+
+	assert.Nil(t, wallet.DeleteAccount(acc2.Address.ToBase58()))
+	assert.Equal(t, 2, len(msAcc.CoSigners))
+	msAcc, err = wallet.GetMultiSigAccountByAddress(msAcc.Address)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{acc1.Address.ToBase58()}, msAcc.CoSigners)
+	//changes of returned copy do not affect wallet
+	msAcc.M = 3
+	msAcc.CoSigners = nil
+	walletMsAcc, err := wallet.GetMultiSigAccountByAddress(msAcc.Address)
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(2), walletMsAcc.M)
+	assert.Equal(t, []string{acc1.Address.ToBase58()}, walletMsAcc.CoSigners)
+	msAcc = walletMsAcc
+	tx, err = sdk.NewMultiSigTransferTransaction(500, 20000, msAcc, TSG_CONTRACT_ADDRESS, acc3.Address, 10)
+	assert.Nil(t, err)
+	count, err = sdk.SignToTransactionByWallet(tx, wallet, msAcc.Address, passwd)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	assert.Nil(t, sdk.SignToTransactionByMultiSigAccount(tx, msAcc, acc3))
+	assert.Equal(t, 2, getMultiSigCount(tx, pubKeys))
+
+	assert.Nil(t, wallet.DeleteMultiSigAccount(msAcc.Address))
+	assert.Equal(t, 0, len(wallet.GetMultiSigAccounts()))
+}
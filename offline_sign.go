@@ -0,0 +1,286 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/core/payload"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+)
+
+//TxSummary is the human readable summary of a transaction, for review before signing
+type TxSummary struct {
+	TxHash   string          `json:"txHash"`
+	TxType   string          `json:"txType"`
+	Nonce    uint32          `json:"nonce"`
+	GasPrice uint64          `json:"gasPrice"`
+	GasLimit uint64          `json:"gasLimit"`
+	MaxFee   uint64          `json:"maxFee"`
+	Payer    string          `json:"payer"`
+	Payload  *DecodedPayload `json:"payload,omitempty"`
+	Code     string          `json:"code"`
+}
+
+//NewTxSummary return the summary of tx. The summary only depends on tx, so the offline side can
+//always compute it by itself instead of trusting the one sent by the online side.
+func NewTxSummary(tx *types.MutableTransaction) (*TxSummary, error) {
+	if tx == nil {
+		return nil, fmt.Errorf("tx should not be nil")
+	}
+	txHash := tx.Hash()
+	summary := &TxSummary{
+		TxHash:   txHash.ToHexString(),
+		TxType:   GetTxTypeString(tx.TxType),
+		Nonce:    tx.Nonce,
+		GasPrice: tx.GasPrice,
+		GasLimit: tx.GasLimit,
+		MaxFee:   tx.GasPrice * tx.GasLimit,
+		Payer:    tx.Payer.ToBase58(),
+	}
+	switch pl := tx.Payload.(type) {
+	case *payload.InvokeCode:
+		summary.Code = hex.EncodeToString(pl.Code)
+	case *payload.DeployCode:
+		summary.Code = hex.EncodeToString(pl.GetRawCode())
+	default:
+		return nil, fmt.Errorf("unsupport payload type:%T", tx.Payload)
+	}
+	decoded, err := DecodeTransaction(tx)
+	if err == nil {
+		summary.Payload = decoded
+	}
+	return summary, nil
+}
+
+//GetTxTypeString return the name of transaction type
+func GetTxTypeString(txType types.TransactionType) string {
+	switch txType {
+	case types.Deploy:
+		return "Deploy"
+	case types.InvokeTeo:
+		return "InvokeTeo"
+	case types.InvokeWasm:
+		return "InvokeWasm"
+	default:
+		return fmt.Sprintf("0x%x", byte(txType))
+	}
+}
+
+//OfflineSignRequest is exported by the online machine, and carries the unsigned transaction to the offline machine
+type OfflineSignRequest struct {
+	Summary *TxSummary          `json:"summary"`
+	Tx      *PartialTransaction `json:"tx"`
+}
+
+//NewOfflineSignRequest return an OfflineSignRequest of the unsigned tx. Multi-sign signers
+//can be declared by Tx.AddSigner before export, normal accounts are added when they sign.
+func NewOfflineSignRequest(tx *types.MutableTransaction, metadata map[string]string) (*OfflineSignRequest, error) {
+	ptx, err := NewPartialTransaction(tx, metadata)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := NewTxSummary(tx)
+	if err != nil {
+		return nil, err
+	}
+	return &OfflineSignRequest{
+		Summary: summary,
+		Tx:      ptx,
+	}, nil
+}
+
+//ToJson return the json encoding of request
+func (this *OfflineSignRequest) ToJson() ([]byte, error) {
+	return json.Marshal(this)
+}
+
+//OfflineSignRequestFromJson parse an OfflineSignRequest from json
+func OfflineSignRequestFromJson(data []byte) (*OfflineSignRequest, error) {
+	type offlineSignRequest struct {
+		Summary *TxSummary      `json:"summary"`
+		Tx      json.RawMessage `json:"tx"`
+	}
+	req := &offlineSignRequest{}
+	err := json.Unmarshal(data, req)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal OfflineSignRequest error:%s", err)
+	}
+	ptx, err := PartialTransactionFromJson(req.Tx)
+	if err != nil {
+		return nil, err
+	}
+	return &OfflineSignRequest{
+		Summary: req.Summary,
+		Tx:      ptx,
+	}, nil
+}
+
+//OfflineSignResult is returned by the offline machine, and carries the signatures back to the online machine
+type OfflineSignResult struct {
+	Tx *PartialTransaction `json:"tx"`
+}
+
+//ToJson return the json encoding of result
+func (this *OfflineSignResult) ToJson() ([]byte, error) {
+	return json.Marshal(this)
+}
+
+//OfflineSignResultFromJson parse an OfflineSignResult from json
+func OfflineSignResultFromJson(data []byte) (*OfflineSignResult, error) {
+	type offlineSignResult struct {
+		Tx json.RawMessage `json:"tx"`
+	}
+	res := &offlineSignResult{}
+	err := json.Unmarshal(data, res)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal OfflineSignResult error:%s", err)
+	}
+	ptx, err := PartialTransactionFromJson(res.Tx)
+	if err != nil {
+		return nil, err
+	}
+	return &OfflineSignResult{Tx: ptx}, nil
+}
+
+//OfflineSigner sign OfflineSignRequest with the accounts of a wallet. OfflineSigner only holds a
+//Wallet and has no client of Tesra, so it never touches the network.
+type OfflineSigner struct {
+	wallet *Wallet
+}
+
+//NewOfflineSigner return an OfflineSigner of wallet
+func NewOfflineSigner(wallet *Wallet) *OfflineSigner {
+	return &OfflineSigner{
+		wallet: wallet,
+	}
+}
+
+//Review return the summary of request computed from the transaction itself. If the summary
+//in request does not match, request has been tampered and an error is returned.
+func (this *OfflineSigner) Review(req *OfflineSignRequest) (*TxSummary, error) {
+	if req == nil || req.Tx == nil {
+		return nil, fmt.Errorf("invalid offline sign request")
+	}
+	tx, err := req.Tx.GetTransaction()
+	if err != nil {
+		return nil, err
+	}
+	summary, err := NewTxSummary(tx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Summary != nil {
+		expect, err := json.Marshal(summary)
+		if err != nil {
+			return nil, err
+		}
+		got, err := json.Marshal(req.Summary)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(expect, got) {
+			return nil, fmt.Errorf("summary of request does not match transaction:%s", summary.TxHash)
+		}
+	}
+	return summary, nil
+}
+
+//Sign sign request with the account of address in wallet. If the account is not an expected
+//signer of request yet, it is added as a normal account signer.
+func (this *OfflineSigner) Sign(req *OfflineSignRequest, address string, passwd []byte) (*OfflineSignResult, error) {
+	_, err := this.Review(req)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := this.wallet.GetAccountByAddress(address, passwd)
+	if err != nil {
+		return nil, fmt.Errorf("GetAccountByAddress:%s error:%s", address, err)
+	}
+	return this.sign(req, acc)
+}
+
+//SignWithSigner sign request with signer. The request is left untouched, signatures are
+//only added to the transaction of result.
+func (this *OfflineSigner) SignWithSigner(req *OfflineSignRequest, signer Signer) (*OfflineSignResult, error) {
+	_, err := this.Review(req)
+	if err != nil {
+		return nil, err
+	}
+	return this.sign(req, signer)
+}
+
+func (this *OfflineSigner) sign(req *OfflineSignRequest, signer Signer) (*OfflineSignResult, error) {
+	data, err := req.Tx.ToJson()
+	if err != nil {
+		return nil, err
+	}
+	ptx, err := PartialTransactionFromJson(data)
+	if err != nil {
+		return nil, err
+	}
+	pubKey := hex.EncodeToString(keypair.SerializePublicKey(signer.GetPublicKey()))
+	expected := false
+	for _, ps := range ptx.Signers {
+		if ps.hasPubKey(pubKey) {
+			expected = true
+			break
+		}
+	}
+	if !expected {
+		err = ptx.AddSigner(1, []keypair.PublicKey{signer.GetPublicKey()}, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = ptx.Sign(signer)
+	if err != nil {
+		return nil, err
+	}
+	return &OfflineSignResult{Tx: ptx}, nil
+}
+
+//ImportOfflineSignResult merge the signatures of results into request, and return the signed transaction ready to send
+func ImportOfflineSignResult(req *OfflineSignRequest, results ...*OfflineSignResult) (*types.MutableTransaction, error) {
+	if req == nil || req.Tx == nil {
+		return nil, fmt.Errorf("invalid offline sign request")
+	}
+	for _, res := range results {
+		if res == nil || res.Tx == nil {
+			return nil, fmt.Errorf("invalid offline sign result")
+		}
+		err := req.Tx.Merge(res.Tx)
+		if err != nil {
+			return nil, fmt.Errorf("merge offline sign result error:%s", err)
+		}
+	}
+	return req.Tx.Finalize()
+}
+
+//SendOfflineSignResult import results into request, and send the signed transaction to Tesra
+func (this *TesraSdk) SendOfflineSignResult(req *OfflineSignRequest, results ...*OfflineSignResult) (common.Uint256, error) {
+	tx, err := ImportOfflineSignResult(req, results...)
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	return this.SendTransaction(tx)
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"github.com/TesraSupernet/Tesra/core/validation"
+	"github.com/TesraSupernet/Tesra/smartcontract/service/native/tsr"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestOfflineSigner_RoundTrip(t *testing.T) {
+	passwd := []byte("123456")
+	//offline side only holds a wallet, no TesraSdk and no client
+	wallet := NewWalletWithKeyStore(NewMemoryKeyStore(nil))
+	acc, err := wallet.NewDefaultSettingAccount(passwd)
+	assert.Nil(t, err)
+	offlineSigner := NewOfflineSigner(wallet)
+
+	sdk := NewTesraSdk()
+	tx, err := sdk.Native.NewNativeInvokeTransaction(500, 20000, TSR_CONTRACT_VERSION, TSR_CONTRACT_ADDRESS,
+		tsr.TRANSFER_NAME, []interface{}{[]*tsr.State{{From: acc.Address, To: NewAccount().Address, Value: 10}}})
+	assert.Nil(t, err)
+	sdk.SetPayer(tx, acc.Address)
+	req, err := NewOfflineSignRequest(tx, nil)
+	assert.Nil(t, err)
+	reqData, err := req.ToJson()
+	assert.Nil(t, err)
+
+	offlineReq, err := OfflineSignRequestFromJson(reqData)
+	assert.Nil(t, err)
+	summary, err := offlineSigner.Review(offlineReq)
+	assert.Nil(t, err)
+	txHash := tx.Hash()
+	assert.Equal(t, txHash.ToHexString(), summary.TxHash)
+	assert.Equal(t, acc.Address.ToBase58(), summary.Payer)
+	assert.Equal(t, uint64(500*20000), summary.MaxFee)
+	res, err := offlineSigner.Sign(offlineReq, acc.Address.ToBase58(), passwd)
+	assert.Nil(t, err)
+	//request is not modified by signing
+	assert.Equal(t, 0, len(offlineReq.Tx.Signers))
+	resData, err := res.ToJson()
+	assert.Nil(t, err)
+
+	onlineRes, err := OfflineSignResultFromJson(resData)
+	assert.Nil(t, err)
+	signedTx, err := ImportOfflineSignResult(req, onlineRes)
+	assert.Nil(t, err)
+	assert.Equal(t, txHash, signedTx.Hash())
+	immutTx, err := signedTx.IntoImmutable()
+	assert.Nil(t, err)
+	assert.Equal(t, "not an error", validation.VerifyTransaction(immutTx).Error())
+}
+
+func TestOfflineSigner_ReviewTampered(t *testing.T) {
+	passwd := []byte("123456")
+	wallet := NewWalletWithKeyStore(NewMemoryKeyStore(nil))
+	acc, err := wallet.NewDefaultSettingAccount(passwd)
+	assert.Nil(t, err)
+	offlineSigner := NewOfflineSigner(wallet)
+
+	sdk := NewTesraSdk()
+	tx, err := sdk.Native.NewNativeInvokeTransaction(500, 20000, TSR_CONTRACT_VERSION, TSR_CONTRACT_ADDRESS,
+		tsr.TRANSFER_NAME, []interface{}{[]*tsr.State{{From: acc.Address, To: NewAccount().Address, Value: 10}}})
+	assert.Nil(t, err)
+	sdk.SetPayer(tx, acc.Address)
+	req, err := NewOfflineSignRequest(tx, nil)
+	assert.Nil(t, err)
+	_, err = offlineSigner.Review(req)
+	assert.Nil(t, err)
+
+	req.Summary.GasPrice = 1
+	_, err = offlineSigner.Review(req)
+	assert.NotNil(t, err)
+	_, err = offlineSigner.Sign(req, acc.Address.ToBase58(), passwd)
+	assert.NotNil(t, err)
+	_, err = offlineSigner.SignWithSigner(req, acc)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(req.Tx.Signers))
+}
@@ -0,0 +1,547 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/core/types"
+	sdkcom "github.com/TesraSupernet/tesrasdk/common"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	OUTBOX_TX_PENDING   = "pending"   //recorded, waiting to be broadcast or packed
+	OUTBOX_TX_CONFIRMED = "confirmed" //packed into block and executed successfully
+	OUTBOX_TX_FAILED    = "failed"    //packed into block but execute failed, or cannot be sent
+	OUTBOX_TX_EXPIRED   = "expired"   //never broadcast before expire time, so it cannot be packed
+	OUTBOX_TX_DROPPED   = "dropped"   //broadcast but not in mempool or chain after expire time and drop grace period, it may still be packed if someone rebroadcasts it
+)
+
+var (
+	DEFAULT_OUTBOX_RESEND_TIMEOUT   = time.Minute
+	DEFAULT_OUTBOX_EXPIRE_TIME      = time.Hour
+	DEFAULT_OUTBOX_DROP_GRACE       = 10 * time.Minute
+	DEFAULT_OUTBOX_MAX_SEND_COUNT   = 10
+	DEFAULT_OUTBOX_PROCESS_INTERVAL = 6 * time.Second
+)
+
+//OutboxClient is the chain client used by Outbox, which is implemented by TesraSdk
+type OutboxClient interface {
+	SendTransaction(tx *types.MutableTransaction) (common.Uint256, error)
+	GetBlockHeightByTxHash(txHash string) (uint32, error)
+	GetSmartContractEvent(txHash string) (*sdkcom.SmartContactEvent, error)
+	GetMemPoolTxState(txHash string) (*sdkcom.MemPoolTxState, error)
+}
+
+//OutboxTx is a signed transaction recorded in Outbox
+type OutboxTx struct {
+	Key          string            `json:"key"`
+	TxHash       string            `json:"txHash"`
+	RawTx        string            `json:"rawTx"`
+	Payer        string            `json:"payer"`
+	Nonce        uint32            `json:"nonce"`
+	State        string            `json:"state"`
+	Height       uint32            `json:"height,omitempty"`
+	SendCount    int               `json:"sendCount"`
+	LastError    string            `json:"lastError,omitempty"`
+	CreateTime   int64             `json:"createTime"`
+	LastSendTime int64             `json:"lastSendTime,omitempty"`
+	ExpireTime   int64             `json:"expireTime,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+//IsFinal return whether the state of tx will not change any more. A dropped tx is final and not
+//checked any more, although it may still be packed if someone else rebroadcasts it.
+func (this *OutboxTx) IsFinal() bool {
+	return this.State != OUTBOX_TX_PENDING
+}
+
+type outboxData struct {
+	Nonces map[string]uint32 `json:"nonces"`
+	Txs    []*OutboxTx       `json:"txs"`
+}
+
+//Outbox is a durable file-backed queue of outbound transactions. Every tx is saved to file
+//before broadcast, so after restart the outbox knows which txs have been sent, and keeps
+//tracking them until they are confirmed, failed, expired or dropped. Adding a tx with the same key or hash
+//again is a no-op, so callers can safely retry after crash. Network requests are made without
+//holding the lock of outbox, so a slow node does not block other operations.
+type Outbox struct {
+	ResendTimeout time.Duration //rebroadcast tx if it is not in mempool or chain after timeout
+	ExpireTime    time.Duration //stop broadcast after expire time, tx never broadcast is marked as expired
+	DropGrace     time.Duration //tx broadcast but not in mempool or chain after expire time and grace is marked as dropped
+	MaxSendCount  int           //mark tx as failed if it cannot be sent after max send count
+	path          string
+	tesraSdk      *TesraSdk
+	client        OutboxClient
+	nonces        map[string]uint32
+	txs           []*OutboxTx
+	txHashMap     map[string]*OutboxTx
+	txKeyMap      map[string]*OutboxTx
+	lock          sync.Mutex
+	processLock   sync.Mutex
+	exitCh        chan interface{}
+}
+
+//OpenOutbox open the outbox file of path, if the file does not exist, a new outbox is created
+func OpenOutbox(path string, tesraSdk *TesraSdk) (*Outbox, error) {
+	outbox := &Outbox{
+		ResendTimeout: DEFAULT_OUTBOX_RESEND_TIMEOUT,
+		ExpireTime:    DEFAULT_OUTBOX_EXPIRE_TIME,
+		DropGrace:     DEFAULT_OUTBOX_DROP_GRACE,
+		MaxSendCount:  DEFAULT_OUTBOX_MAX_SEND_COUNT,
+		path:          path,
+		tesraSdk:      tesraSdk,
+		client:        tesraSdk,
+		nonces:        make(map[string]uint32),
+		txs:           make([]*OutboxTx, 0),
+		txHashMap:     make(map[string]*OutboxTx),
+		txKeyMap:      make(map[string]*OutboxTx),
+	}
+	if !common.FileExisted(path) {
+		return outbox, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	od := &outboxData{}
+	err = json.Unmarshal(data, od)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal outbox:%s error:%s", path, err)
+	}
+	if od.Nonces != nil {
+		outbox.nonces = od.Nonces
+	}
+	for _, otx := range od.Txs {
+		outbox.addTx(otx)
+	}
+	return outbox, nil
+}
+
+//SetClient set the chain client of outbox, which is TesraSdk by default
+func (this *Outbox) SetClient(client OutboxClient) *Outbox {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.client = client
+	return this
+}
+
+func (this *Outbox) addTx(otx *OutboxTx) {
+	this.txs = append(this.txs, otx)
+	this.txHashMap[otx.TxHash] = otx
+	if otx.Key != "" {
+		this.txKeyMap[otx.Key] = otx
+	}
+}
+
+//save write outbox to a temp file and rename it to path, so the file is never half written
+func (this *Outbox) save() error {
+	data, err := json.Marshal(&outboxData{Nonces: this.nonces, Txs: this.txs})
+	if err != nil {
+		return err
+	}
+	filename := this.path + "~"
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	closeErr := f.Close()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	return os.Rename(filename, this.path)
+}
+
+//AssignNonce set a nonce of tx which is never used by the payer of tx in this outbox.
+//It should be called before tx is signed.
+func (this *Outbox) AssignNonce(tx *types.MutableTransaction) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	payer := tx.Payer.ToBase58()
+	nonce, ok := this.nonces[payer]
+	if ok {
+		nonce++
+	} else {
+		nonce = rand.Uint32()
+	}
+	this.nonces[payer] = nonce
+	err := this.save()
+	if err != nil {
+		return err
+	}
+	tx.Nonce = nonce
+	return nil
+}
+
+//Add record the signed tx into outbox without broadcast. Key is an optional idempotent key of tx,
+//if a tx with the same key or hash is already in outbox, the recorded one is returned.
+func (this *Outbox) Add(key string, tx *types.MutableTransaction, metadata map[string]string) (*OutboxTx, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	otx, err := this.add(key, tx, metadata)
+	if err != nil {
+		return nil, err
+	}
+	cpy := *otx
+	return &cpy, nil
+}
+
+func (this *Outbox) add(key string, tx *types.MutableTransaction, metadata map[string]string) (*OutboxTx, error) {
+	txHash := tx.Hash()
+	if otx, ok := this.txHashMap[txHash.ToHexString()]; ok {
+		return otx, nil
+	}
+	if otx, ok := this.txKeyMap[key]; key != "" && ok {
+		return otx, nil
+	}
+	problems := ValidateTransaction(tx)
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid tx:%s", problems.Error())
+	}
+	payer := tx.Payer.ToBase58()
+	for _, otx := range this.txs {
+		if otx.Payer == payer && otx.Nonce == tx.Nonce && !otx.IsFinal() {
+			return nil, fmt.Errorf("nonce:%d of payer:%s is used by pending tx:%s", tx.Nonce, payer, otx.TxHash)
+		}
+	}
+	rawTx, err := this.tesraSdk.GetTxData(tx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	otx := &OutboxTx{
+		Key:        key,
+		TxHash:     txHash.ToHexString(),
+		RawTx:      rawTx,
+		Payer:      payer,
+		Nonce:      tx.Nonce,
+		State:      OUTBOX_TX_PENDING,
+		CreateTime: now.Unix(),
+		Metadata:   metadata,
+	}
+	if this.ExpireTime > 0 {
+		otx.ExpireTime = now.Add(this.ExpireTime).Unix()
+	}
+	this.addTx(otx)
+	err = this.save()
+	if err != nil {
+		this.removeTx(otx)
+		return nil, fmt.Errorf("save outbox error:%s", err)
+	}
+	return otx, nil
+}
+
+func (this *Outbox) removeTx(otx *OutboxTx) {
+	for i, tx := range this.txs {
+		if tx == otx {
+			this.txs = append(this.txs[:i], this.txs[i+1:]...)
+			break
+		}
+	}
+	delete(this.txHashMap, otx.TxHash)
+	if otx.Key != "" {
+		delete(this.txKeyMap, otx.Key)
+	}
+}
+
+//Send record the signed tx into outbox, and broadcast it if it has not been sent. The error of
+//broadcast is recorded in the returned OutboxTx, and the tx will be resent by Process.
+func (this *Outbox) Send(key string, tx *types.MutableTransaction, metadata map[string]string) (*OutboxTx, error) {
+	this.lock.Lock()
+	otx, err := this.add(key, tx, metadata)
+	if err != nil {
+		this.lock.Unlock()
+		return nil, err
+	}
+	if otx.IsFinal() || otx.SendCount > 0 {
+		cpy := *otx
+		this.lock.Unlock()
+		return &cpy, nil
+	}
+	//mark as sent before broadcast, so that it is not broadcast by Process at the same time
+	this.markSending(otx)
+	err = this.save()
+	rawTx := otx.RawTx
+	client := this.client
+	this.lock.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("save outbox error:%s", err)
+	}
+
+	sendErr := this.sendRawTx(client, rawTx)
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.applySendResult(otx, sendErr)
+	err = this.save()
+	if err != nil {
+		return nil, fmt.Errorf("save outbox error:%s", err)
+	}
+	cpy := *otx
+	return &cpy, nil
+}
+
+func (this *Outbox) markSending(otx *OutboxTx) {
+	otx.SendCount++
+	otx.LastSendTime = time.Now().Unix()
+}
+
+func (this *Outbox) sendRawTx(client OutboxClient, rawTx string) error {
+	tx, err := this.tesraSdk.GetMutableTx(rawTx)
+	if err != nil {
+		return err
+	}
+	_, err = client.SendTransaction(tx)
+	return err
+}
+
+func (this *Outbox) applySendResult(otx *OutboxTx, err error) {
+	if otx.IsFinal() {
+		return
+	}
+	if err != nil {
+		otx.LastError = err.Error()
+		if this.MaxSendCount > 0 && otx.SendCount >= this.MaxSendCount {
+			otx.State = OUTBOX_TX_FAILED
+		}
+		return
+	}
+	otx.LastError = ""
+}
+
+//outboxCheck is the state of a pending tx on chain and in mempool
+type outboxCheck struct {
+	txHash    string
+	height    uint32
+	event     *sdkcom.SmartContactEvent
+	inMempool bool
+}
+
+//Process check the state of pending txs on chain and in mempool once. Packed txs are marked as
+//confirmed or failed by the state of execution, and txs not seen after ResendTimeout are broadcast
+//again. After expire time, txs never broadcast are marked as expired, and txs which have been broadcast
+//are not resent any more, but still checked since they may be packed later. If such a tx is still neither
+//in mempool nor on chain after DropGrace, it is marked as dropped.
+func (this *Outbox) Process() error {
+	this.processLock.Lock()
+	defer this.processLock.Unlock()
+	this.lock.Lock()
+	client := this.client
+	pending := make([]OutboxTx, 0)
+	for _, otx := range this.txs {
+		if !otx.IsFinal() {
+			pending = append(pending, *otx)
+		}
+	}
+	this.lock.Unlock()
+
+	now := time.Now().Unix()
+	resendTimeout := int64(this.ResendTimeout / time.Second)
+	dropGrace := int64(this.DropGrace / time.Second)
+	checks := make([]*outboxCheck, 0, len(pending))
+	for _, otx := range pending {
+		check := &outboxCheck{txHash: otx.TxHash}
+		checks = append(checks, check)
+		height, err := client.GetBlockHeightByTxHash(otx.TxHash)
+		if err == nil && height > 0 {
+			check.height = height
+			event, err := client.GetSmartContractEvent(otx.TxHash)
+			if err == nil {
+				check.event = event
+			}
+			continue
+		}
+		if otx.SendCount > 0 && now-otx.LastSendTime >= resendTimeout &&
+			(otx.ExpireTime == 0 || now < otx.ExpireTime || now >= otx.ExpireTime+dropGrace) {
+			state, err := client.GetMemPoolTxState(otx.TxHash)
+			check.inMempool = err == nil && state != nil && len(state.State) > 0
+		}
+	}
+
+	this.lock.Lock()
+	sending := make([]*OutboxTx, 0)
+	rawTxs := make([]string, 0)
+	for _, check := range checks {
+		otx, ok := this.txHashMap[check.txHash]
+		if !ok || otx.IsFinal() {
+			continue
+		}
+		if check.height > 0 {
+			if check.event == nil {
+				//wait for event in next process
+				continue
+			}
+			otx.Height = check.height
+			if check.event.State == 1 {
+				otx.State = OUTBOX_TX_CONFIRMED
+				otx.LastError = ""
+			} else {
+				otx.State = OUTBOX_TX_FAILED
+				otx.LastError = "tx execute failed"
+			}
+			continue
+		}
+		if otx.ExpireTime > 0 && now >= otx.ExpireTime {
+			if otx.SendCount == 0 {
+				otx.State = OUTBOX_TX_EXPIRED
+			} else if now >= otx.ExpireTime+dropGrace && now-otx.LastSendTime >= resendTimeout && !check.inMempool {
+				otx.State = OUTBOX_TX_DROPPED
+				otx.LastError = "tx is not packed before expire time"
+			}
+			continue
+		}
+		if otx.SendCount > 0 && (now-otx.LastSendTime < resendTimeout || check.inMempool) {
+			continue
+		}
+		this.markSending(otx)
+		sending = append(sending, otx)
+		rawTxs = append(rawTxs, otx.RawTx)
+	}
+	err := this.save()
+	this.lock.Unlock()
+	if err != nil || len(sending) == 0 {
+		return err
+	}
+
+	sendErrs := make([]error, len(sending))
+	for i, rawTx := range rawTxs {
+		sendErrs[i] = this.sendRawTx(client, rawTx)
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for i, otx := range sending {
+		this.applySendResult(otx, sendErrs[i])
+	}
+	return this.save()
+}
+
+//Start process outbox every interval in background until Stop
+func (this *Outbox) Start(interval time.Duration) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.exitCh != nil {
+		return
+	}
+	if interval <= 0 {
+		interval = DEFAULT_OUTBOX_PROCESS_INTERVAL
+	}
+	exitCh := make(chan interface{})
+	this.exitCh = exitCh
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-exitCh:
+				return
+			case <-ticker.C:
+				this.Process()
+			}
+		}
+	}()
+}
+
+//Stop the background process started by Start
+func (this *Outbox) Stop() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.exitCh == nil {
+		return
+	}
+	close(this.exitCh)
+	this.exitCh = nil
+}
+
+//GetTx return the tx of hash in outbox
+func (this *Outbox) GetTx(txHash string) *OutboxTx {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	otx, ok := this.txHashMap[txHash]
+	if !ok {
+		return nil
+	}
+	cpy := *otx
+	return &cpy
+}
+
+//GetTxByKey return the tx of key in outbox
+func (this *Outbox) GetTxByKey(key string) *OutboxTx {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	otx, ok := this.txKeyMap[key]
+	if !ok {
+		return nil
+	}
+	cpy := *otx
+	return &cpy
+}
+
+//GetTxs return the txs in outbox of states, or all the txs if no state is specified
+func (this *Outbox) GetTxs(states ...string) []*OutboxTx {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	txs := make([]*OutboxTx, 0, len(this.txs))
+	for _, otx := range this.txs {
+		match := len(states) == 0
+		for _, state := range states {
+			if otx.State == state {
+				match = true
+				break
+			}
+		}
+		if match {
+			cpy := *otx
+			txs = append(txs, &cpy)
+		}
+	}
+	return txs
+}
+
+//Prune remove the final txs created before time from outbox
+func (this *Outbox) Prune(before time.Time) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	txs := make([]*OutboxTx, 0, len(this.txs))
+	for _, otx := range this.txs {
+		if otx.IsFinal() && otx.CreateTime < before.Unix() {
+			delete(this.txHashMap, otx.TxHash)
+			if otx.Key != "" {
+				delete(this.txKeyMap, otx.Key)
+			}
+			continue
+		}
+		txs = append(txs, otx)
+	}
+	this.txs = txs
+	return this.save()
+}
@@ -0,0 +1,329 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/core/types"
+	sdkcom "github.com/TesraSupernet/tesrasdk/common"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+//stubOutboxClient is an OutboxClient without network, which records the sent txs
+type stubOutboxClient struct {
+	sendErr error
+	sent    map[string]int
+	heights map[string]uint32
+	events  map[string]*sdkcom.SmartContactEvent
+	mempool map[string]bool
+	lock    sync.Mutex
+}
+
+func newStubOutboxClient() *stubOutboxClient {
+	return &stubOutboxClient{
+		sent:    make(map[string]int),
+		heights: make(map[string]uint32),
+		events:  make(map[string]*sdkcom.SmartContactEvent),
+		mempool: make(map[string]bool),
+	}
+}
+
+func (this *stubOutboxClient) SendTransaction(tx *types.MutableTransaction) (common.Uint256, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	txHash := tx.Hash()
+	if this.sendErr != nil {
+		return common.UINT256_EMPTY, this.sendErr
+	}
+	this.sent[txHash.ToHexString()]++
+	return txHash, nil
+}
+
+func (this *stubOutboxClient) GetBlockHeightByTxHash(txHash string) (uint32, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	height, ok := this.heights[txHash]
+	if !ok {
+		return 0, fmt.Errorf("unknown transaction")
+	}
+	return height, nil
+}
+
+func (this *stubOutboxClient) GetSmartContractEvent(txHash string) (*sdkcom.SmartContactEvent, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.events[txHash], nil
+}
+
+func (this *stubOutboxClient) GetMemPoolTxState(txHash string) (*sdkcom.MemPoolTxState, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if !this.mempool[txHash] {
+		return nil, fmt.Errorf("not in mempool")
+	}
+	return &sdkcom.MemPoolTxState{State: []*sdkcom.MemPoolTxStateItem{{Height: 1}}}, nil
+}
+
+func (this *stubOutboxClient) getSendCount(txHash string) int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.sent[txHash]
+}
+
+func (this *stubOutboxClient) getTotalSendCount() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	count := 0
+	for _, n := range this.sent {
+		count += n
+	}
+	return count
+}
+
+func newOutboxTestTx(t *testing.T, outbox *Outbox, acc *Account, amount uint64) *types.MutableTransaction {
+	sdk := NewTesraSdk()
+	tx, err := sdk.Native.Tsg.NewTransferTransaction(500, 20000, acc.Address, NewAccount().Address, amount)
+	assert.Nil(t, err)
+	sdk.SetPayer(tx, acc.Address)
+	assert.Nil(t, outbox.AssignNonce(tx))
+	assert.Nil(t, sdk.SignToTransaction(tx, acc))
+	return tx
+}
+
+func TestOutbox_Send(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "outbox.json")
+	client := newStubOutboxClient()
+	outbox, err := OpenOutbox(path, NewTesraSdk())
+	assert.Nil(t, err)
+	outbox.SetClient(client)
+	acc := NewAccount()
+
+	tx1 := newOutboxTestTx(t, outbox, acc, 100)
+	txHash1 := tx1.Hash()
+	otx, err := outbox.Add("pay1", tx1, map[string]string{"order": "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, OUTBOX_TX_PENDING, otx.State)
+	assert.Equal(t, 0, otx.SendCount)
+	assert.Equal(t, 0, client.getTotalSendCount())
+
+	//the same key returns the recorded tx
+	tx2 := newOutboxTestTx(t, outbox, acc, 200)
+	otx, err = outbox.Send("pay1", tx2, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, txHash1.ToHexString(), otx.TxHash)
+	assert.Equal(t, 1, otx.SendCount)
+	assert.Equal(t, 1, client.getSendCount(txHash1.ToHexString()))
+	otx, err = outbox.Send("pay1", tx1, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, otx.SendCount)
+	//the same hash returns the recorded tx
+	otx, err = outbox.Send("", tx1, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "pay1", otx.Key)
+	assert.Equal(t, 1, client.getTotalSendCount())
+	assert.Nil(t, outbox.GetTxByKey("pay2"))
+
+	//send error is recorded, and the tx is kept pending
+	client.sendErr = fmt.Errorf("connection refused")
+	tx3 := newOutboxTestTx(t, outbox, acc, 300)
+	otx, err = outbox.Send("pay3", tx3, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, OUTBOX_TX_PENDING, otx.State)
+	assert.Equal(t, "connection refused", otx.LastError)
+	client.sendErr = nil
+
+	//reload after restart
+	outbox, err = OpenOutbox(path, NewTesraSdk())
+	assert.Nil(t, err)
+	outbox.SetClient(client)
+	otx = outbox.GetTxByKey("pay1")
+	assert.NotNil(t, otx)
+	assert.Equal(t, 1, otx.SendCount)
+	assert.Equal(t, "1", otx.Metadata["order"])
+	assert.NotNil(t, outbox.GetTx(tx3.Hash().ToHexString()))
+	assert.Equal(t, 2, len(outbox.GetTxs(OUTBOX_TX_PENDING)))
+	otx, err = outbox.Send("pay1", tx1, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, client.getSendCount(txHash1.ToHexString()))
+	//nonce keeps increasing after reload
+	tx4 := newOutboxTestTx(t, outbox, acc, 400)
+	assert.Equal(t, tx3.Nonce+1, tx4.Nonce)
+}
+
+func TestOutbox_NonceConflict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	outbox, err := OpenOutbox(filepath.Join(dir, "outbox.json"), NewTesraSdk())
+	assert.Nil(t, err)
+	outbox.SetClient(newStubOutboxClient())
+	sdk := NewTesraSdk()
+	acc := NewAccount()
+
+	tx1 := newOutboxTestTx(t, outbox, acc, 100)
+	_, err = outbox.Add("", tx1, nil)
+	assert.Nil(t, err)
+	tx2, err := sdk.Native.Tsg.NewTransferTransaction(500, 20000, acc.Address, NewAccount().Address, 200)
+	assert.Nil(t, err)
+	sdk.SetPayer(tx2, acc.Address)
+	tx2.Nonce = tx1.Nonce
+	assert.Nil(t, sdk.SignToTransaction(tx2, acc))
+	_, err = outbox.Add("", tx2, nil)
+	assert.NotNil(t, err)
+	assert.Nil(t, outbox.GetTx(tx2.Hash().ToHexString()))
+
+	//nonce of other payer is not conflict
+	other := NewAccount()
+	tx3, err := sdk.Native.Tsg.NewTransferTransaction(500, 20000, other.Address, NewAccount().Address, 200)
+	assert.Nil(t, err)
+	sdk.SetPayer(tx3, other.Address)
+	tx3.Nonce = tx1.Nonce
+	assert.Nil(t, sdk.SignToTransaction(tx3, other))
+	_, err = outbox.Add("", tx3, nil)
+	assert.Nil(t, err)
+}
+
+func TestOutbox_Process(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	client := newStubOutboxClient()
+	outbox, err := OpenOutbox(filepath.Join(dir, "outbox.json"), NewTesraSdk())
+	assert.Nil(t, err)
+	outbox.SetClient(client)
+	outbox.ResendTimeout = 0
+	outbox.MaxSendCount = 2
+	acc := NewAccount()
+
+	hashes := make([]string, 0)
+	for i := 0; i < 7; i++ {
+		tx := newOutboxTestTx(t, outbox, acc, uint64(i+1))
+		_, err = outbox.Send("", tx, nil)
+		assert.Nil(t, err)
+		hashes = append(hashes, tx.Hash().ToHexString())
+	}
+	//never broadcast
+	tx := newOutboxTestTx(t, outbox, acc, 100)
+	_, err = outbox.Add("", tx, nil)
+	assert.Nil(t, err)
+	unsent := tx.Hash().ToHexString()
+	tx = newOutboxTestTx(t, outbox, acc, 200)
+	_, err = outbox.Add("", tx, nil)
+	assert.Nil(t, err)
+	expired := tx.Hash().ToHexString()
+	outbox.txHashMap[expired].ExpireTime = time.Now().Unix() - 1
+	//broadcast but expired
+	outbox.txHashMap[hashes[6]].ExpireTime = time.Now().Unix() - 1
+
+	client.heights[hashes[0]] = 10
+	client.events[hashes[0]] = &sdkcom.SmartContactEvent{TxHash: hashes[0], State: 1}
+	client.heights[hashes[1]] = 11
+	client.events[hashes[1]] = &sdkcom.SmartContactEvent{TxHash: hashes[1], State: 0}
+	//packed, event is not ready
+	client.heights[hashes[2]] = 12
+	client.mempool[hashes[3]] = true
+	assert.Nil(t, outbox.Process())
+
+	assert.Equal(t, OUTBOX_TX_CONFIRMED, outbox.GetTx(hashes[0]).State)
+	assert.Equal(t, uint32(10), outbox.GetTx(hashes[0]).Height)
+	assert.Equal(t, OUTBOX_TX_FAILED, outbox.GetTx(hashes[1]).State)
+	assert.Equal(t, OUTBOX_TX_PENDING, outbox.GetTx(hashes[2]).State)
+	assert.Equal(t, 1, client.getSendCount(hashes[2]))
+	//in mempool, not resent
+	assert.Equal(t, 1, client.getSendCount(hashes[3]))
+	//not seen, resent
+	assert.Equal(t, 2, client.getSendCount(hashes[4]))
+	assert.Equal(t, 2, outbox.GetTx(hashes[4]).SendCount)
+	assert.Equal(t, 1, client.getSendCount(unsent))
+	assert.Equal(t, OUTBOX_TX_EXPIRED, outbox.GetTx(expired).State)
+	assert.Equal(t, 0, client.getSendCount(expired))
+	//broadcast tx is not expired, and not resent
+	assert.Equal(t, OUTBOX_TX_PENDING, outbox.GetTx(hashes[6]).State)
+	assert.Equal(t, 1, client.getSendCount(hashes[6]))
+
+	//failed after max send count
+	client.sendErr = fmt.Errorf("connection refused")
+	assert.Nil(t, outbox.Process())
+	assert.Equal(t, OUTBOX_TX_FAILED, outbox.GetTx(hashes[5]).State)
+	assert.Equal(t, "connection refused", outbox.GetTx(hashes[5]).LastError)
+	client.sendErr = nil
+
+	//expired tx which has been broadcast is still tracked
+	client.heights[hashes[6]] = 13
+	client.events[hashes[6]] = &sdkcom.SmartContactEvent{TxHash: hashes[6], State: 1}
+	client.events[hashes[2]] = &sdkcom.SmartContactEvent{TxHash: hashes[2], State: 1}
+	assert.Nil(t, outbox.Process())
+	assert.Equal(t, OUTBOX_TX_CONFIRMED, outbox.GetTx(hashes[6]).State)
+	assert.Equal(t, OUTBOX_TX_CONFIRMED, outbox.GetTx(hashes[2]).State)
+
+	assert.Nil(t, outbox.Prune(time.Now().Add(time.Minute)))
+	for _, otx := range outbox.GetTxs() {
+		assert.False(t, otx.IsFinal())
+	}
+}
+
+func TestOutbox_Dropped(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	client := newStubOutboxClient()
+	outbox, err := OpenOutbox(filepath.Join(dir, "outbox.json"), NewTesraSdk())
+	assert.Nil(t, err)
+	outbox.SetClient(client)
+	outbox.ResendTimeout = 0
+	acc := NewAccount()
+
+	hashes := make([]string, 0)
+	for i := 0; i < 2; i++ {
+		tx := newOutboxTestTx(t, outbox, acc, uint64(i+1))
+		_, err = outbox.Send("", tx, nil)
+		assert.Nil(t, err)
+		hashes = append(hashes, tx.Hash().ToHexString())
+		outbox.txHashMap[hashes[i]].ExpireTime = time.Now().Unix() - 1
+	}
+	client.mempool[hashes[1]] = true
+
+	//still in grace period
+	assert.Nil(t, outbox.Process())
+	assert.Equal(t, OUTBOX_TX_PENDING, outbox.GetTx(hashes[0]).State)
+	assert.Equal(t, OUTBOX_TX_PENDING, outbox.GetTx(hashes[1]).State)
+
+	outbox.DropGrace = 0
+	assert.Nil(t, outbox.Process())
+	assert.Equal(t, OUTBOX_TX_DROPPED, outbox.GetTx(hashes[0]).State)
+	assert.True(t, outbox.GetTx(hashes[0]).IsFinal())
+	//in mempool, it may still be packed
+	assert.Equal(t, OUTBOX_TX_PENDING, outbox.GetTx(hashes[1]).State)
+
+	client.mempool[hashes[1]] = false
+	assert.Nil(t, outbox.Process())
+	assert.Equal(t, OUTBOX_TX_DROPPED, outbox.GetTx(hashes[1]).State)
+	//dropped txs are never resent
+	assert.Equal(t, 1, client.getSendCount(hashes[0]))
+	assert.Equal(t, 1, client.getSendCount(hashes[1]))
+}
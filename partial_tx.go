@@ -0,0 +1,463 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/common/constants"
+	"github.com/TesraSupernet/Tesra/core/signature"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+)
+
+//PARTIAL_TX_VERSION is the version of the partially-signed transaction encoding
+const PARTIAL_TX_VERSION = uint32(1)
+
+//PartialSig is a signature collected from one key of a PartialSigner
+type PartialSig struct {
+	PubKey  string `json:"pubKey"`
+	SigData string `json:"sigData"`
+}
+
+//PartialSigner is an expected witness of the transaction, m of PubKeys should sign.
+//A normal account is a PartialSigner with one public key and m = 1
+type PartialSigner struct {
+	Label   string        `json:"label,omitempty"`
+	Address string        `json:"address"`
+	M       uint16        `json:"m"`
+	PubKeys []string      `json:"pubKeys"`
+	Sigs    []*PartialSig `json:"sigs"`
+}
+
+//PartialTransaction is a portable container of an unsigned transaction, its expected
+//signers and the signatures collected so far. It can be passed between co-signers
+//as json or base64 and finalized into a MutableTransaction once complete.
+type PartialTransaction struct {
+	Version  uint32            `json:"version"`
+	TxHash   string            `json:"txHash"`
+	Tx       string            `json:"tx"`
+	Signers  []*PartialSigner  `json:"signers"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	tx       *types.MutableTransaction
+}
+
+//NewPartialTransaction return a PartialTransaction of tx. Signatures already in tx are
+//imported as collected signatures of their signers. Payer of tx must be set, since the
+//payer is part of the transaction hash.
+func NewPartialTransaction(tx *types.MutableTransaction, metadata map[string]string) (*PartialTransaction, error) {
+	if tx == nil {
+		return nil, fmt.Errorf("tx should not be nil")
+	}
+	if tx.Payer == common.ADDRESS_EMPTY {
+		return nil, fmt.Errorf("payer should be set before creating partial transaction")
+	}
+	unsigned := *tx
+	unsigned.Sigs = make([]types.Sig, 0)
+	txData, err := serializeUnsignedTx(&unsigned)
+	if err != nil {
+		return nil, err
+	}
+	txHash := unsigned.Hash()
+	ptx := &PartialTransaction{
+		Version:  PARTIAL_TX_VERSION,
+		TxHash:   txHash.ToHexString(),
+		Tx:       txData,
+		Signers:  make([]*PartialSigner, 0),
+		Metadata: make(map[string]string),
+		tx:       &unsigned,
+	}
+	for k, v := range metadata {
+		ptx.Metadata[k] = v
+	}
+	for _, sig := range tx.Sigs {
+		signer, err := ptx.addSigner(sig.M, sig.PubKeys, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, sigData := range sig.SigData {
+			err = ptx.addSigData(signer, sig.PubKeys, sigData)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ptx, nil
+}
+
+//AddSigner add an expected signer to the transaction. For a normal account pubKeys has
+//one public key and m is 1.
+func (this *PartialTransaction) AddSigner(m uint16, pubKeys []keypair.PublicKey, label string) error {
+	_, err := this.addSigner(m, pubKeys, label)
+	return err
+}
+
+func (this *PartialTransaction) addSigner(m uint16, pubKeys []keypair.PublicKey, label string) (*PartialSigner, error) {
+	pkSize := len(pubKeys)
+	if m == 0 || int(m) > pkSize || pkSize > constants.MULTI_SIG_MAX_PUBKEY_SIZE {
+		return nil, fmt.Errorf("both m and number of pub key must larger than 0, and small than %d, and m must smaller than pub key number", constants.MULTI_SIG_MAX_PUBKEY_SIZE)
+	}
+	var address common.Address
+	var err error
+	if pkSize == 1 {
+		address = types.AddressFromPubKey(pubKeys[0])
+	} else {
+		address, err = types.AddressFromMultiPubKeys(pubKeys, int(m))
+		if err != nil {
+			return nil, fmt.Errorf("AddressFromMultiPubKeys error:%s", err)
+		}
+	}
+	addrStr := address.ToBase58()
+	for _, signer := range this.Signers {
+		if signer.Address == addrStr {
+			if signer.Label == "" {
+				signer.Label = label
+			}
+			return signer, nil
+		}
+	}
+	signer := &PartialSigner{
+		Label:   label,
+		Address: addrStr,
+		M:       m,
+		PubKeys: make([]string, 0, pkSize),
+		Sigs:    make([]*PartialSig, 0),
+	}
+	for _, pk := range pubKeys {
+		signer.PubKeys = append(signer.PubKeys, hex.EncodeToString(keypair.SerializePublicKey(pk)))
+	}
+	this.Signers = append(this.Signers, signer)
+	return signer, nil
+}
+
+//Sign sign the transaction with signer, for every expected signer which contains the public key of signer
+func (this *PartialTransaction) Sign(signer Signer) error {
+	tx, err := this.GetTransaction()
+	if err != nil {
+		return err
+	}
+	pubKey := hex.EncodeToString(keypair.SerializePublicKey(signer.GetPublicKey()))
+	signed := false
+	for _, ps := range this.Signers {
+		if !ps.hasPubKey(pubKey) {
+			continue
+		}
+		signed = true
+		if ps.hasSigned(pubKey) {
+			continue
+		}
+		sigData, err := signTransaction(tx, signer)
+		if err != nil {
+			return fmt.Errorf("sign error:%s", err)
+		}
+		ps.Sigs = append(ps.Sigs, &PartialSig{
+			PubKey:  pubKey,
+			SigData: hex.EncodeToString(sigData),
+		})
+	}
+	if !signed {
+		return fmt.Errorf("invalid signer, public key:%s is not expected", pubKey)
+	}
+	return nil
+}
+
+//Merge merge the signers and signatures of other into this. Both must hold the same transaction.
+func (this *PartialTransaction) Merge(other *PartialTransaction) error {
+	if other == nil {
+		return fmt.Errorf("partial transaction to merge should not be nil")
+	}
+	if this.TxHash != other.TxHash || this.Tx != other.Tx {
+		return fmt.Errorf("cannot merge partial transaction of different tx:%s and %s", this.TxHash, other.TxHash)
+	}
+	for _, os := range other.Signers {
+		pubKeys, err := os.GetPubKeys()
+		if err != nil {
+			return err
+		}
+		signer, err := this.addSigner(os.M, pubKeys, os.Label)
+		if err != nil {
+			return err
+		}
+		for _, sig := range os.Sigs {
+			if signer.hasSigned(sig.PubKey) {
+				continue
+			}
+			sigData, err := hex.DecodeString(sig.SigData)
+			if err != nil {
+				return fmt.Errorf("signature hex decode error:%s", err)
+			}
+			err = this.addSigData(signer, pubKeys, sigData)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for k, v := range other.Metadata {
+		if _, ok := this.Metadata[k]; !ok {
+			this.Metadata[k] = v
+		}
+	}
+	return nil
+}
+
+//addSigData verify sigData against the public keys of signer, and record it for the matched key
+func (this *PartialTransaction) addSigData(signer *PartialSigner, pubKeys []keypair.PublicKey, sigData []byte) error {
+	txHash, err := common.Uint256FromHexString(this.TxHash)
+	if err != nil {
+		return fmt.Errorf("tx hash error:%s", err)
+	}
+	for _, pk := range pubKeys {
+		if signature.Verify(pk, txHash.ToArray(), sigData) != nil {
+			continue
+		}
+		pubKey := hex.EncodeToString(keypair.SerializePublicKey(pk))
+		if !signer.hasSigned(pubKey) {
+			signer.Sigs = append(signer.Sigs, &PartialSig{
+				PubKey:  pubKey,
+				SigData: hex.EncodeToString(sigData),
+			})
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid signature of signer:%s", signer.Address)
+}
+
+//IsComplete return true if every expected signer has collected at least m signatures
+func (this *PartialTransaction) IsComplete() bool {
+	if len(this.Signers) == 0 {
+		return false
+	}
+	for _, signer := range this.Signers {
+		if !signer.IsComplete() {
+			return false
+		}
+	}
+	return true
+}
+
+//MissingSigners return the signers which still need signatures
+func (this *PartialTransaction) MissingSigners() []*PartialSigner {
+	missing := make([]*PartialSigner, 0)
+	for _, signer := range this.Signers {
+		if !signer.IsComplete() {
+			missing = append(missing, signer)
+		}
+	}
+	return missing
+}
+
+//Finalize return the signed transaction. The payer must be one of the signers.
+func (this *PartialTransaction) Finalize() (*types.MutableTransaction, error) {
+	if !this.IsComplete() {
+		return nil, fmt.Errorf("partial transaction is not complete, %d signer(s) missing signatures", len(this.MissingSigners()))
+	}
+	tx, err := this.GetTransaction()
+	if err != nil {
+		return nil, err
+	}
+	payer := tx.Payer.ToBase58()
+	hasPayer := false
+	sigs := make([]types.Sig, 0, len(this.Signers))
+	for _, signer := range this.Signers {
+		if signer.Address == payer {
+			hasPayer = true
+		}
+		pubKeys, err := signer.GetPubKeys()
+		if err != nil {
+			return nil, err
+		}
+		sig := types.Sig{
+			PubKeys: pubKeys,
+			M:       signer.M,
+			SigData: make([][]byte, 0, signer.M),
+		}
+		//sig data in public keys order, and no more than m
+		for _, pk := range signer.PubKeys {
+			if len(sig.SigData) == int(signer.M) {
+				break
+			}
+			for _, s := range signer.Sigs {
+				if s.PubKey != pk {
+					continue
+				}
+				sigData, err := hex.DecodeString(s.SigData)
+				if err != nil {
+					return nil, fmt.Errorf("signature hex decode error:%s", err)
+				}
+				sig.SigData = append(sig.SigData, sigData)
+				break
+			}
+		}
+		sigs = append(sigs, sig)
+	}
+	if !hasPayer {
+		return nil, fmt.Errorf("payer:%s is not in signers", payer)
+	}
+	signedTx := *tx
+	signedTx.Sigs = sigs
+	return &signedTx, nil
+}
+
+//GetTransaction return the unsigned transaction
+func (this *PartialTransaction) GetTransaction() (*types.MutableTransaction, error) {
+	if this.tx != nil {
+		return this.tx, nil
+	}
+	txData, err := hex.DecodeString(this.Tx)
+	if err != nil {
+		return nil, fmt.Errorf("tx hex decode error:%s", err)
+	}
+	tx, err := types.TransactionFromRawBytes(txData)
+	if err != nil {
+		return nil, fmt.Errorf("TransactionFromRawBytes error:%s", err)
+	}
+	mutTx, err := tx.IntoMutable()
+	if err != nil {
+		return nil, fmt.Errorf("IntoMutable error:%s", err)
+	}
+	if len(mutTx.Sigs) != 0 {
+		return nil, fmt.Errorf("tx of partial transaction should not contain signatures")
+	}
+	txHash := mutTx.Hash()
+	if txHash.ToHexString() != this.TxHash {
+		return nil, fmt.Errorf("tx hash unmatch, expect:%s got:%s", this.TxHash, txHash.ToHexString())
+	}
+	this.tx = mutTx
+	return mutTx, nil
+}
+
+//ToJson return the stable json encoding of the partial transaction
+func (this *PartialTransaction) ToJson() ([]byte, error) {
+	return json.Marshal(this)
+}
+
+//ToBase64 return the base64 encoding of ToJson
+func (this *PartialTransaction) ToBase64() (string, error) {
+	data, err := this.ToJson()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+//PartialTransactionFromJson parse a partial transaction from json, and verify all of the signatures
+func PartialTransactionFromJson(data []byte) (*PartialTransaction, error) {
+	ptx := &PartialTransaction{}
+	err := json.Unmarshal(data, ptx)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal PartialTransaction error:%s", err)
+	}
+	if ptx.Version != PARTIAL_TX_VERSION {
+		return nil, fmt.Errorf("unsupport partial transaction version:%d", ptx.Version)
+	}
+	if ptx.Metadata == nil {
+		ptx.Metadata = make(map[string]string)
+	}
+	_, err = ptx.GetTransaction()
+	if err != nil {
+		return nil, err
+	}
+	signers := ptx.Signers
+	ptx.Signers = make([]*PartialSigner, 0, len(signers))
+	for _, s := range signers {
+		pubKeys, err := s.GetPubKeys()
+		if err != nil {
+			return nil, err
+		}
+		signer, err := ptx.addSigner(s.M, pubKeys, s.Label)
+		if err != nil {
+			return nil, err
+		}
+		if s.Address != "" && s.Address != signer.Address {
+			return nil, fmt.Errorf("signer address:%s unmatch with public keys", s.Address)
+		}
+		for _, sig := range s.Sigs {
+			sigData, err := hex.DecodeString(sig.SigData)
+			if err != nil {
+				return nil, fmt.Errorf("signature hex decode error:%s", err)
+			}
+			err = ptx.addSigData(signer, pubKeys, sigData)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ptx, nil
+}
+
+//PartialTransactionFromBase64 parse a partial transaction from base64 encoding
+func PartialTransactionFromBase64(data string) (*PartialTransaction, error) {
+	jsonData, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("base64 decode error:%s", err)
+	}
+	return PartialTransactionFromJson(jsonData)
+}
+
+//GetPubKeys return the deserialized public keys of signer
+func (this *PartialSigner) GetPubKeys() ([]keypair.PublicKey, error) {
+	pubKeys := make([]keypair.PublicKey, 0, len(this.PubKeys))
+	for _, pkStr := range this.PubKeys {
+		pkData, err := hex.DecodeString(pkStr)
+		if err != nil {
+			return nil, fmt.Errorf("public key hex decode error:%s", err)
+		}
+		pk, err := keypair.DeserializePublicKey(pkData)
+		if err != nil {
+			return nil, fmt.Errorf("DeserializePublicKey error:%s", err)
+		}
+		pubKeys = append(pubKeys, pk)
+	}
+	return pubKeys, nil
+}
+
+//IsComplete return true if signer has collected at least m signatures
+func (this *PartialSigner) IsComplete() bool {
+	return len(this.Sigs) >= int(this.M)
+}
+
+func (this *PartialSigner) hasPubKey(pubKey string) bool {
+	for _, pk := range this.PubKeys {
+		if pk == pubKey {
+			return true
+		}
+	}
+	return false
+}
+
+func (this *PartialSigner) hasSigned(pubKey string) bool {
+	for _, sig := range this.Sigs {
+		if sig.PubKey == pubKey {
+			return true
+		}
+	}
+	return false
+}
+
+func serializeUnsignedTx(tx *types.MutableTransaction) (string, error) {
+	txData, err := tx.IntoImmutable()
+	if err != nil {
+		return "", fmt.Errorf("IntoImmutable error:%s", err)
+	}
+	sink := common.NewZeroCopySink(nil)
+	txData.Serialization(sink)
+	return hex.EncodeToString(sink.Bytes()), nil
+}
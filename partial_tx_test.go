@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"encoding/hex"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/Tesra/core/validation"
+	"github.com/TesraSupernet/Tesra/smartcontract/service/native/tsr"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPartialTransaction_MultiSign(t *testing.T) {
+	sdk := NewTesraSdk()
+	acc1 := NewAccount()
+	acc2 := NewAccount()
+	acc3 := NewAccount()
+	pubKeys := []keypair.PublicKey{acc1.PublicKey, acc2.PublicKey, acc3.PublicKey}
+	m := uint16(2)
+	multiAddr, err := types.AddressFromMultiPubKeys(pubKeys, int(m))
+	assert.Nil(t, err)
+
+	tx, err := sdk.Native.NewNativeInvokeTransaction(500, 20000, TSR_CONTRACT_VERSION, TSR_CONTRACT_ADDRESS,
+		tsr.TRANSFER_NAME, []interface{}{[]*tsr.State{{From: multiAddr, To: acc1.Address, Value: 10}}})
+	assert.Nil(t, err)
+	sdk.SetPayer(tx, multiAddr)
+
+	ptx, err := NewPartialTransaction(tx, map[string]string{"memo": "treasury payout"})
+	assert.Nil(t, err)
+	assert.Nil(t, ptx.AddSigner(m, pubKeys, "treasury"))
+	assert.False(t, ptx.IsComplete())
+	assert.NotNil(t, ptx.Sign(NewAccount()))
+
+	//two co-signers sign independent copies
+	data, err := ptx.ToBase64()
+	assert.Nil(t, err)
+	ptx1, err := PartialTransactionFromBase64(data)
+	assert.Nil(t, err)
+	ptx2, err := PartialTransactionFromBase64(data)
+	assert.Nil(t, err)
+	assert.Nil(t, ptx1.Sign(acc1))
+	assert.Nil(t, ptx2.Sign(acc3))
+	assert.False(t, ptx1.IsComplete())
+	_, err = ptx1.Finalize()
+	assert.NotNil(t, err)
+
+	assert.Nil(t, ptx1.Merge(ptx2))
+	assert.True(t, ptx1.IsComplete())
+	assert.Equal(t, "treasury payout", ptx1.Metadata["memo"])
+
+	signedTx, err := ptx1.Finalize()
+	assert.Nil(t, err)
+	assert.Equal(t, tx.Hash(), signedTx.Hash())
+	immutTx, err := signedTx.IntoImmutable()
+	assert.Nil(t, err)
+	assert.Equal(t, "not an error", validation.VerifyTransaction(immutTx).Error())
+}
+
+func TestPartialTransaction_FromJsonRejectBadSig(t *testing.T) {
+	sdk := NewTesraSdk()
+	acc := NewAccount()
+	tx, err := sdk.Native.NewNativeInvokeTransaction(500, 20000, TSR_CONTRACT_VERSION, TSR_CONTRACT_ADDRESS,
+		tsr.TRANSFER_NAME, []interface{}{[]*tsr.State{{From: acc.Address, To: acc.Address, Value: 10}}})
+	assert.Nil(t, err)
+	sdk.SetPayer(tx, acc.Address)
+	ptx, err := NewPartialTransaction(tx, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, ptx.AddSigner(1, []keypair.PublicKey{acc.PublicKey}, ""))
+	assert.Nil(t, ptx.Sign(acc))
+	assert.True(t, ptx.IsComplete())
+
+	txHash := tx.Hash()
+	otherSig, err := NewAccount().Sign(txHash.ToArray())
+	assert.Nil(t, err)
+	ptx.Signers[0].Sigs[0].SigData = hex.EncodeToString(otherSig)
+	data, err := ptx.ToJson()
+	assert.Nil(t, err)
+	_, err = PartialTransactionFromJson(data)
+	assert.NotNil(t, err)
+}
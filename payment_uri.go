@@ -0,0 +1,354 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/Tesra/smartcontract/service/native/tsr"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//PAYMENT_URI_SCHEME is the scheme of payment request uri, such as
+//tesra:AJ2Zjxb3kUZvQHr4Up1PmYd6YJBpswcTq4?asset=tsg&amount=1.5&memo=order-1024&expiry=1700000000
+const PAYMENT_URI_SCHEME = "tesra"
+
+const (
+	PAYMENT_ASSET_TSR  = "tsr"
+	PAYMENT_ASSET_TSG  = "tsg"
+	PAYMENT_ASSET_TEP1 = "tep1"
+)
+
+var (
+	PAYMENT_TSR_DECIMALS = 0
+	PAYMENT_TSG_DECIMALS = 9
+	PAYMENT_MAX_DECIMALS = 32
+)
+
+//PaymentRequest is a request to pay asset to Recipient. It can be encoded as uri by ToURI, and
+//the uri can be shown as QR code directly.
+type PaymentRequest struct {
+	Recipient common.Address
+	Asset     string         //PAYMENT_ASSET_TSR, PAYMENT_ASSET_TSG or PAYMENT_ASSET_TEP1
+	Contract  common.Address //contract address of TEP1 token, unused for tsr and tsg
+	Amount    *big.Int       //amount in the smallest unit, nil means amount is decided by payer
+	Decimals  int            //decimals of asset, used to format amount in uri
+	Label     string         //name of recipient, such as shop name
+	Memo      string         //message to payer, such as order id, it is not written into transaction
+	Expiry    int64          //unix timestamp after which the request is invalid, 0 means never expire
+}
+
+//NewPaymentRequest return a PaymentRequest of tsr or tsg, amount is in the smallest unit
+func NewPaymentRequest(asset string, recipient common.Address, amount *big.Int) (*PaymentRequest, error) {
+	asset = strings.ToLower(asset)
+	decimals, err := getPaymentAssetDecimals(asset)
+	if err != nil {
+		return nil, err
+	}
+	return &PaymentRequest{
+		Recipient: recipient,
+		Asset:     asset,
+		Amount:    amount,
+		Decimals:  decimals,
+	}, nil
+}
+
+//NewTep1PaymentRequest return a PaymentRequest of TEP1 token, amount is in the smallest unit
+func NewTep1PaymentRequest(contract, recipient common.Address, amount *big.Int, decimals int) *PaymentRequest {
+	return &PaymentRequest{
+		Recipient: recipient,
+		Asset:     PAYMENT_ASSET_TEP1,
+		Contract:  contract,
+		Amount:    amount,
+		Decimals:  decimals,
+	}
+}
+
+func getPaymentAssetDecimals(asset string) (int, error) {
+	switch asset {
+	case PAYMENT_ASSET_TSR:
+		return PAYMENT_TSR_DECIMALS, nil
+	case PAYMENT_ASSET_TSG:
+		return PAYMENT_TSG_DECIMALS, nil
+	default:
+		return 0, fmt.Errorf("unsupport asset:%s", asset)
+	}
+}
+
+//GetContractAddress return the contract address of asset
+func (this *PaymentRequest) GetContractAddress() (common.Address, error) {
+	switch this.Asset {
+	case PAYMENT_ASSET_TSR:
+		return TSR_CONTRACT_ADDRESS, nil
+	case PAYMENT_ASSET_TSG:
+		return TSG_CONTRACT_ADDRESS, nil
+	case PAYMENT_ASSET_TEP1:
+		if this.Contract == common.ADDRESS_EMPTY {
+			return common.ADDRESS_EMPTY, fmt.Errorf("contract address of tep1 token is empty")
+		}
+		return this.Contract, nil
+	default:
+		return common.ADDRESS_EMPTY, fmt.Errorf("unsupport asset:%s", this.Asset)
+	}
+}
+
+//IsExpired return whether request has expired at now
+func (this *PaymentRequest) IsExpired() bool {
+	return this.Expiry > 0 && time.Now().Unix() > this.Expiry
+}
+
+//Validate check whether request is well formed
+func (this *PaymentRequest) Validate() error {
+	if this.Recipient == common.ADDRESS_EMPTY {
+		return fmt.Errorf("recipient is empty")
+	}
+	_, err := this.GetContractAddress()
+	if err != nil {
+		return err
+	}
+	if this.Decimals < 0 || this.Decimals > PAYMENT_MAX_DECIMALS {
+		return fmt.Errorf("invalid decimals:%d", this.Decimals)
+	}
+	if this.Asset != PAYMENT_ASSET_TEP1 {
+		decimals, _ := getPaymentAssetDecimals(this.Asset)
+		if this.Decimals != decimals {
+			return fmt.Errorf("decimals of %s should be %d", this.Asset, decimals)
+		}
+	}
+	if this.Amount != nil {
+		if this.Amount.Sign() <= 0 {
+			return fmt.Errorf("amount should be greater than zero")
+		}
+		if this.Asset != PAYMENT_ASSET_TEP1 && !this.Amount.IsUint64() {
+			return fmt.Errorf("amount:%s overflow uint64", this.Amount.String())
+		}
+	}
+	if this.Expiry < 0 {
+		return fmt.Errorf("invalid expiry:%d", this.Expiry)
+	}
+	return nil
+}
+
+//ToURI return the uri of request
+func (this *PaymentRequest) ToURI() (string, error) {
+	err := this.Validate()
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set("asset", this.Asset)
+	if this.Asset == PAYMENT_ASSET_TEP1 {
+		query.Set("contract", this.Contract.ToHexString())
+		query.Set("decimals", strconv.Itoa(this.Decimals))
+	}
+	if this.Amount != nil {
+		query.Set("amount", FormatDecimalAmount(this.Amount, this.Decimals))
+	}
+	if this.Label != "" {
+		query.Set("label", this.Label)
+	}
+	if this.Memo != "" {
+		query.Set("memo", this.Memo)
+	}
+	if this.Expiry > 0 {
+		query.Set("expiry", strconv.FormatInt(this.Expiry, 10))
+	}
+	return fmt.Sprintf("%s:%s?%s", PAYMENT_URI_SCHEME, this.Recipient.ToBase58(), query.Encode()), nil
+}
+
+//ParsePaymentURI parse payment request from uri. Asset defaults to tsr if absent. Unknown parameters
+//are ignored, except those starting with "req-" which payer must understand.
+func ParsePaymentURI(uri string) (*PaymentRequest, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil {
+		return nil, fmt.Errorf("invalid uri:%s", err)
+	}
+	if strings.ToLower(u.Scheme) != PAYMENT_URI_SCHEME {
+		return nil, fmt.Errorf("invalid scheme:%s", u.Scheme)
+	}
+	address := u.Opaque
+	if address == "" {
+		address = u.Host
+	}
+	recipient, err := common.AddressFromBase58(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient:%s", address)
+	}
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query:%s", err)
+	}
+	for key := range query {
+		switch key {
+		case "asset", "contract", "amount", "decimals", "label", "memo", "expiry":
+		default:
+			if strings.HasPrefix(key, "req-") {
+				return nil, fmt.Errorf("unsupport required parameter:%s", key)
+			}
+		}
+	}
+	req := &PaymentRequest{
+		Recipient: recipient,
+		Asset:     strings.ToLower(query.Get("asset")),
+		Label:     query.Get("label"),
+		Memo:      query.Get("memo"),
+	}
+	if req.Asset == "" {
+		req.Asset = PAYMENT_ASSET_TSR
+	}
+	if req.Asset == PAYMENT_ASSET_TEP1 {
+		req.Contract, err = common.AddressFromHexString(query.Get("contract"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid contract:%s", query.Get("contract"))
+		}
+		if decimals := query.Get("decimals"); decimals != "" {
+			req.Decimals, err = strconv.Atoi(decimals)
+			if err != nil {
+				return nil, fmt.Errorf("invalid decimals:%s", decimals)
+			}
+		}
+	} else {
+		req.Decimals, err = getPaymentAssetDecimals(req.Asset)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if amount := query.Get("amount"); amount != "" {
+		req.Amount, err = ParseDecimalAmount(amount, req.Decimals)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if expiry := query.Get("expiry"); expiry != "" {
+		req.Expiry, err = strconv.ParseInt(expiry, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiry:%s", expiry)
+		}
+	}
+	err = req.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+//ParseDecimalAmount parse decimal amount such as "1.5" to integer amount in the smallest unit
+func ParseDecimalAmount(amount string, decimals int) (*big.Int, error) {
+	if decimals < 0 {
+		return nil, fmt.Errorf("invalid decimals:%d", decimals)
+	}
+	parts := strings.Split(amount, ".")
+	if len(parts) > 2 || parts[0] == "" && (len(parts) == 1 || parts[1] == "") {
+		return nil, fmt.Errorf("invalid amount:%s", amount)
+	}
+	intPart := parts[0]
+	fracPart := ""
+	if len(parts) == 2 {
+		fracPart = strings.TrimRight(parts[1], "0")
+	}
+	if len(fracPart) > decimals {
+		return nil, fmt.Errorf("amount:%s exceed decimals:%d", amount, decimals)
+	}
+	digits := intPart + fracPart + strings.Repeat("0", decimals-len(fracPart))
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("invalid amount:%s", amount)
+		}
+	}
+	value, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount:%s", amount)
+	}
+	return value, nil
+}
+
+//FormatDecimalAmount format integer amount in the smallest unit to decimal amount, such as 1500000000 with 9 decimals to "1.5"
+func FormatDecimalAmount(amount *big.Int, decimals int) string {
+	str := new(big.Int).Abs(amount).String()
+	if decimals > 0 {
+		if len(str) <= decimals {
+			str = strings.Repeat("0", decimals-len(str)+1) + str
+		}
+		str = str[:len(str)-decimals] + "." + str[len(str)-decimals:]
+		str = strings.TrimRight(strings.TrimRight(str, "0"), ".")
+	}
+	if amount.Sign() < 0 {
+		str = "-" + str
+	}
+	return str
+}
+
+//NewTransferTransaction return the unsigned transfer transaction from payer of request.
+//amount is used only if request has no amount, and it is in the smallest unit.
+func (this *PaymentRequest) NewTransferTransaction(tesraSdk *TesraSdk, gasPrice, gasLimit uint64, from common.Address, amount *big.Int) (*types.MutableTransaction, error) {
+	err := this.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if this.IsExpired() {
+		return nil, fmt.Errorf("payment request has expired at:%d", this.Expiry)
+	}
+	if this.Amount != nil {
+		amount = this.Amount
+	}
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid amount")
+	}
+	contract, _ := this.GetContractAddress()
+	var tx *types.MutableTransaction
+	switch this.Asset {
+	case PAYMENT_ASSET_TEP1:
+		tx, err = tesraSdk.TeoVM.NewTeoVMInvokeTransaction(gasPrice, gasLimit, contract,
+			[]interface{}{"transfer", []interface{}{from, this.Recipient, amount}})
+	default:
+		if !amount.IsUint64() {
+			return nil, fmt.Errorf("amount:%s overflow uint64", amount.String())
+		}
+		version := TSR_CONTRACT_VERSION
+		if this.Asset == PAYMENT_ASSET_TSG {
+			version = TSG_CONTRACT_VERSION
+		}
+		states := []*tsr.State{{From: from, To: this.Recipient, Value: amount.Uint64()}}
+		tx, err = tesraSdk.Native.NewNativeInvokeTransaction(gasPrice, gasLimit, version, contract,
+			tsr.TRANSFER_NAME, []interface{}{states})
+	}
+	if err != nil {
+		return nil, err
+	}
+	tesraSdk.SetPayer(tx, from)
+	return tx, nil
+}
+
+//NewPaymentTransaction return the transfer transaction of req signed by payer, which also pays the gas.
+//amount is used only if req has no amount, and it is in the smallest unit.
+func (this *TesraSdk) NewPaymentTransaction(req *PaymentRequest, payer *Account, gasPrice, gasLimit uint64, amount *big.Int) (*types.MutableTransaction, error) {
+	tx, err := req.NewTransferTransaction(this, gasPrice, gasLimit, payer.Address, amount)
+	if err != nil {
+		return nil, err
+	}
+	err = this.SignToTransaction(tx, payer)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestDecimalAmount(t *testing.T) {
+	amount, err := ParseDecimalAmount("1.5", 9)
+	assert.Nil(t, err)
+	assert.Equal(t, "1500000000", amount.String())
+	assert.Equal(t, "1.5", FormatDecimalAmount(amount, 9))
+	amount, err = ParseDecimalAmount("0.000000001", 9)
+	assert.Nil(t, err)
+	assert.Equal(t, "1", amount.String())
+	assert.Equal(t, "0.000000001", FormatDecimalAmount(amount, 9))
+	assert.Equal(t, "20", FormatDecimalAmount(big.NewInt(20), 0))
+
+	_, err = ParseDecimalAmount("0.0000000001", 9)
+	assert.NotNil(t, err)
+	_, err = ParseDecimalAmount("1.5", 0)
+	assert.NotNil(t, err)
+	_, err = ParseDecimalAmount("-1", 0)
+	assert.NotNil(t, err)
+	_, err = ParseDecimalAmount("1e3", 0)
+	assert.NotNil(t, err)
+}
+
+func TestPaymentURI(t *testing.T) {
+	recipient := NewAccount().Address
+	req, err := NewPaymentRequest("TSG", recipient, big.NewInt(1500000000))
+	assert.Nil(t, err)
+	req.Memo = "order 1024"
+	req.Expiry = time.Now().Unix() + 600
+	uri, err := req.ToURI()
+	assert.Nil(t, err)
+
+	req2, err := ParsePaymentURI(uri)
+	assert.Nil(t, err)
+	assert.Equal(t, req, req2)
+
+	req2, err = ParsePaymentURI("tesra:" + recipient.ToBase58())
+	assert.Nil(t, err)
+	assert.Equal(t, PAYMENT_ASSET_TSR, req2.Asset)
+	assert.Nil(t, req2.Amount)
+
+	_, err = ParsePaymentURI("tesra:" + recipient.ToBase58() + "?amount=1.5")
+	assert.NotNil(t, err)
+	_, err = ParsePaymentURI("tesra:" + recipient.ToBase58() + "?req-unknown=1")
+	assert.NotNil(t, err)
+
+	contract, _ := common.AddressFromHexString("1ddbb682743e9d9e2b71ff419e97a9358c5c4ee9")
+	req = NewTep1PaymentRequest(contract, recipient, big.NewInt(12345), 2)
+	uri, err = req.ToURI()
+	assert.Nil(t, err)
+	req2, err = ParsePaymentURI(uri)
+	assert.Nil(t, err)
+	assert.Equal(t, req, req2)
+}
+
+func TestNewPaymentTransaction(t *testing.T) {
+	sdk := NewTesraSdk()
+	payer := NewAccount()
+	recipient := NewAccount().Address
+	req, err := NewPaymentRequest(PAYMENT_ASSET_TSG, recipient, big.NewInt(100))
+	assert.Nil(t, err)
+	tx, err := sdk.NewPaymentTransaction(req, payer, 500, 20000, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, VerifyTransaction(tx))
+	decoded, err := DecodeTransaction(tx)
+	assert.Nil(t, err)
+	assert.Equal(t, TSG_CONTRACT_ADDRESS.ToHexString(), decoded.Invoke.Contract)
+	state := decoded.Invoke.GetArg("states").Items[0]
+	assert.Equal(t, recipient.ToBase58(), state.GetItem("to").Value)
+	assert.Equal(t, "100", state.GetItem("value").Value)
+
+	contract, _ := common.AddressFromHexString("1ddbb682743e9d9e2b71ff419e97a9358c5c4ee9")
+	req = NewTep1PaymentRequest(contract, recipient, nil, 2)
+	_, err = sdk.NewPaymentTransaction(req, payer, 500, 200000, nil)
+	assert.NotNil(t, err)
+	tx, err = sdk.NewPaymentTransaction(req, payer, 500, 200000, big.NewInt(250))
+	assert.Nil(t, err)
+	decoded, err = DecodeTransaction(tx)
+	assert.Nil(t, err)
+	assert.Equal(t, "transfer", decoded.Invoke.Method)
+	assert.Equal(t, "250", decoded.Invoke.GetArg("amount").Value)
+
+	req.Expiry = time.Now().Unix() - 1
+	_, err = sdk.NewPaymentTransaction(req, payer, 500, 200000, big.NewInt(250))
+	assert.NotNil(t, err)
+}
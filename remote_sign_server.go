@@ -0,0 +1,346 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	REMOTE_SIGN_READ_TIMEOUT  = 30 * time.Second  //read timeout of remote sign server
+	REMOTE_SIGN_WRITE_TIMEOUT = 30 * time.Second  //write timeout of remote sign server
+	REMOTE_SIGN_IDLE_TIMEOUT  = 120 * time.Second //keep-alive timeout of remote sign server
+)
+
+//SignPolicy is the policy of RemoteSignServer to decide which transactions can be signed
+type SignPolicy struct {
+	AllowedContracts []string          `json:"allowedContracts"` //contract address in hex, empty means any contract
+	AllowedMethods   []string          `json:"allowedMethods"`   //method name, empty means any method
+	MaxAmounts       map[string]uint64 `json:"maxAmounts"`       //max amount in one tx of contract address in hex, calls of unknown args are rejected
+	AllowDeploy      bool              `json:"allowDeploy"`      //whether deploy transaction can be signed
+	AllowMessage     bool              `json:"allowMessage"`     //whether message can be signed by REMOTE_SIGN_SIGN_MESSAGE, see GetMessageHash
+	AllowRawData     bool              `json:"allowRawData"`     //whether raw data without tx can be signed, it may be a tx hash so the other rules are bypassed
+}
+
+//Check return error if tx is not allowed by policy
+func (this *SignPolicy) Check(tx *types.MutableTransaction) error {
+	decoded, err := DecodeTransaction(tx)
+	if err != nil {
+		return fmt.Errorf("cannot decode payload:%s", err)
+	}
+	if decoded.Deploy != nil {
+		if !this.AllowDeploy {
+			return fmt.Errorf("deploy transaction is not allowed")
+		}
+		return nil
+	}
+	call := decoded.Invoke
+	if len(this.AllowedContracts) > 0 && !containsString(this.AllowedContracts, call.Contract) {
+		return fmt.Errorf("contract:%s is not allowed", call.Contract)
+	}
+	if len(this.AllowedMethods) > 0 && !containsString(this.AllowedMethods, call.Method) {
+		return fmt.Errorf("method:%s is not allowed", call.Method)
+	}
+	if maxAmount, ok := this.MaxAmounts[call.Contract]; ok {
+		if !call.ArgsDecoded {
+			return fmt.Errorf("args of method:%s of contract:%s are unknown, amount cannot be checked", call.Method, call.Contract)
+		}
+		amount := getCallAmount(call.Args)
+		if amount.Cmp(new(big.Int).SetUint64(maxAmount)) > 0 {
+			return fmt.Errorf("amount:%s of contract:%s exceed max amount:%d", amount.String(), call.Contract, maxAmount)
+		}
+	}
+	return nil
+}
+
+//getCallAmount return the sum of integer args named value or amount, such as the values of transfer states
+func getCallAmount(args []*DecodedArg) *big.Int {
+	amount := new(big.Int)
+	for _, arg := range args {
+		if arg.Type == ARG_TYPE_INTEGER && (arg.Name == "value" || arg.Name == "amount") {
+			value, err := arg.ToInteger()
+			if err == nil && value.Sign() > 0 {
+				amount.Add(amount, value)
+			}
+		}
+		if len(arg.Items) > 0 {
+			amount.Add(amount, getCallAmount(arg.Items))
+		}
+	}
+	return amount
+}
+
+func containsString(list []string, str string) bool {
+	for _, s := range list {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+//RemoteSignServer is the reference sign server of RemoteSigner. It signs with the accounts of wallet
+//which have been unlocked, and only signs the transactions allowed by SignPolicy.
+type RemoteSignServer struct {
+	policy        *SignPolicy
+	unlockManager *UnlockManager
+	authToken     string
+}
+
+//NewRemoteSignServer return a RemoteSignServer of wallet. If policy is nil, nothing can be signed.
+func NewRemoteSignServer(wallet *Wallet, policy *SignPolicy) *RemoteSignServer {
+	return &RemoteSignServer{
+		policy:        policy,
+		unlockManager: NewUnlockManager(wallet),
+	}
+}
+
+//UnlockAccount decrypt the account of address in wallet, so that it can be used to sign
+func (this *RemoteSignServer) UnlockAccount(address string, passwd []byte) error {
+	return this.UnlockAccountWithOptions(address, passwd, nil)
+}
+
+//UnlockAccountWithOptions decrypt the account of address in wallet, which is locked when the limit of opts is reached
+func (this *RemoteSignServer) UnlockAccountWithOptions(address string, passwd []byte, opts *UnlockOptions) error {
+	_, err := this.unlockManager.Unlock(address, passwd, opts)
+	return err
+}
+
+//LockAccount wipe the decrypted account of address
+func (this *RemoteSignServer) LockAccount(address string) {
+	this.unlockManager.Lock(address)
+}
+
+func (this *RemoteSignServer) getAccount(address string) Signer {
+	signer, err := this.unlockManager.GetSigner(address)
+	if err != nil {
+		return nil
+	}
+	return signer
+}
+
+//SetAuthToken set the shared token which must be sent by RemoteSigner.SetAuthToken, empty means no token is required
+func (this *RemoteSignServer) SetAuthToken(token string) *RemoteSignServer {
+	this.authToken = token
+	return this
+}
+
+func (this *RemoteSignServer) newHttpServer(addr string) *http.Server {
+	return &http.Server{
+		Addr:         addr,
+		Handler:      this,
+		ReadTimeout:  REMOTE_SIGN_READ_TIMEOUT,
+		WriteTimeout: REMOTE_SIGN_WRITE_TIMEOUT,
+		IdleTimeout:  REMOTE_SIGN_IDLE_TIMEOUT,
+	}
+}
+
+//Start start http server on addr, such as ":20350". Set auth token by SetAuthToken or use StartTLS
+//with client certificates, unless addr only listens on a trusted network
+func (this *RemoteSignServer) Start(addr string) error {
+	return this.newHttpServer(addr).ListenAndServe()
+}
+
+//StartTLS start https server on addr with certFile and keyFile. If clientCAFile is not empty, clients must
+//present a certificate signed by the CAs in clientCAFile
+func (this *RemoteSignServer) StartTLS(addr, certFile, keyFile, clientCAFile string) error {
+	server := this.newHttpServer(addr)
+	if clientCAFile != "" {
+		caData, err := ioutil.ReadFile(clientCAFile)
+		if err != nil {
+			return fmt.Errorf("read client ca file error:%s", err)
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caData) {
+			return fmt.Errorf("no certificate in client ca file:%s", clientCAFile)
+		}
+		server.TLSConfig = &tls.Config{
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  clientCAs,
+		}
+	}
+	return server.ListenAndServeTLS(certFile, keyFile)
+}
+
+func (this *RemoteSignServer) checkAuthToken(r *http.Request) bool {
+	if this.authToken == "" {
+		return true
+	}
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(this.authToken)) == 1
+}
+
+func (this *RemoteSignServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rsp := &RemoteSignResponse{}
+	if !this.checkAuthToken(r) {
+		rsp.Error, rsp.Desc = REMOTE_SIGN_ERR_UNAUTHORIZED, "unauthorized"
+		w.WriteHeader(http.StatusUnauthorized)
+		writeRemoteSignResponse(w, rsp)
+		return
+	}
+	if r.Method != http.MethodPost {
+		rsp.Error, rsp.Desc = REMOTE_SIGN_ERR_INVALID_PARAMS, "only POST is supported"
+		writeRemoteSignResponse(w, rsp)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, int64(MAX_TX_SIZE*2+4096)))
+	if err != nil {
+		rsp.Error, rsp.Desc = REMOTE_SIGN_ERR_INVALID_PARAMS, fmt.Sprintf("read request error:%s", err)
+		writeRemoteSignResponse(w, rsp)
+		return
+	}
+	var result interface{}
+	switch r.URL.Path {
+	case REMOTE_SIGN_GET_PUBLIC_KEY:
+		req := &RemoteGetPublicKeyRequest{}
+		err = json.Unmarshal(body, req)
+		if err != nil {
+			rsp.Error, rsp.Desc = REMOTE_SIGN_ERR_INVALID_PARAMS, fmt.Sprintf("json.Unmarshal request error:%s", err)
+			break
+		}
+		result, rsp.Error, err = this.getPublicKey(req)
+	case REMOTE_SIGN_SIGN:
+		req := &RemoteSignRequest{}
+		err = json.Unmarshal(body, req)
+		if err != nil {
+			rsp.Error, rsp.Desc = REMOTE_SIGN_ERR_INVALID_PARAMS, fmt.Sprintf("json.Unmarshal request error:%s", err)
+			break
+		}
+		result, rsp.Error, err = this.sign(req)
+	case REMOTE_SIGN_SIGN_MESSAGE:
+		req := &RemoteSignMessageRequest{}
+		err = json.Unmarshal(body, req)
+		if err != nil {
+			rsp.Error, rsp.Desc = REMOTE_SIGN_ERR_INVALID_PARAMS, fmt.Sprintf("json.Unmarshal request error:%s", err)
+			break
+		}
+		result, rsp.Error, err = this.signMessage(req)
+	default:
+		rsp.Error, err = REMOTE_SIGN_ERR_INVALID_PARAMS, fmt.Errorf("unknown method:%s", r.URL.Path)
+	}
+	if rsp.Error != REMOTE_SIGN_SUCCESS {
+		if err != nil {
+			rsp.Desc = err.Error()
+		}
+		writeRemoteSignResponse(w, rsp)
+		return
+	}
+	rsp.Result, err = json.Marshal(result)
+	if err != nil {
+		rsp.Error, rsp.Desc = REMOTE_SIGN_ERR_SIGN, fmt.Sprintf("json.Marshal result error:%s", err)
+	}
+	writeRemoteSignResponse(w, rsp)
+}
+
+func writeRemoteSignResponse(w http.ResponseWriter, rsp *RemoteSignResponse) {
+	data, _ := json.Marshal(rsp)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func (this *RemoteSignServer) getPublicKey(req *RemoteGetPublicKeyRequest) (*RemoteGetPublicKeyResult, int64, error) {
+	acc := this.getAccount(req.Address)
+	if acc == nil {
+		return nil, REMOTE_SIGN_ERR_ACCOUNT, fmt.Errorf("account:%s is not unlocked", req.Address)
+	}
+	return &RemoteGetPublicKeyResult{
+		Address:   req.Address,
+		PublicKey: hex.EncodeToString(keypair.SerializePublicKey(acc.GetPublicKey())),
+		SigScheme: acc.GetSigScheme().Name(),
+	}, REMOTE_SIGN_SUCCESS, nil
+}
+
+func (this *RemoteSignServer) sign(req *RemoteSignRequest) (*RemoteSignResult, int64, error) {
+	acc := this.getAccount(req.Address)
+	if acc == nil {
+		return nil, REMOTE_SIGN_ERR_ACCOUNT, fmt.Errorf("account:%s is not unlocked", req.Address)
+	}
+	data, err := hex.DecodeString(req.Data)
+	if err != nil || len(data) == 0 {
+		return nil, REMOTE_SIGN_ERR_INVALID_PARAMS, fmt.Errorf("invalid data:%s", req.Data)
+	}
+	if this.policy == nil {
+		return nil, REMOTE_SIGN_ERR_POLICY, fmt.Errorf("no sign policy")
+	}
+	if req.Tx == "" {
+		if !this.policy.AllowRawData {
+			return nil, REMOTE_SIGN_ERR_POLICY, fmt.Errorf("sign raw data is not allowed")
+		}
+	} else {
+		rawTx, err := hex.DecodeString(req.Tx)
+		if err != nil {
+			return nil, REMOTE_SIGN_ERR_INVALID_PARAMS, fmt.Errorf("invalid tx:%s", err)
+		}
+		immutTx, err := types.TransactionFromRawBytes(rawTx)
+		if err != nil {
+			return nil, REMOTE_SIGN_ERR_INVALID_PARAMS, fmt.Errorf("invalid tx:%s", err)
+		}
+		tx, err := immutTx.IntoMutable()
+		if err != nil {
+			return nil, REMOTE_SIGN_ERR_INVALID_PARAMS, fmt.Errorf("invalid tx:%s", err)
+		}
+		txHash := tx.Hash()
+		if !bytes.Equal(txHash.ToArray(), data) {
+			return nil, REMOTE_SIGN_ERR_INVALID_PARAMS, fmt.Errorf("data is not the hash of tx:%s", txHash.ToHexString())
+		}
+		err = this.policy.Check(tx)
+		if err != nil {
+			return nil, REMOTE_SIGN_ERR_POLICY, err
+		}
+	}
+	sigData, err := acc.Sign(data)
+	if err != nil {
+		return nil, REMOTE_SIGN_ERR_SIGN, err
+	}
+	return &RemoteSignResult{SigData: hex.EncodeToString(sigData)}, REMOTE_SIGN_SUCCESS, nil
+}
+
+func (this *RemoteSignServer) signMessage(req *RemoteSignMessageRequest) (*RemoteSignResult, int64, error) {
+	acc := this.getAccount(req.Address)
+	if acc == nil {
+		return nil, REMOTE_SIGN_ERR_ACCOUNT, fmt.Errorf("account:%s is not unlocked", req.Address)
+	}
+	msg, err := hex.DecodeString(req.Message)
+	if err != nil {
+		return nil, REMOTE_SIGN_ERR_INVALID_PARAMS, fmt.Errorf("invalid message:%s", req.Message)
+	}
+	if this.policy == nil || !this.policy.AllowMessage {
+		return nil, REMOTE_SIGN_ERR_POLICY, fmt.Errorf("sign message is not allowed")
+	}
+	sigData, err := acc.Sign(GetMessageHash(msg))
+	if err != nil {
+		return nil, REMOTE_SIGN_ERR_SIGN, err
+	}
+	return &RemoteSignResult{SigData: hex.EncodeToString(sigData)}, REMOTE_SIGN_SUCCESS, nil
+}
@@ -0,0 +1,280 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	s "github.com/TesraSupernet/tesracrypto/signature"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	REMOTE_SIGN_GET_PUBLIC_KEY = "/getPublicKey"
+	REMOTE_SIGN_SIGN           = "/sign"
+	REMOTE_SIGN_SIGN_MESSAGE   = "/signMessage"
+)
+
+const (
+	REMOTE_SIGN_SUCCESS            = 0
+	REMOTE_SIGN_ERR_INVALID_PARAMS = 1 //invalid request
+	REMOTE_SIGN_ERR_ACCOUNT        = 2 //account not found or not unlocked
+	REMOTE_SIGN_ERR_POLICY         = 3 //rejected by sign policy
+	REMOTE_SIGN_ERR_SIGN           = 4 //sign failed
+	REMOTE_SIGN_ERR_UNAUTHORIZED   = 5 //auth token missing or wrong
+)
+
+//TxSigner is a Signer which need the whole transaction to sign, such as RemoteSigner.
+//SignToTransaction, MultiSignToTransaction and PartialTransaction.Sign call SignTransaction
+//instead of Sign if signer implements TxSigner.
+type TxSigner interface {
+	Signer
+	SignTransaction(tx *types.MutableTransaction) ([]byte, error)
+}
+
+//signTransaction return the signature of tx hash signed by signer
+func signTransaction(tx *types.MutableTransaction, signer Signer) ([]byte, error) {
+	if txSigner, ok := signer.(TxSigner); ok {
+		return txSigner.SignTransaction(tx)
+	}
+	txHash := tx.Hash()
+	return signer.Sign(txHash.ToArray())
+}
+
+//RemoteGetPublicKeyRequest is the request of REMOTE_SIGN_GET_PUBLIC_KEY
+type RemoteGetPublicKeyRequest struct {
+	Address string `json:"address"`
+}
+
+//RemoteGetPublicKeyResult is the result of REMOTE_SIGN_GET_PUBLIC_KEY
+type RemoteGetPublicKeyResult struct {
+	Address   string `json:"address"`
+	PublicKey string `json:"publicKey"`
+	SigScheme string `json:"signatureScheme"`
+}
+
+//RemoteSignRequest is the request of REMOTE_SIGN_SIGN. Data is the data to sign in hex. For transaction,
+//Tx is the unsigned transaction in hex and Data must be the hash of Tx, Payload is the decoded payload
+//for logging and review, server always decodes Tx by itself to enforce policy. Without Tx, Data is signed
+//as it is, which server only allows by SignPolicy.AllowRawData.
+type RemoteSignRequest struct {
+	Address string          `json:"address"`
+	Data    string          `json:"data"`
+	Tx      string          `json:"tx,omitempty"`
+	Payload *DecodedPayload `json:"payload,omitempty"`
+}
+
+//RemoteSignMessageRequest is the request of REMOTE_SIGN_SIGN_MESSAGE, server signs GetMessageHash of Message in hex
+type RemoteSignMessageRequest struct {
+	Address string `json:"address"`
+	Message string `json:"message"`
+}
+
+//RemoteSignResult is the result of REMOTE_SIGN_SIGN and REMOTE_SIGN_SIGN_MESSAGE
+type RemoteSignResult struct {
+	SigData string `json:"sigData"`
+}
+
+//RemoteSignResponse is the response of remote sign server
+type RemoteSignResponse struct {
+	Error  int64           `json:"error"`
+	Desc   string          `json:"desc"`
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+//RemoteSigner is a Signer which delegates signing to remote sign server over HTTP/JSON.
+//Private key never leaves the server, so GetPrivateKey always return nil.
+type RemoteSigner struct {
+	Address    string
+	addr       string
+	authToken  string
+	publicKey  keypair.PublicKey
+	sigScheme  s.SignatureScheme
+	httpClient *http.Client
+}
+
+//NewRemoteSigner return a RemoteSigner of account address on sign server addr, such as http://localhost:20350
+func NewRemoteSigner(addr, address string) (*RemoteSigner, error) {
+	return NewRemoteSignerWithAuth(addr, address, "", nil)
+}
+
+//NewRemoteSignerWithAuth return a RemoteSigner which sends authToken to sign server, see RemoteSignServer.SetAuthToken.
+//httpClient can carry the TLS client certificate for RemoteSignServer.StartTLS, nil for the default client
+func NewRemoteSignerWithAuth(addr, address, authToken string, httpClient *http.Client) (*RemoteSigner, error) {
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout: time.Second * 60,
+		}
+	}
+	signer := &RemoteSigner{
+		Address:    address,
+		addr:       strings.TrimRight(addr, "/"),
+		authToken:  authToken,
+		httpClient: httpClient,
+	}
+	err := signer.loadPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+//SetHttpClient set http client to RemoteSigner. In most cases SetHttpClient is not necessary
+func (this *RemoteSigner) SetHttpClient(httpClient *http.Client) *RemoteSigner {
+	this.httpClient = httpClient
+	return this
+}
+
+//SetAuthToken set the token sent to sign server in Authorization header, see RemoteSignServer.SetAuthToken
+func (this *RemoteSigner) SetAuthToken(token string) *RemoteSigner {
+	this.authToken = token
+	return this
+}
+
+func (this *RemoteSigner) loadPublicKey() error {
+	result := &RemoteGetPublicKeyResult{}
+	err := this.sendRequest(REMOTE_SIGN_GET_PUBLIC_KEY, &RemoteGetPublicKeyRequest{Address: this.Address}, result)
+	if err != nil {
+		return err
+	}
+	pkData, err := hex.DecodeString(result.PublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key:%s", result.PublicKey)
+	}
+	pubKey, err := keypair.DeserializePublicKey(pkData)
+	if err != nil {
+		return fmt.Errorf("DeserializePublicKey error:%s", err)
+	}
+	if types.AddressFromPubKey(pubKey).ToBase58() != this.Address {
+		return fmt.Errorf("public key does not belong to address:%s", this.Address)
+	}
+	scheme, err := s.GetScheme(result.SigScheme)
+	if err != nil {
+		return fmt.Errorf("invalid signature scheme:%s", result.SigScheme)
+	}
+	this.publicKey = pubKey
+	this.sigScheme = scheme
+	return nil
+}
+
+func (this *RemoteSigner) sendRequest(method string, req interface{}, result interface{}) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("json.Marshal request error:%s", err)
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, this.addr+method, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("new http request error:%s", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if this.authToken != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+this.authToken)
+	}
+	resp, err := this.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("http post request:%s error:%s", method, err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response body error:%s", err)
+	}
+	rsp := &RemoteSignResponse{}
+	err = json.Unmarshal(body, rsp)
+	if err != nil {
+		return fmt.Errorf("json.Unmarshal RemoteSignResponse:%s error:%s", body, err)
+	}
+	if rsp.Error != REMOTE_SIGN_SUCCESS {
+		return fmt.Errorf("RemoteSignResponse error code:%d desc:%s", rsp.Error, rsp.Desc)
+	}
+	return json.Unmarshal(rsp.Result, result)
+}
+
+func (this *RemoteSigner) sign(method string, req interface{}, data []byte) ([]byte, error) {
+	result := &RemoteSignResult{}
+	err := this.sendRequest(method, req, result)
+	if err != nil {
+		return nil, err
+	}
+	sigData, err := hex.DecodeString(result.SigData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sigData:%s", result.SigData)
+	}
+	err = VerifySignature(this.publicKey, data, sigData)
+	if err != nil {
+		return nil, fmt.Errorf("verify remote signature error:%s", err)
+	}
+	return sigData, nil
+}
+
+//Sign sign raw data like Account.Sign. The server rejects it unless SignPolicy.AllowRawData is set,
+//use SignMessage to sign a message
+func (this *RemoteSigner) Sign(data []byte) ([]byte, error) {
+	return this.sign(REMOTE_SIGN_SIGN, &RemoteSignRequest{
+		Address: this.Address,
+		Data:    hex.EncodeToString(data),
+	}, data)
+}
+
+//SignMessage sign GetMessageHash(msg) on server, the same as SignMessage of other signers
+func (this *RemoteSigner) SignMessage(msg []byte) ([]byte, error) {
+	return this.sign(REMOTE_SIGN_SIGN_MESSAGE, &RemoteSignMessageRequest{
+		Address: this.Address,
+		Message: hex.EncodeToString(msg),
+	}, GetMessageHash(msg))
+}
+
+//SignTransaction sign the hash of tx, with tx and decoded payload carried to server
+func (this *RemoteSigner) SignTransaction(tx *types.MutableTransaction) ([]byte, error) {
+	unsigned := *tx
+	unsigned.Sigs = make([]types.Sig, 0)
+	rawTx, err := serializeUnsignedTx(&unsigned)
+	if err != nil {
+		return nil, err
+	}
+	txHash := tx.Hash()
+	req := &RemoteSignRequest{
+		Address: this.Address,
+		Data:    hex.EncodeToString(txHash.ToArray()),
+		Tx:      rawTx,
+	}
+	decoded, err := DecodeTransaction(tx)
+	if err == nil {
+		req.Payload = decoded
+	}
+	return this.sign(REMOTE_SIGN_SIGN, req, txHash.ToArray())
+}
+
+func (this *RemoteSigner) GetPublicKey() keypair.PublicKey {
+	return this.publicKey
+}
+
+func (this *RemoteSigner) GetPrivateKey() keypair.PrivateKey {
+	return nil
+}
+
+func (this *RemoteSigner) GetSigScheme() s.SignatureScheme {
+	return this.sigScheme
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRemoteSigner(t *testing.T) {
+	passwd := []byte("123456")
+	wallet := NewWallet("remote_sign_wallet.dat")
+	acc, err := wallet.NewDefaultSettingAccount(passwd)
+	assert.Nil(t, err)
+	policy := &SignPolicy{
+		AllowedContracts: []string{TSG_CONTRACT_ADDRESS.ToHexString()},
+		AllowedMethods:   []string{"transfer"},
+		MaxAmounts:       map[string]uint64{TSG_CONTRACT_ADDRESS.ToHexString(): 1000},
+	}
+	server := NewRemoteSignServer(wallet, policy)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	address := acc.Address.ToBase58()
+	_, err = NewRemoteSigner(httpServer.URL, address)
+	assert.NotNil(t, err)
+	assert.Nil(t, server.UnlockAccount(address, passwd))
+	signer, err := NewRemoteSigner(httpServer.URL, address)
+	assert.Nil(t, err)
+	assert.Equal(t, acc.SigScheme, signer.GetSigScheme())
+
+	sdk := NewTesraSdk()
+	tx, err := sdk.Native.Tsg.NewTransferTransaction(500, 20000, acc.Address, NewAccount().Address, 1000)
+	assert.Nil(t, err)
+	sdk.SetPayer(tx, acc.Address)
+	assert.Nil(t, sdk.SignToTransaction(tx, signer))
+	assert.Nil(t, VerifyTransaction(tx))
+
+	//exceed max amount
+	tx, err = sdk.Native.Tsg.NewTransferTransaction(500, 20000, acc.Address, NewAccount().Address, 1001)
+	assert.Nil(t, err)
+	sdk.SetPayer(tx, acc.Address)
+	assert.NotNil(t, sdk.SignToTransaction(tx, signer))
+
+	//method not allowed
+	tx, err = sdk.Native.Tsg.NewApproveTransaction(500, 20000, acc.Address, NewAccount().Address, 1)
+	assert.Nil(t, err)
+	sdk.SetPayer(tx, acc.Address)
+	assert.NotNil(t, sdk.SignToTransaction(tx, signer))
+
+	//message is not allowed by policy
+	_, err = signer.SignMessage([]byte("hello"))
+	assert.NotNil(t, err)
+	policy.AllowMessage = true
+	sigData, err := SignMessage(signer, []byte("hello"))
+	assert.Nil(t, err)
+	assert.Nil(t, VerifyMessage(acc.PublicKey, []byte("hello"), sigData))
+	assert.NotNil(t, acc.Verify([]byte("hello"), sigData))
+	localSig, err := SignMessage(acc, []byte("hello"))
+	assert.Nil(t, err)
+	assert.Nil(t, VerifyMessage(signer.GetPublicKey(), []byte("hello"), localSig))
+
+	//message signature of tx hash is not a valid tx signature
+	tx, err = sdk.Native.Tsg.NewTransferTransaction(500, 20000, acc.Address, NewAccount().Address, 1000000)
+	assert.Nil(t, err)
+	sdk.SetPayer(tx, acc.Address)
+	txHash := tx.Hash()
+	sigData, err = signer.SignMessage(txHash.ToArray())
+	assert.Nil(t, err)
+	tx.Sigs = []types.Sig{{PubKeys: []keypair.PublicKey{acc.PublicKey}, M: 1, SigData: [][]byte{sigData}}}
+	assert.NotNil(t, VerifyTransaction(tx))
+
+	//raw data is signed as it is like Account.Sign, only if allowed by policy
+	_, err = signer.Sign([]byte("hello"))
+	assert.NotNil(t, err)
+	policy.AllowRawData = true
+	sigData, err = signer.Sign([]byte("hello"))
+	assert.Nil(t, err)
+	assert.Nil(t, VerifySignature(signer.GetPublicKey(), []byte("hello"), sigData))
+	assert.Nil(t, acc.Verify([]byte("hello"), sigData))
+
+	server.LockAccount(address)
+	_, err = signer.Sign([]byte("hello"))
+	assert.NotNil(t, err)
+}
+
+func TestSignPolicy_MaxAmounts(t *testing.T) {
+	sdk := NewTesraSdk()
+	contract, err := common.AddressFromHexString("1ddbb682743e9d9e2b71ff419e97a9358c5c4ee9")
+	assert.Nil(t, err)
+	policy := &SignPolicy{
+		MaxAmounts: map[string]uint64{contract.ToHexString(): 1000},
+	}
+	from := NewAccount().Address
+	to := NewAccount().Address
+	//TEP1 transfer
+	tx, err := sdk.TeoVM.NewTeoVMInvokeTransaction(500, 20000, contract,
+		[]interface{}{"transfer", []interface{}{from, to, 1000}})
+	assert.Nil(t, err)
+	assert.Nil(t, policy.Check(tx))
+	tx, err = sdk.TeoVM.NewTeoVMInvokeTransaction(500, 20000, contract,
+		[]interface{}{"transfer", []interface{}{from, to, 1001}})
+	assert.Nil(t, err)
+	assert.NotNil(t, policy.Check(tx))
+
+	//args do not match TEP1 transfer, amount is unknown
+	tx, err = sdk.TeoVM.NewTeoVMInvokeTransaction(500, 20000, contract,
+		[]interface{}{"transfer", []interface{}{from, to, 1000000, "memo"}})
+	assert.Nil(t, err)
+	assert.NotNil(t, policy.Check(tx))
+	tx, err = sdk.TeoVM.NewTeoVMInvokeTransaction(500, 20000, contract,
+		[]interface{}{"pay", []interface{}{from, to, 1000000}})
+	assert.Nil(t, err)
+	assert.NotNil(t, policy.Check(tx))
+
+	//contract without max amount
+	delete(policy.MaxAmounts, contract.ToHexString())
+	assert.Nil(t, policy.Check(tx))
+}
+
+func TestRemoteSignServer_AuthToken(t *testing.T) {
+	passwd := []byte("123456")
+	wallet := NewWallet("remote_sign_wallet.dat")
+	acc, err := wallet.NewDefaultSettingAccount(passwd)
+	assert.Nil(t, err)
+	server := NewRemoteSignServer(wallet, &SignPolicy{AllowMessage: true}).SetAuthToken("secret")
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	address := acc.Address.ToBase58()
+	assert.Nil(t, server.UnlockAccount(address, passwd))
+
+	_, err = NewRemoteSigner(httpServer.URL, address)
+	assert.NotNil(t, err)
+	_, err = NewRemoteSignerWithAuth(httpServer.URL, address, "wrong", nil)
+	assert.NotNil(t, err)
+	signer, err := NewRemoteSignerWithAuth(httpServer.URL, address, "secret", nil)
+	assert.Nil(t, err)
+	sigData, err := signer.SignMessage([]byte("hello"))
+	assert.Nil(t, err)
+	assert.Nil(t, VerifyMessage(acc.PublicKey, []byte("hello"), sigData))
+}
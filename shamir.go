@@ -0,0 +1,591 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	s "github.com/TesraSupernet/tesracrypto/signature"
+	"strings"
+)
+
+const (
+	SHAMIR_SHARE_VERSION = 1
+
+	SHAMIR_SECRET_PRIVATE_KEY = 1 //secret is raw private key, param is signature scheme
+	SHAMIR_SECRET_MNEMONIC    = 2 //secret is mnemonic entropy, param is index of mnemonic language
+	SHAMIR_SECRET_SEED        = 3 //secret is BIP39 seed
+	//secret is entropy length, mnemonic entropy and BIP39 passphrase, param is index of mnemonic language
+	SHAMIR_SECRET_MNEMONIC_PASSPHRASE = 4
+
+	SHAMIR_ENCODING_WORDS = "words" //share encoded as english mnemonic words, 11 bits per word
+	SHAMIR_ENCODING_HEX   = "hex"
+
+	MAX_SHAMIR_SHARES = 255
+
+	//shamirChecksumLen is the length of checksum of share and the digest of secret
+	shamirChecksumLen = 4
+	//shamirHeaderLen is the length of version, id, type, param, threshold and index of share
+	shamirHeaderLen = 7
+)
+
+var gf256Exp [512]byte
+var gf256Log [256]byte
+
+func init() {
+	//GF(2^8) of AES polynomial x^8+x^4+x^3+x+1, 3 is generator
+	x := 1
+	for i := 0; i < 255; i++ {
+		gf256Exp[i] = byte(x)
+		gf256Log[x] = byte(i)
+		x ^= x << 1
+		if x&0x100 != 0 {
+			x ^= 0x11b
+		}
+	}
+	for i := 255; i < len(gf256Exp); i++ {
+		gf256Exp[i] = gf256Exp[i-255]
+	}
+}
+
+func gf256Mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gf256Exp[int(gf256Log[a])+int(gf256Log[b])]
+}
+
+func gf256Div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gf256Exp[int(gf256Log[a])+255-int(gf256Log[b])]
+}
+
+//ShamirShare is one share of a k-of-n split secret. Shares of the same split have the same Id
+type ShamirShare struct {
+	Version   byte
+	Id        uint16
+	Type      byte
+	Param     byte
+	Threshold byte
+	Index     byte
+	Value     []byte
+}
+
+//ShamirSecret is the secret recovered from shares
+type ShamirSecret struct {
+	Type  byte
+	Param byte
+	Data  []byte
+}
+
+//SplitSecret split secret into count shares, any threshold shares can recover the secret.
+//A digest of secret is split together with secret, so that wrong share set can be detected on recovery
+func SplitSecret(secretType, param byte, secret []byte, threshold, count int) ([]*ShamirShare, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("secret cannot empty")
+	}
+	if threshold < 2 || threshold > count || count > MAX_SHAMIR_SHARES {
+		return nil, fmt.Errorf("invalid threshold:%d of count:%d, should be 2 <= threshold <= count <= %d", threshold, count, MAX_SHAMIR_SHARES)
+	}
+	idData := make([]byte, 2)
+	_, err := rand.Read(idData)
+	if err != nil {
+		return nil, fmt.Errorf("generate share id error:%s", err)
+	}
+	digest := sha256.Sum256(secret)
+	data := append(append([]byte{}, secret...), digest[:shamirChecksumLen]...)
+	defer wipeBytes(data)
+
+	shares := make([]*ShamirShare, 0, count)
+	for i := 1; i <= count; i++ {
+		shares = append(shares, &ShamirShare{
+			Version:   SHAMIR_SHARE_VERSION,
+			Id:        binary.BigEndian.Uint16(idData),
+			Type:      secretType,
+			Param:     param,
+			Threshold: byte(threshold),
+			Index:     byte(i),
+			Value:     make([]byte, len(data)),
+		})
+	}
+	coeffs := make([]byte, threshold)
+	defer wipeBytes(coeffs)
+	for i, b := range data {
+		coeffs[0] = b
+		_, err = rand.Read(coeffs[1:])
+		if err != nil {
+			return nil, fmt.Errorf("generate polynomial error:%s", err)
+		}
+		for _, share := range shares {
+			//Horner's method
+			var y byte
+			for j := threshold - 1; j >= 0; j-- {
+				y = gf256Mul(y, share.Index) ^ coeffs[j]
+			}
+			share.Value[i] = y
+		}
+	}
+	return shares, nil
+}
+
+//CombineShares recover secret from shares. Shares must be of the same split, and no less than threshold.
+//Shares more than threshold are checked to be consistent with the others
+func CombineShares(shares []*ShamirShare) (*ShamirSecret, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("no share")
+	}
+	first := shares[0]
+	indexes := make(map[byte]bool, len(shares))
+	for _, share := range shares {
+		if share.Id != first.Id || share.Type != first.Type || share.Param != first.Param ||
+			share.Threshold != first.Threshold || len(share.Value) != len(first.Value) {
+			return nil, fmt.Errorf("share:%d does not belong to the same secret", share.Index)
+		}
+		if share.Index == 0 {
+			return nil, fmt.Errorf("invalid share index:0")
+		}
+		if indexes[share.Index] {
+			return nil, fmt.Errorf("duplicate share:%d", share.Index)
+		}
+		indexes[share.Index] = true
+	}
+	threshold := int(first.Threshold)
+	if len(shares) < threshold {
+		return nil, fmt.Errorf("need %d shares, only %d given", threshold, len(shares))
+	}
+	if len(first.Value) <= shamirChecksumLen {
+		return nil, fmt.Errorf("invalid share value length:%d", len(first.Value))
+	}
+	data := interpolateShares(shares[:threshold], 0)
+	secret := data[:len(data)-shamirChecksumLen]
+	digest := sha256.Sum256(secret)
+	if !bytes.Equal(digest[:shamirChecksumLen], data[len(secret):]) {
+		wipeBytes(data)
+		return nil, fmt.Errorf("secret digest mismatch, shares are invalid")
+	}
+	for _, share := range shares[threshold:] {
+		if !bytes.Equal(interpolateShares(shares[:threshold], share.Index), share.Value) {
+			wipeBytes(data)
+			return nil, fmt.Errorf("share:%d is inconsistent with the others", share.Index)
+		}
+	}
+	return &ShamirSecret{
+		Type:  first.Type,
+		Param: first.Param,
+		Data:  secret,
+	}, nil
+}
+
+//interpolateShares return the values of polynomial at x by Lagrange interpolation
+func interpolateShares(shares []*ShamirShare, x byte) []byte {
+	value := make([]byte, len(shares[0].Value))
+	for i, si := range shares {
+		basis := byte(1)
+		for j, sj := range shares {
+			if i == j {
+				continue
+			}
+			basis = gf256Mul(basis, gf256Div(x^sj.Index, si.Index^sj.Index))
+		}
+		for k, y := range si.Value {
+			value[k] ^= gf256Mul(basis, y)
+		}
+	}
+	return value
+}
+
+//Serialize return the binary of share, with checksum at the end
+func (this *ShamirShare) Serialize() []byte {
+	data := make([]byte, 0, shamirHeaderLen+len(this.Value)+shamirChecksumLen)
+	data = append(data, this.Version, byte(this.Id>>8), byte(this.Id), this.Type, this.Param, this.Threshold, this.Index)
+	data = append(data, this.Value...)
+	checksum := sha256.Sum256(data)
+	return append(data, checksum[:shamirChecksumLen]...)
+}
+
+//Encode return the share in encoding, SHAMIR_ENCODING_WORDS or SHAMIR_ENCODING_HEX
+func (this *ShamirShare) Encode(encoding string) (string, error) {
+	data := this.Serialize()
+	switch encoding {
+	case SHAMIR_ENCODING_WORDS:
+		return encodeShareWords(data)
+	case SHAMIR_ENCODING_HEX:
+		return hex.EncodeToString(data), nil
+	default:
+		return "", fmt.Errorf("unsupport share encoding:%s", encoding)
+	}
+}
+
+//DeserializeShamirShare parse share from binary, and verify checksum
+func DeserializeShamirShare(data []byte) (*ShamirShare, error) {
+	if len(data) <= shamirHeaderLen+shamirChecksumLen {
+		return nil, fmt.Errorf("invalid share length:%d", len(data))
+	}
+	body := data[:len(data)-shamirChecksumLen]
+	checksum := sha256.Sum256(body)
+	if !bytes.Equal(checksum[:shamirChecksumLen], data[len(body):]) {
+		return nil, fmt.Errorf("share checksum mismatch")
+	}
+	if body[0] != SHAMIR_SHARE_VERSION {
+		return nil, fmt.Errorf("unsupport share version:%d", body[0])
+	}
+	return &ShamirShare{
+		Version:   body[0],
+		Id:        binary.BigEndian.Uint16(body[1:3]),
+		Type:      body[3],
+		Param:     body[4],
+		Threshold: body[5],
+		Index:     body[6],
+		Value:     append([]byte{}, body[shamirHeaderLen:]...),
+	}, nil
+}
+
+//ParseShamirShare parse share encoded in words or hex. Share of several words is decoded as words
+func ParseShamirShare(share string) (*ShamirShare, error) {
+	share = strings.TrimSpace(share)
+	var data []byte
+	var err error
+	if len(strings.Fields(share)) > 1 {
+		data, err = decodeShareWords(share)
+	} else {
+		data, err = hex.DecodeString(share)
+		if err != nil {
+			err = fmt.Errorf("decode hex share error:%s", err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return DeserializeShamirShare(data)
+}
+
+//ParseShamirShares parse shares, see ParseShamirShare
+func ParseShamirShares(shares []string) ([]*ShamirShare, error) {
+	result := make([]*ShamirShare, 0, len(shares))
+	for i, share := range shares {
+		shamirShare, err := ParseShamirShare(share)
+		if err != nil {
+			return nil, fmt.Errorf("parse share %d error:%s", i+1, err)
+		}
+		result = append(result, shamirShare)
+	}
+	return result, nil
+}
+
+//encodeShareWords encode data with length prefix as english words, each word is 11 bits
+func encodeShareWords(data []byte) (string, error) {
+	if len(data) > 255 {
+		return "", fmt.Errorf("share too long:%d", len(data))
+	}
+	words := mnemonicWordLists[MNEMONIC_LANG_ENGLISH]
+	payload := append([]byte{byte(len(data))}, data...)
+	wordCount := (len(payload)*8 + 10) / 11
+	//padding so that the bits of last word can be read
+	payload = append(payload, 0, 0)
+	result := make([]string, 0, wordCount)
+	for i := 0; i < wordCount; i++ {
+		result = append(result, words[getMnemonicBits(payload, i*11)])
+	}
+	return strings.Join(result, " "), nil
+}
+
+func decodeShareWords(share string) ([]byte, error) {
+	words := splitMnemonicWords(share)
+	indexes := mnemonicWordIndexes[MNEMONIC_LANG_ENGLISH]
+	payload := make([]byte, (len(words)*11+7)/8)
+	for i, word := range words {
+		index, ok := indexes[word]
+		if !ok {
+			return nil, fmt.Errorf("unknown share word:%s at %d", word, i+1)
+		}
+		for j := 0; j < 11; j++ {
+			if index&(1<<uint(10-j)) != 0 {
+				bit := i*11 + j
+				payload[bit/8] |= 1 << uint(7-bit%8)
+			}
+		}
+	}
+	size := int(payload[0])
+	if size == 0 || ((size+1)*8+10)/11 != len(words) {
+		return nil, fmt.Errorf("invalid share word count:%d", len(words))
+	}
+	return payload[1 : size+1], nil
+}
+
+func encodeShares(shares []*ShamirShare, encoding string) ([]string, error) {
+	result := make([]string, 0, len(shares))
+	for _, share := range shares {
+		data, err := share.Encode(encoding)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, data)
+	}
+	return result, nil
+}
+
+func combineEncodedShares(shares []string) (*ShamirSecret, error) {
+	shamirShares, err := ParseShamirShares(shares)
+	if err != nil {
+		return nil, err
+	}
+	return CombineShares(shamirShares)
+}
+
+//SplitAccount split the private key of account into count shares in encoding, any threshold shares can recover the account
+func SplitAccount(acc *Account, threshold, count int, encoding string) ([]string, error) {
+	privateKey, err := getRawPrivateKey(acc.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(privateKey)
+	shares, err := SplitSecret(SHAMIR_SECRET_PRIVATE_KEY, byte(acc.SigScheme), privateKey, threshold, count)
+	if err != nil {
+		return nil, err
+	}
+	return encodeShares(shares, encoding)
+}
+
+//SplitMnemonic split the entropy of mnemonic into count shares in encoding, any threshold shares can recover the mnemonic
+func SplitMnemonic(mnemonic string, threshold, count int, encoding string) ([]string, error) {
+	return SplitMnemonicWithPassphrase(mnemonic, "", threshold, count, encoding)
+}
+
+//SplitMnemonicWithPassphrase split the entropy of mnemonic and BIP39 passphrase into count shares in encoding, any
+//threshold shares can recover both of them. Empty passphrase is the same as SplitMnemonic
+func SplitMnemonicWithPassphrase(mnemonic, passphrase string, threshold, count int, encoding string) ([]string, error) {
+	lang, err := DetectMnemonicLanguage(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	entropy, err := MnemonicToEntropy(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(entropy)
+	langIndex := 0
+	for i, l := range mnemonicLanguages {
+		if l == lang {
+			langIndex = i
+			break
+		}
+	}
+	secretType := byte(SHAMIR_SECRET_MNEMONIC)
+	secret := entropy
+	if passphrase != "" {
+		secretType = SHAMIR_SECRET_MNEMONIC_PASSPHRASE
+		secret = make([]byte, 0, 1+len(entropy)+len(passphrase))
+		secret = append(secret, byte(len(entropy)))
+		secret = append(secret, entropy...)
+		secret = append(secret, passphrase...)
+		defer wipeBytes(secret)
+	}
+	shares, err := SplitSecret(secretType, byte(langIndex), secret, threshold, count)
+	if err != nil {
+		return nil, err
+	}
+	return encodeShares(shares, encoding)
+}
+
+//SplitSeed split BIP39 seed into count shares in encoding, any threshold shares can recover the seed
+func SplitSeed(seed []byte, threshold, count int, encoding string) ([]string, error) {
+	shares, err := SplitSecret(SHAMIR_SECRET_SEED, 0, seed, threshold, count)
+	if err != nil {
+		return nil, err
+	}
+	return encodeShares(shares, encoding)
+}
+
+//RecoverAccount recover account from shares of SplitAccount
+func RecoverAccount(shares []string) (*Account, error) {
+	secret, err := combineEncodedShares(shares)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(secret.Data)
+	if secret.Type != SHAMIR_SECRET_PRIVATE_KEY {
+		return nil, fmt.Errorf("shares are not of private key")
+	}
+	sigScheme := s.SignatureScheme(secret.Param)
+	keyType, err := GetKeyTypeOfScheme(sigScheme)
+	if err != nil {
+		return nil, err
+	}
+	return NewAccountFromPrivateKeyWithKeyType(secret.Data, keyType, sigScheme)
+}
+
+//RecoverMnemonic recover mnemonic from shares of SplitMnemonic. Shares with passphrase are refused, which should
+//be recovered by RecoverMnemonicWithPassphrase
+func RecoverMnemonic(shares []string) (string, error) {
+	mnemonic, passphrase, err := RecoverMnemonicWithPassphrase(shares)
+	if err != nil {
+		return "", err
+	}
+	if passphrase != "" {
+		return "", fmt.Errorf("shares contain BIP39 passphrase, should be recovered with passphrase")
+	}
+	return mnemonic, nil
+}
+
+//RecoverMnemonicWithPassphrase recover mnemonic and BIP39 passphrase from shares of SplitMnemonicWithPassphrase,
+//passphrase is empty for shares of SplitMnemonic
+func RecoverMnemonicWithPassphrase(shares []string) (string, string, error) {
+	secret, err := combineEncodedShares(shares)
+	if err != nil {
+		return "", "", err
+	}
+	defer wipeBytes(secret.Data)
+	return secret.toMnemonic()
+}
+
+//RecoverSeed recover BIP39 seed from shares of SplitMnemonic, SplitMnemonicWithPassphrase or SplitSeed. passphrase is
+//used with shares of SplitMnemonic only, and must be empty or the same as the passphrase in shares if there is one
+func RecoverSeed(shares []string, passphrase string) ([]byte, error) {
+	secret, err := combineEncodedShares(shares)
+	if err != nil {
+		return nil, err
+	}
+	if secret.Type == SHAMIR_SECRET_SEED {
+		return secret.Data, nil
+	}
+	defer wipeBytes(secret.Data)
+	mnemonic, passphrase, err := secret.getMnemonicAndPassphrase(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return MnemonicToSeed(mnemonic, passphrase)
+}
+
+//toMnemonic return the mnemonic and passphrase of secret
+func (this *ShamirSecret) toMnemonic() (string, string, error) {
+	if this.Type != SHAMIR_SECRET_MNEMONIC && this.Type != SHAMIR_SECRET_MNEMONIC_PASSPHRASE {
+		return "", "", fmt.Errorf("shares are not of mnemonic")
+	}
+	if int(this.Param) >= len(mnemonicLanguages) {
+		return "", "", fmt.Errorf("unknown mnemonic language:%d", this.Param)
+	}
+	entropy, passphrase := this.Data, ""
+	if this.Type == SHAMIR_SECRET_MNEMONIC_PASSPHRASE {
+		if len(this.Data) == 0 || int(this.Data[0]) >= len(this.Data) {
+			return "", "", fmt.Errorf("invalid mnemonic secret")
+		}
+		entropy = this.Data[1 : 1+int(this.Data[0])]
+		passphrase = string(this.Data[1+int(this.Data[0]):])
+	}
+	mnemonic, err := EntropyToMnemonic(entropy, mnemonicLanguages[this.Param])
+	if err != nil {
+		return "", "", err
+	}
+	return mnemonic, passphrase, nil
+}
+
+//getMnemonicAndPassphrase return the mnemonic of secret, and the passphrase in secret or the given passphrase
+func (this *ShamirSecret) getMnemonicAndPassphrase(passphrase string) (string, string, error) {
+	mnemonic, sharedPassphrase, err := this.toMnemonic()
+	if err != nil {
+		return "", "", err
+	}
+	if sharedPassphrase == "" {
+		return mnemonic, passphrase, nil
+	}
+	if passphrase != "" && passphrase != sharedPassphrase {
+		return "", "", fmt.Errorf("passphrase is different from the passphrase in shares")
+	}
+	return mnemonic, sharedPassphrase, nil
+}
+
+//ExportAccountShares split the private key of account of address in wallet into shares, see SplitAccount
+func (this *Wallet) ExportAccountShares(address string, passwd []byte, threshold, count int, encoding string) ([]string, error) {
+	acc, err := this.GetAccountByAddress(address, passwd)
+	if err != nil {
+		return nil, err
+	}
+	defer WipePrivateKey(acc.PrivateKey)
+	return SplitAccount(acc, threshold, count, encoding)
+}
+
+//ImportAccountShares recover account from shares, and add it into wallet encrypted by passwd
+func (this *Wallet) ImportAccountShares(shares []string, passwd []byte) (*AccountData, error) {
+	acc, err := RecoverAccount(shares)
+	if err != nil {
+		return nil, err
+	}
+	defer WipePrivateKey(acc.PrivateKey)
+	if _, err = this.GetAccountDataByAddress(acc.Address.ToBase58()); err == nil {
+		return nil, fmt.Errorf("account:%s already exist", acc.Address.ToBase58())
+	}
+	accData, err := NewAccountDataFromAccount(acc, passwd, this.Scrypt)
+	if err != nil {
+		return nil, err
+	}
+	err = this.AddAccountData(accData)
+	if err != nil {
+		return nil, err
+	}
+	return accData, nil
+}
+
+//ExportHDShares split the mnemonic and BIP39 passphrase of HD wallet into shares, or the seed if HD wallet is created
+//from seed. The passphrase is included in shares, so that ImportHDShares restores the same accounts without it
+func (this *Wallet) ExportHDShares(passwd []byte, threshold, count int, encoding string) ([]string, error) {
+	seedData, err := this.getHDSeedData()
+	if err != nil {
+		return nil, err
+	}
+	if seedData.Type == HD_SEED_TYPE_MNEMONIC {
+		mnemonic, err := this.GetHDMnemonic(passwd)
+		if err != nil {
+			return nil, err
+		}
+		passphrase, err := seedData.GetPassphrase(passwd, this.Scrypt)
+		if err != nil {
+			return nil, err
+		}
+		return SplitMnemonicWithPassphrase(mnemonic, passphrase, threshold, count, encoding)
+	}
+	seed, err := this.GetHDSeed(passwd)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(seed)
+	return SplitSeed(seed, threshold, count, encoding)
+}
+
+//ImportHDShares recover mnemonic or seed from shares as HD seed of wallet. passphrase is used with shares of
+//SplitMnemonic only, and must be empty or the same as the passphrase in shares if there is one
+func (this *Wallet) ImportHDShares(shares []string, passphrase string, passwd []byte) error {
+	secret, err := combineEncodedShares(shares)
+	if err != nil {
+		return err
+	}
+	defer wipeBytes(secret.Data)
+	if secret.Type == SHAMIR_SECRET_SEED {
+		return this.InitHDFromSeed(secret.Data, passwd)
+	}
+	mnemonic, passphrase, err := secret.getMnemonicAndPassphrase(passphrase)
+	if err != nil {
+		return err
+	}
+	return this.InitHDFromMnemonicWithPassphrase(mnemonic, passphrase, passwd)
+}
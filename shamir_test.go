@@ -0,0 +1,248 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	s "github.com/TesraSupernet/tesracrypto/signature"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestSplitSecret(t *testing.T) {
+	secret := []byte("treasury private key 0123456789")
+	shares, err := SplitSecret(SHAMIR_SECRET_SEED, 0, secret, 3, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(shares))
+	for i := 0; i < 5; i++ {
+		for j := i + 1; j < 5; j++ {
+			for k := j + 1; k < 5; k++ {
+				result, err := CombineShares([]*ShamirShare{shares[k], shares[i], shares[j]})
+				assert.Nil(t, err)
+				assert.Equal(t, secret, result.Data)
+			}
+		}
+	}
+	result, err := CombineShares(shares)
+	assert.Nil(t, err)
+	assert.Equal(t, secret, result.Data)
+
+	_, err = CombineShares(shares[:2])
+	assert.NotNil(t, err)
+	_, err = CombineShares([]*ShamirShare{shares[0], shares[1], shares[1]})
+	assert.NotNil(t, err)
+
+	corrupted := *shares[4]
+	corrupted.Value = append([]byte{}, shares[4].Value...)
+	corrupted.Value[0] ^= 1
+	_, err = CombineShares([]*ShamirShare{shares[0], shares[1], &corrupted})
+	assert.NotNil(t, err)
+	_, err = CombineShares([]*ShamirShare{shares[0], shares[1], shares[2], &corrupted})
+	assert.NotNil(t, err)
+
+	others, err := SplitSecret(SHAMIR_SECRET_SEED, 0, secret, 3, 5)
+	assert.Nil(t, err)
+	others[2].Id = shares[0].Id ^ 1
+	_, err = CombineShares([]*ShamirShare{shares[0], shares[1], others[2]})
+	assert.NotNil(t, err)
+
+	_, err = SplitSecret(SHAMIR_SECRET_SEED, 0, secret, 1, 5)
+	assert.NotNil(t, err)
+	_, err = SplitSecret(SHAMIR_SECRET_SEED, 0, secret, 6, 5)
+	assert.NotNil(t, err)
+	_, err = SplitSecret(SHAMIR_SECRET_SEED, 0, secret, 2, 256)
+	assert.NotNil(t, err)
+}
+
+func TestShamirShareEncoding(t *testing.T) {
+	for size := 1; size <= 64; size++ {
+		secret := make([]byte, size)
+		for i := range secret {
+			secret[i] = byte(i*7 + size)
+		}
+		shares, err := SplitSecret(SHAMIR_SECRET_SEED, 0, secret, 2, 3)
+		assert.Nil(t, err)
+		for _, encoding := range []string{SHAMIR_ENCODING_WORDS, SHAMIR_ENCODING_HEX} {
+			data, err := shares[1].Encode(encoding)
+			assert.Nil(t, err)
+			share, err := ParseShamirShare(data)
+			assert.Nil(t, err)
+			assert.Equal(t, shares[1], share)
+		}
+	}
+	shares, err := SplitSecret(SHAMIR_SECRET_SEED, 0, []byte("secret"), 2, 3)
+	assert.Nil(t, err)
+	_, err = shares[0].Encode("base64")
+	assert.NotNil(t, err)
+
+	words, err := shares[0].Encode(SHAMIR_ENCODING_WORDS)
+	assert.Nil(t, err)
+	list := strings.Split(words, " ")
+	_, err = ParseShamirShare(strings.Join(list[:len(list)-1], " "))
+	assert.NotNil(t, err)
+	if list[1] == "zoo" {
+		list[1] = "abandon"
+	} else {
+		list[1] = "zoo"
+	}
+	_, err = ParseShamirShare(strings.Join(list, " "))
+	assert.NotNil(t, err)
+
+	data, err := shares[0].Encode(SHAMIR_ENCODING_HEX)
+	assert.Nil(t, err)
+	if data[16] == '0' {
+		data = data[:16] + "1" + data[17:]
+	} else {
+		data = data[:16] + "0" + data[17:]
+	}
+	_, err = ParseShamirShare(data)
+	assert.NotNil(t, err)
+}
+
+func TestSplitAccount(t *testing.T) {
+	accounts := []*Account{
+		NewAccount(s.SHA256withECDSA),
+		NewAccount(s.SM3withSM2),
+		NewAccount(s.SHA512withEDDSA),
+	}
+	for _, acc := range accounts {
+		shares, err := SplitAccount(acc, 2, 3, SHAMIR_ENCODING_WORDS)
+		assert.Nil(t, err)
+		result, err := RecoverAccount([]string{shares[2], shares[0]})
+		assert.Nil(t, err)
+		assert.Equal(t, acc.Address, result.Address)
+		assert.Equal(t, acc.SigScheme, result.SigScheme)
+
+		_, err = RecoverAccount(shares[:1])
+		assert.NotNil(t, err)
+		_, err = RecoverMnemonic(shares)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestSplitMnemonic(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	shares, err := SplitMnemonic(mnemonic, 3, 5, SHAMIR_ENCODING_HEX)
+	assert.Nil(t, err)
+	result, err := RecoverMnemonic(shares[1:4])
+	assert.Nil(t, err)
+	assert.Equal(t, mnemonic, result)
+
+	seed, err := RecoverSeed(shares[2:], "TREZOR")
+	assert.Nil(t, err)
+	expected, err := MnemonicToSeed(mnemonic, "TREZOR")
+	assert.Nil(t, err)
+	assert.Equal(t, expected, seed)
+
+	mnemonic, err = NewMnemonic(24, MNEMONIC_LANG_FRENCH)
+	assert.Nil(t, err)
+	shares, err = SplitMnemonic(mnemonic, 2, 2, SHAMIR_ENCODING_WORDS)
+	assert.Nil(t, err)
+	result, err = RecoverMnemonic(shares)
+	assert.Nil(t, err)
+	assert.Equal(t, NormalizeMnemonic(mnemonic), NormalizeMnemonic(result))
+	_, err = RecoverAccount(shares)
+	assert.NotNil(t, err)
+
+	//passphrase is included in shares
+	mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	shares, err = SplitMnemonicWithPassphrase(mnemonic, "TREZOR", 2, 3, SHAMIR_ENCODING_WORDS)
+	assert.Nil(t, err)
+	_, err = RecoverMnemonic(shares)
+	assert.NotNil(t, err)
+	result, passphrase, err := RecoverMnemonicWithPassphrase(shares[1:])
+	assert.Nil(t, err)
+	assert.Equal(t, mnemonic, result)
+	assert.Equal(t, "TREZOR", passphrase)
+	seed, err = RecoverSeed(shares[:2], "")
+	assert.Nil(t, err)
+	assert.Equal(t, expected, seed)
+	_, err = RecoverSeed(shares[:2], "other")
+	assert.NotNil(t, err)
+
+	seed = expected
+	shares, err = SplitSeed(seed, 2, 3, SHAMIR_ENCODING_WORDS)
+	assert.Nil(t, err)
+	result2, err := RecoverSeed(shares[1:], "")
+	assert.Nil(t, err)
+	assert.Equal(t, seed, result2)
+	_, err = RecoverMnemonic(shares)
+	assert.NotNil(t, err)
+}
+
+func TestWalletShares(t *testing.T) {
+	passwd := []byte("123456")
+	wallet := NewWalletWithKeyStore(NewMemoryKeyStore(nil))
+	acc, err := wallet.NewDefaultSettingAccount(passwd)
+	assert.Nil(t, err)
+	_, err = wallet.ExportAccountShares(acc.Address.ToBase58(), []byte("wrong"), 2, 3, SHAMIR_ENCODING_WORDS)
+	assert.NotNil(t, err)
+	shares, err := wallet.ExportAccountShares(acc.Address.ToBase58(), passwd, 2, 3, SHAMIR_ENCODING_WORDS)
+	assert.Nil(t, err)
+	_, err = wallet.ImportAccountShares(shares[:2], passwd)
+	assert.NotNil(t, err)
+
+	other := NewWalletWithKeyStore(NewMemoryKeyStore(nil))
+	newPasswd := []byte("654321")
+	accData, err := other.ImportAccountShares(shares[1:], newPasswd)
+	assert.Nil(t, err)
+	assert.Equal(t, acc.Address.ToBase58(), accData.Address)
+	result, err := other.GetAccountByAddress(accData.Address, newPasswd)
+	assert.Nil(t, err)
+	assert.Equal(t, keypair.SerializePublicKey(acc.PublicKey), keypair.SerializePublicKey(result.PublicKey))
+
+	_, err = wallet.ExportHDShares(passwd, 2, 3, SHAMIR_ENCODING_WORDS)
+	assert.NotNil(t, err)
+	mnemonic, err := wallet.InitHD(passwd)
+	assert.Nil(t, err)
+	shares, err = wallet.ExportHDShares(passwd, 2, 3, SHAMIR_ENCODING_WORDS)
+	assert.Nil(t, err)
+	assert.Nil(t, other.ImportHDShares(shares[:2], "", newPasswd))
+	result3, err := other.GetHDMnemonic(newPasswd)
+	assert.Nil(t, err)
+	assert.Equal(t, mnemonic, result3)
+	assert.NotNil(t, other.ImportHDShares(shares[1:], "", newPasswd))
+
+	//BIP39 passphrase of HD wallet is included in shares
+	passphraseWallet := NewWalletWithKeyStore(NewMemoryKeyStore(nil))
+	_, err = passphraseWallet.InitHDWithOptions(DEFAULT_MNEMONIC_WORD_COUNT, MNEMONIC_LANG_ENGLISH, "TREZOR", passwd)
+	assert.Nil(t, err)
+	hdAcc, err := passphraseWallet.NewHDAccount(passwd)
+	assert.Nil(t, err)
+	shares, err = passphraseWallet.ExportHDShares(passwd, 2, 3, SHAMIR_ENCODING_WORDS)
+	assert.Nil(t, err)
+	restored := NewWalletWithKeyStore(NewMemoryKeyStore(nil))
+	assert.NotNil(t, restored.ImportHDShares(shares[:2], "other", passwd))
+	assert.Nil(t, restored.ImportHDShares(shares[:2], "", passwd))
+	restoredAcc, err := restored.NewHDAccount(passwd)
+	assert.Nil(t, err)
+	assert.Equal(t, hdAcc.Address, restoredAcc.Address)
+
+	seed, err := wallet.GetHDSeed(passwd)
+	assert.Nil(t, err)
+	seedWallet := NewWalletWithKeyStore(NewMemoryKeyStore(nil))
+	assert.Nil(t, seedWallet.InitHDFromSeed(seed, passwd))
+	shares, err = seedWallet.ExportHDShares(passwd, 2, 3, SHAMIR_ENCODING_HEX)
+	assert.Nil(t, err)
+	restored = NewWalletWithKeyStore(NewMemoryKeyStore(nil))
+	assert.Nil(t, restored.ImportHDShares(shares[:2], "", passwd))
+	result4, err := restored.GetHDSeed(passwd)
+	assert.Nil(t, err)
+	assert.Equal(t, seed, result4)
+}
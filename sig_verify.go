@@ -0,0 +1,183 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/common/constants"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	s "github.com/TesraSupernet/tesracrypto/signature"
+	"strconv"
+)
+
+//SIGN_MESSAGE_PREFIX is prepended to message before signing, so that a signed message can never be used as
+//the signature of a transaction
+const SIGN_MESSAGE_PREFIX = "\x19Tesra Signed Message:\n"
+
+//MessageSigner is a Signer which signs message by itself, such as RemoteSigner.
+//SignMessage calls SignMessage of signer if signer implements MessageSigner.
+type MessageSigner interface {
+	Signer
+	SignMessage(msg []byte) ([]byte, error)
+}
+
+//GetMessageHash return the sha256 hash of SIGN_MESSAGE_PREFIX, message length and message, which is the data
+//actually signed when signing a message
+func GetMessageHash(msg []byte) []byte {
+	data := make([]byte, 0, len(SIGN_MESSAGE_PREFIX)+20+len(msg))
+	data = append(data, SIGN_MESSAGE_PREFIX...)
+	data = append(data, strconv.Itoa(len(msg))...)
+	data = append(data, msg...)
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+//SignMessage sign msg by signer, the signature is of GetMessageHash(msg) and can be verified by VerifyMessage
+func SignMessage(signer Signer, msg []byte) ([]byte, error) {
+	if msgSigner, ok := signer.(MessageSigner); ok {
+		return msgSigner.SignMessage(msg)
+	}
+	return signer.Sign(GetMessageHash(msg))
+}
+
+//VerifyMessage verify sigData of msg, which is returned by SignMessage. Return nil if verify success.
+func VerifyMessage(pubKey keypair.PublicKey, msg, sigData []byte) error {
+	return VerifySignature(pubKey, GetMessageHash(msg), sigData)
+}
+
+//VerifySignature verify sigData of data, which is returned by Signer.Sign. Signature scheme is
+//carried by sigData, and must be supported by the key type of pubKey. Return nil if verify success.
+func VerifySignature(pubKey keypair.PublicKey, data, sigData []byte) error {
+	_, err := verifySignature(pubKey, data, sigData)
+	return err
+}
+
+//VerifySignatureWithScheme verify sigData like VerifySignature, and the signature scheme of sigData must be sigScheme
+func VerifySignatureWithScheme(pubKey keypair.PublicKey, data, sigData []byte, sigScheme s.SignatureScheme) error {
+	scheme, err := verifySignature(pubKey, data, sigData)
+	if err != nil {
+		return err
+	}
+	if scheme != sigScheme {
+		return fmt.Errorf("signature scheme:%s does not match:%s", scheme.Name(), sigScheme.Name())
+	}
+	return nil
+}
+
+//VerifySignatureByAddress verify sigData like VerifySignature, and pubKey must belong to address
+func VerifySignatureByAddress(address common.Address, pubKey keypair.PublicKey, data, sigData []byte) error {
+	if types.AddressFromPubKey(pubKey) != address {
+		return fmt.Errorf("pubkey does not belong to address:%s", address.ToBase58())
+	}
+	return VerifySignature(pubKey, data, sigData)
+}
+
+func verifySignature(pubKey keypair.PublicKey, data, sigData []byte) (s.SignatureScheme, error) {
+	if pubKey == nil {
+		return 0, fmt.Errorf("pubkey should not be nil")
+	}
+	sig, err := s.Deserialize(sigData)
+	if err != nil {
+		return 0, fmt.Errorf("signature.Deserialize error:%s", err)
+	}
+	keyType := keypair.GetKeyType(pubKey)
+	if !CheckSigScheme(keyType, sig.Scheme) {
+		return 0, fmt.Errorf("signature scheme:%s does not match key type:%s", sig.Scheme.Name(), GetKeyTypeString(keyType))
+	}
+	if !s.Verify(pubKey, data, sig) {
+		return 0, fmt.Errorf("signature verify failed")
+	}
+	return sig.Scheme, nil
+}
+
+//VerifyMultiSignature verify m-of-n multi-sign sigData of data. Every sigData must be signed by a
+//different pubkey in pubKeys, in any order, and at least m sigData are required.
+func VerifyMultiSignature(data []byte, pubKeys []keypair.PublicKey, m int, sigData [][]byte) error {
+	n := len(pubKeys)
+	if m <= 0 || m > n || n > constants.MULTI_SIG_MAX_PUBKEY_SIZE {
+		return fmt.Errorf("both m and number of pub key must larger than 0, and small than %d, and m must smaller than pub key number", constants.MULTI_SIG_MAX_PUBKEY_SIZE)
+	}
+	if len(sigData) < m {
+		return fmt.Errorf("not enough signatures:%d, need:%d", len(sigData), m)
+	}
+	if len(sigData) > n {
+		return fmt.Errorf("too many signatures:%d, pubkey count:%d", len(sigData), n)
+	}
+	used := make([]bool, n)
+	for i, sd := range sigData {
+		verified := false
+		for j, pk := range pubKeys {
+			if used[j] {
+				continue
+			}
+			if VerifySignature(pk, data, sd) == nil {
+				used[j] = true
+				verified = true
+				break
+			}
+		}
+		if !verified {
+			return fmt.Errorf("signature %d does not match any pubkey", i)
+		}
+	}
+	return nil
+}
+
+//VerifyTxSig verify sig of tx against tx hash. Sig with one pubkey is a normal account signature,
+//otherwise it is a multi-sign signature.
+func VerifyTxSig(tx *types.MutableTransaction, sig types.Sig) error {
+	txHash := tx.Hash()
+	switch len(sig.PubKeys) {
+	case 0:
+		return fmt.Errorf("no pubkey in sig")
+	case 1:
+		if len(sig.SigData) != 1 {
+			return fmt.Errorf("signature count:%d of normal account should be 1", len(sig.SigData))
+		}
+		return VerifySignature(sig.PubKeys[0], txHash.ToArray(), sig.SigData[0])
+	default:
+		return VerifyMultiSignature(txHash.ToArray(), sig.PubKeys, int(sig.M), sig.SigData)
+	}
+}
+
+//VerifyTransaction verify all of the sigs of tx
+func VerifyTransaction(tx *types.MutableTransaction) error {
+	if len(tx.Sigs) == 0 {
+		return fmt.Errorf("tx has no signature")
+	}
+	for i, sig := range tx.Sigs {
+		err := VerifyTxSig(tx, sig)
+		if err != nil {
+			return fmt.Errorf("sigs[%d] error:%s", i, err)
+		}
+	}
+	return nil
+}
+
+//Verify verify sigData of data signed by account
+func (this *Account) Verify(data, sigData []byte) error {
+	return VerifySignatureWithScheme(this.PublicKey, data, sigData, this.SigScheme)
+}
+
+//Verify verify sigData of data signed by controller
+func (this *Controller) Verify(data, sigData []byte) error {
+	return VerifySignatureWithScheme(this.PublicKey, data, sigData, this.SigScheme)
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	s "github.com/TesraSupernet/tesracrypto/signature"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestVerifySignature(t *testing.T) {
+	msg := []byte("challenge:1234567890")
+	for _, scheme := range []s.SignatureScheme{s.SHA256withECDSA, s.SHA3_384withECDSA, s.SM3withSM2, s.SHA512withEDDSA} {
+		acc := NewAccount(scheme)
+		sigData, err := acc.Sign(msg)
+		assert.Nil(t, err)
+		assert.Nil(t, VerifySignature(acc.PublicKey, msg, sigData), scheme.Name())
+		assert.Nil(t, acc.Verify(msg, sigData), scheme.Name())
+		assert.Nil(t, VerifySignatureByAddress(acc.Address, acc.PublicKey, msg, sigData))
+		assert.NotNil(t, VerifySignature(acc.PublicKey, []byte("other"), sigData), scheme.Name())
+		assert.NotNil(t, VerifySignature(NewAccount(scheme).PublicKey, msg, sigData), scheme.Name())
+		assert.NotNil(t, VerifySignatureByAddress(NewAccount().Address, acc.PublicKey, msg, sigData))
+	}
+}
+
+func TestVerifyMultiSignature(t *testing.T) {
+	msg := []byte("multi sign message")
+	acc1 := NewAccount()
+	acc2 := NewAccount(s.SM3withSM2)
+	acc3 := NewAccount(s.SHA512withEDDSA)
+	pubKeys := []keypair.PublicKey{acc1.PublicKey, acc2.PublicKey, acc3.PublicKey}
+	sig1, err := acc1.Sign(msg)
+	assert.Nil(t, err)
+	sig3, err := acc3.Sign(msg)
+	assert.Nil(t, err)
+
+	assert.Nil(t, VerifyMultiSignature(msg, pubKeys, 2, [][]byte{sig3, sig1}))
+	assert.NotNil(t, VerifyMultiSignature(msg, pubKeys, 2, [][]byte{sig1}))
+	assert.NotNil(t, VerifyMultiSignature(msg, pubKeys, 2, [][]byte{sig1, sig1}))
+	assert.NotNil(t, VerifyMultiSignature(msg, pubKeys, 4, [][]byte{sig1, sig3}))
+
+	sdk := NewTesraSdk()
+	tx, err := sdk.Native.Tsg.NewTransferTransaction(500, 20000, acc1.Address, acc2.Address, 1)
+	assert.Nil(t, err)
+	sdk.SetPayer(tx, acc1.Address)
+	assert.Nil(t, sdk.SignToTransaction(tx, acc1))
+	assert.Nil(t, sdk.MultiSignToTransaction(tx, 2, pubKeys, acc2))
+	assert.NotNil(t, VerifyTransaction(tx))
+	assert.Nil(t, sdk.MultiSignToTransaction(tx, 2, pubKeys, acc3))
+	assert.Nil(t, VerifyTransaction(tx))
+	assert.Equal(t, 0, len(ValidateTransaction(tx)))
+}
+
+func TestSignMessage(t *testing.T) {
+	msg := []byte("hello")
+	acc := NewAccount()
+	sigData, err := SignMessage(acc, msg)
+	assert.Nil(t, err)
+	assert.Nil(t, VerifyMessage(acc.PublicKey, msg, sigData))
+	assert.Nil(t, acc.Verify(GetMessageHash(msg), sigData))
+	assert.NotNil(t, VerifySignature(acc.PublicKey, msg, sigData))
+	assert.NotNil(t, VerifyMessage(acc.PublicKey, []byte("hello!"), sigData))
+
+	rawSig, err := acc.Sign(msg)
+	assert.Nil(t, err)
+	assert.NotNil(t, VerifyMessage(acc.PublicKey, msg, rawSig))
+}
@@ -18,7 +18,6 @@
 package tep1
 
 import (
-	"encoding/hex"
 	"fmt"
 	"github.com/TesraSupernet/tesracrypto/keypair"
 	tesra_go_sdk "github.com/TesraSupernet/tesrasdk"
@@ -231,7 +230,7 @@ func (this *Tep1) parseTransferEvent(contractEvt *scomm.SmartContactEvent) []*Te
 	for _, notify := range contractEvt.Notify {
 		addr, _ := utils.AddressFromHexString(notify.ContractAddress)
 		if addr == this.ContractAddress {
-			selfEvt, err := parseTep1TransferEvent(notify)
+			selfEvt, err := this.sdk.ParseTep1TransferEvent(notify)
 			if err == nil {
 				result = append(result, selfEvt)
 			}
@@ -239,59 +238,3 @@ func (this *Tep1) parseTransferEvent(contractEvt *scomm.SmartContactEvent) []*Te
 	}
 	return result
 }
-
-func parseTep1TransferEvent(notify *scomm.NotifyEventInfo) (*Tep1TransferEvent, error) {
-	state, ok := notify.States.([]interface{})
-	if !ok {
-		return nil, fmt.Errorf("state.States is not []interface")
-	}
-	if len(state) != 4 {
-		return nil, fmt.Errorf("state length is not 4")
-	}
-	eventName, ok := state[0].(string)
-	if !ok {
-		return nil, fmt.Errorf("state.States[0] is not string")
-	}
-	from, ok := state[1].(string)
-	if !ok {
-		return nil, fmt.Errorf("state[1] is not string")
-	}
-	to, ok := state[2].(string)
-	if !ok {
-		return nil, fmt.Errorf("state[2] is not string")
-	}
-	amount, ok := state[3].(string)
-	if !ok {
-		return nil, fmt.Errorf("state[3] is not uint64")
-	}
-	evt, err := hex.DecodeString(eventName)
-	if err != nil {
-		return nil, fmt.Errorf("decode event name failed, err: %s", err)
-	}
-	fr, err := common.HexToBytes(from)
-	if err != nil {
-		return nil, fmt.Errorf("HexToBytes, err: %s", err)
-	}
-	fromAddr, err := utils.AddressParseFromBytes(fr)
-	if err != nil {
-		return nil, fmt.Errorf("decode from failed, err: %s", err)
-	}
-	toBs, err := common.HexToBytes(to)
-	if err != nil {
-		return nil, fmt.Errorf("HexToBytes, err: %s", err)
-	}
-	toAddr, err := utils.AddressParseFromBytes(toBs)
-	if err != nil {
-		return nil, fmt.Errorf("decode to failed, err: %s", err)
-	}
-	value, err := hex.DecodeString(amount)
-	if err != nil {
-		return nil, fmt.Errorf("decode value failed, err: %s", err)
-	}
-	return &Tep1TransferEvent{
-		Name:   string(evt),
-		From:   fromAddr,
-		To:     toAddr,
-		Amount: common.BigIntFromTeoBytes(value),
-	}, nil
-}
@@ -18,8 +18,8 @@
 package tep1
 
 import (
-	"fmt"
 	"github.com/TesraSupernet/Tesra/common"
+	tesra_go_sdk "github.com/TesraSupernet/tesrasdk"
 	"math/big"
 )
 
@@ -29,14 +29,4 @@ type State struct {
 	Amount *big.Int
 }
 
-type Tep1TransferEvent struct {
-	Name   string
-	From   common.Address
-	To     common.Address
-	Amount *big.Int
-}
-
-func (this *Tep1TransferEvent) String() string {
-	return fmt.Sprintf("name %s, from %s, to %s, amount %s", this.Name, this.From.ToBase58(), this.To.ToBase58(),
-		this.Amount.String())
-}
+type Tep1TransferEvent = tesra_go_sdk.Tep1TransferEvent
@@ -26,7 +26,7 @@ import (
 	"github.com/TesraSupernet/tesrasdk/bip44"
 	"github.com/TesraSupernet/Tesra/smartcontract/event"
 	"github.com/tyler-smith/go-bip39"
-	"io"
+	"math/big"
 	"math/rand"
 	"time"
 
@@ -91,240 +91,59 @@ func ParseNativeTxPayload(raw []byte) (map[string]interface{}, error) {
 	return ParsePayload(code)
 }
 
+//ParsePayload parse the native transfer and transferFrom invoke code
 func ParsePayload(code []byte) (map[string]interface{}, error) {
-	l := len(code)
-	if l > 44 && string(code[l-22:]) == "TesraSupernet.Native.Invoke" {
-		//46 = 22  "TesraSupernet.Native.Invoke"
-		// +1   length
-		// +1   SYSCALL
-		// +1   version
-		// +20  address
-		// +1   length
-		//TODO if version>15, there will be bug
-		if l > 54 && string(code[l-46-8:l-46]) == "transfer" {
-			param := make([]common3.StateInfo, 0)
-			source := common.NewZeroCopySource(code)
-			for {
-				zeroByte, eof := source.NextByte()
-				if eof {
-					return nil, io.ErrUnexpectedEOF
-				}
-				if zeroByte != 0 {
-					break
-				}
-				err := ignoreOpCode(source)
-				if err != nil {
-					return nil, err
-				}
-				from, err := readAddress(source)
-				if err != nil {
-					return nil, err
-				}
-				err = ignoreOpCode(source)
-				if err != nil {
-					return nil, err
-				}
-				to, err := readAddress(source)
-				if err != nil {
-					return nil, err
-				}
-				err = ignoreOpCode(source)
-				if err != nil {
-					return nil, err
-				}
-				amount, err := getValue(source)
-				if err != nil {
-					return nil, err
-				}
-				state := common3.StateInfo{
-					From:  from.ToBase58(),
-					To:    to.ToBase58(),
-					Value: amount,
-				}
-				param = append(param, state)
-				err = ignoreOpCode(source)
-				if err != nil {
-					return nil, err
-				}
-				var isend bool
-				if isend, err = isEnd(source); err != nil {
-					return nil, err
-				}
-				if isend {
-					break
-				}
-			}
-			err := ignoreOpCode(source)
-			if err != nil {
-				return nil, err
-			}
-			//method name
-			_, _, irregular, eof := source.NextVarBytes()
-			if irregular || eof {
-				return nil, io.ErrUnexpectedEOF
-			}
-			//contract address
-			contractAddress, err := readAddress(source)
-			if err != nil {
-				return nil, err
-			}
-			res := make(map[string]interface{})
-			res["functionName"] = "transfer"
-			res["contractAddress"] = contractAddress
-			res["param"] = param
-			if contractAddress == TSR_CONTRACT_ADDRESS {
-				res["asset"] = "tsr"
-			} else if contractAddress == TSG_CONTRACT_ADDRESS {
-				res["asset"] = "tsg"
-			}
-			return res, nil
-		} else if l > 58 && string(code[l-46-12:l-46]) == "transferFrom" {
-			source := common.NewZeroCopySource(code)
-			//ignore 00
-			_, eof := source.NextByte()
-			if eof {
-				return nil, io.ErrUnexpectedEOF
-			}
-			err := ignoreOpCode(source)
-			if err != nil {
-				return nil, err
-			}
-			sender, err := readAddress(source)
-			if err != nil {
-				return nil, err
-			}
-			err = ignoreOpCode(source)
-			if err != nil {
-				return nil, err
-			}
-			from, err := readAddress(source)
-			if err != nil {
-				return nil, err
-			}
-			err = ignoreOpCode(source)
-			if err != nil {
-				return nil, err
-			}
-			to, err := readAddress(source)
-			if err != nil {
-				return nil, err
-			}
-			err = ignoreOpCode(source)
-			if err != nil {
-				return nil, err
-			}
-			amount, err := getValue(source)
-			if err != nil {
-				return nil, err
-			}
-			tf := common3.TransferFromInfo{
-				Sender: sender.ToBase58(),
-				From:   from.ToBase58(),
-				To:     to.ToBase58(),
-				Value:  amount,
-			}
-			err = ignoreOpCode(source)
-			if err != nil {
-				return nil, err
-			}
-			//method name
-			_, _, irregular, eof := source.NextVarBytes()
-			if irregular || eof {
-				return nil, io.ErrUnexpectedEOF
-			}
-			//contract address
-			contractAddress, err := readAddress(source)
+	call, err := DecodeInvokeCode(code)
+	if err != nil || call.VMType != VM_TYPE_NATIVE {
+		return nil, fmt.Errorf("not native transfer and transferFrom transaction")
+	}
+	res := make(map[string]interface{})
+	switch call.Method {
+	case "transfer":
+		states := call.GetArg("states")
+		if states == nil {
+			return nil, fmt.Errorf("not native transfer and transferFrom transaction")
+		}
+		param := make([]common3.StateInfo, 0, len(states.Items))
+		for _, state := range states.Items {
+			value, err := state.GetItem("value").ToUint64()
 			if err != nil {
 				return nil, err
 			}
-			res := make(map[string]interface{})
-			res["functionName"] = "transferFrom"
-			res["contractAddress"] = contractAddress
-			res["param"] = tf
-			if contractAddress == TSR_CONTRACT_ADDRESS {
-				res["asset"] = "tsr"
-			} else if contractAddress == TSG_CONTRACT_ADDRESS {
-				res["asset"] = "tsg"
-			}
-			return res, nil
-		}
-	}
-	return nil, fmt.Errorf("not native transfer and transferFrom transaction")
-}
-func getValue(source *common.ZeroCopySource) (uint64, error) {
-	var amount = uint64(0)
-	zeroByte, eof := source.NextByte()
-	if eof {
-		return 0, io.ErrUnexpectedEOF
-	}
-
-	if zeroByte == 0 {
-		amount = 0
-	} else if zeroByte >= 0x51 && zeroByte <= 0x5f {
-		b := common.BigIntFromTeoBytes([]byte{zeroByte})
-		amount = b.Uint64() - 0x50
-	} else {
-		source.BackUp(1)
-		amountBytes, _, irregular, eof := source.NextVarBytes()
-		if irregular || eof {
-			return 0, io.ErrUnexpectedEOF
+			param = append(param, common3.StateInfo{
+				From:  state.GetItem("from").Value,
+				To:    state.GetItem("to").Value,
+				Value: value,
+			})
 		}
-		amount = common.BigIntFromTeoBytes(amountBytes).Uint64()
-	}
-	return amount, nil
-}
-func isEnd(source *common.ZeroCopySource) (bool, error) {
-	by, eof := source.NextByte()
-	if eof {
-		return true, io.EOF
-	}
-	if by == 0x00 || by >= 0x14 && by < 0x51 {
-		source.BackUp(1)
-		return false, nil
-	} else {
-		if by >= 0x51 && by <= 0x5f {
-			return true, nil
-		} else {
-			_, _, irregular, eof := source.NextVarUint()
-			if irregular || eof {
-				return true, io.ErrUnexpectedEOF
-			}
-			return true, nil
+		res["param"] = param
+	case "transferFrom":
+		value := call.GetArg("value")
+		if value == nil {
+			return nil, fmt.Errorf("not native transfer and transferFrom transaction")
 		}
-	}
-}
-
-func readAddress(source *common.ZeroCopySource) (common2.Address, error) {
-	senderBytes, _, irregular, eof := source.NextVarBytes()
-	if irregular || eof {
-		return common.ADDRESS_EMPTY, io.ErrUnexpectedEOF
-	}
-	sender, err := utils.AddressParseFromBytes(senderBytes)
-	if err != nil {
-		return common.ADDRESS_EMPTY, err
-	}
-	return sender, nil
-}
-func ignoreOpCode(source *common.ZeroCopySource) error {
-	s := source.Size()
-	start := source.Pos()
-	for {
-		if source.Pos() >= s {
-			return nil
-		}
-		by, eof := source.NextByte()
-		if eof {
-			return io.EOF
+		amount, err := value.ToUint64()
+		if err != nil {
+			return nil, err
 		}
-		if OPCODE_IN_PAYLOAD[by] {
-			continue
-		} else {
-			if start < source.Pos() {
-				source.BackUp(1)
-			}
-			return nil
+		res["param"] = common3.TransferFromInfo{
+			Sender: call.GetArg("sender").Value,
+			From:   call.GetArg("from").Value,
+			To:     call.GetArg("to").Value,
+			Value:  amount,
 		}
-	}
+	default:
+		return nil, fmt.Errorf("not native transfer and transferFrom transaction")
+	}
+	contractAddress := call.GetContractAddress()
+	res["functionName"] = call.Method
+	res["contractAddress"] = contractAddress
+	if contractAddress == TSR_CONTRACT_ADDRESS {
+		res["asset"] = "tsr"
+	} else if contractAddress == TSG_CONTRACT_ADDRESS {
+		res["asset"] = "tsg"
+	}
+	return res, nil
 }
 
 func (this *TesraSdk) GenerateMnemonicCodesStr() (string, error) {
@@ -335,6 +154,11 @@ func (this *TesraSdk) GenerateMnemonicCodesStr() (string, error) {
 	return bip39.NewMnemonic(entropy)
 }
 
+//GenerateMnemonic return a random mnemonic of wordCount(12, 15, 18, 21 or 24) words in lang, such as MNEMONIC_LANG_CHINESE_SIMPLIFIED
+func (this *TesraSdk) GenerateMnemonic(wordCount int, lang string) (string, error) {
+	return NewMnemonic(wordCount, lang)
+}
+
 func (this *TesraSdk) GetPrivateKeyFromMnemonicCodesStrBip44(mnemonicCodesStr string, index uint32) ([]byte, error) {
 	if mnemonicCodesStr == "" {
 		return nil, fmt.Errorf("mnemonicCodesStr should not be nil")
@@ -344,6 +168,23 @@ func (this *TesraSdk) GetPrivateKeyFromMnemonicCodesStrBip44(mnemonicCodesStr st
 		return nil, fmt.Errorf("index should be bigger than 0")
 	}
 	seed := bip39.NewSeed(mnemonicCodesStr, "")
+	return getPrivateKeyFromSeedBip44(seed, index)
+}
+
+//GetPrivateKeyFromMnemonicCodesStrBip44WithPassphrase return the private key of address index derived from mnemonic
+//with BIP39 passphrase. Mnemonic is validated before derivation, the error is *MnemonicError with typo suggestions
+func (this *TesraSdk) GetPrivateKeyFromMnemonicCodesStrBip44WithPassphrase(mnemonicCodesStr, passphrase string, index uint32) ([]byte, error) {
+	if mnemonicCodesStr == "" {
+		return nil, fmt.Errorf("mnemonicCodesStr should not be nil")
+	}
+	seed, err := MnemonicToSeed(mnemonicCodesStr, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return getPrivateKeyFromSeedBip44(seed, index)
+}
+
+func getPrivateKeyFromSeedBip44(seed []byte, index uint32) ([]byte, error) {
 	masterKey, err := bip32.NewMasterKey(seed)
 	if err != nil {
 		return nil, err
@@ -397,8 +238,7 @@ func (this *TesraSdk) SignToTransaction(tx *types.MutableTransaction, signer Sig
 			return nil
 		}
 	}
-	txHash := tx.Hash()
-	sigData, err := signer.Sign(txHash.ToArray())
+	sigData, err := signTransaction(tx, signer)
 	if err != nil {
 		return fmt.Errorf("sign error:%s", err)
 	}
@@ -439,7 +279,7 @@ func (this *TesraSdk) MultiSignToTransaction(tx *types.MutableTransaction, m uin
 	if len(tx.Sigs) == 0 {
 		tx.Sigs = make([]types.Sig, 0)
 	}
-	sigData, err := signer.Sign(txHash.ToArray())
+	sigData, err := signTransaction(tx, signer)
 	if err != nil {
 		return fmt.Errorf("sign error:%s", err)
 	}
@@ -522,6 +362,78 @@ func (this *TesraSdk) ParseNaitveTransferEvent(event *event.NotifyEventInfo) (*T
 	}
 }
 
+type Tep1TransferEvent struct {
+	Name   string
+	From   common.Address
+	To     common.Address
+	Amount *big.Int
+}
+
+func (this *Tep1TransferEvent) String() string {
+	return fmt.Sprintf("name %s, from %s, to %s, amount %s", this.Name, this.From.ToBase58(), this.To.ToBase58(),
+		this.Amount.String())
+}
+
+//ParseTep1TransferEvent parse the transfer event notified by TEP1 contract, event name, addresses and amount are in hex
+func (this *TesraSdk) ParseTep1TransferEvent(notify *common3.NotifyEventInfo) (*Tep1TransferEvent, error) {
+	if notify == nil {
+		return nil, fmt.Errorf("event is nil")
+	}
+	state, ok := notify.States.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("state.States is not []interface")
+	}
+	if len(state) != 4 {
+		return nil, fmt.Errorf("state length is not 4")
+	}
+	eventName, ok := state[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("state.States[0] is not string")
+	}
+	from, ok := state[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("state[1] is not string")
+	}
+	to, ok := state[2].(string)
+	if !ok {
+		return nil, fmt.Errorf("state[2] is not string")
+	}
+	amount, ok := state[3].(string)
+	if !ok {
+		return nil, fmt.Errorf("state[3] is not string")
+	}
+	evt, err := hex.DecodeString(eventName)
+	if err != nil {
+		return nil, fmt.Errorf("decode event name failed, err: %s", err)
+	}
+	fr, err := common.HexToBytes(from)
+	if err != nil {
+		return nil, fmt.Errorf("HexToBytes, err: %s", err)
+	}
+	fromAddr, err := utils.AddressParseFromBytes(fr)
+	if err != nil {
+		return nil, fmt.Errorf("decode from failed, err: %s", err)
+	}
+	toBs, err := common.HexToBytes(to)
+	if err != nil {
+		return nil, fmt.Errorf("HexToBytes, err: %s", err)
+	}
+	toAddr, err := utils.AddressParseFromBytes(toBs)
+	if err != nil {
+		return nil, fmt.Errorf("decode to failed, err: %s", err)
+	}
+	value, err := hex.DecodeString(amount)
+	if err != nil {
+		return nil, fmt.Errorf("decode value failed, err: %s", err)
+	}
+	return &Tep1TransferEvent{
+		Name:   string(evt),
+		From:   fromAddr,
+		To:     toAddr,
+		Amount: common.BigIntFromTeoBytes(value),
+	}, nil
+}
+
 func (this *TesraSdk) GetMutableTx(rawTx string) (*types.MutableTransaction, error) {
 	txData, err := hex.DecodeString(rawTx)
 	if err != nil {
@@ -0,0 +1,969 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/core/payload"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	VM_TYPE_NATIVE = "native"
+	VM_TYPE_TEOVM  = "teovm"
+	VM_TYPE_WASMVM = "wasmvm"
+)
+
+const (
+	ARG_TYPE_ADDRESS = "address"
+	ARG_TYPE_INTEGER = "integer"
+	ARG_TYPE_STRING  = "string"
+	ARG_TYPE_BYTES   = "bytes"
+	ARG_TYPE_BOOL    = "bool"
+	ARG_TYPE_PUBKEY  = "pubkey"
+	ARG_TYPE_HASH    = "hash"
+	ARG_TYPE_ARRAY   = "array"
+	ARG_TYPE_STRUCT  = "struct"
+	ARG_TYPE_MAP     = "map"
+)
+
+//DecodedArg is a decoded argument of contract invoke. Value is the text form of scalar
+//argument: base58 for address, decimal for integer, hex for bytes, pubkey and hash.
+//Items is the elements of array, struct and map (map items are key-value structs).
+type DecodedArg struct {
+	Name  string        `json:"name,omitempty"`
+	Type  string        `json:"type"`
+	Value string        `json:"value,omitempty"`
+	Items []*DecodedArg `json:"items,omitempty"`
+}
+
+//ToAddress return the value of address argument
+func (this *DecodedArg) ToAddress() (common.Address, error) {
+	if this.Type != ARG_TYPE_ADDRESS {
+		return common.ADDRESS_EMPTY, fmt.Errorf("arg type:%s is not %s", this.Type, ARG_TYPE_ADDRESS)
+	}
+	return common.AddressFromBase58(this.Value)
+}
+
+//ToInteger return the value of integer argument
+func (this *DecodedArg) ToInteger() (*big.Int, error) {
+	if this.Type != ARG_TYPE_INTEGER {
+		return nil, fmt.Errorf("arg type:%s is not %s", this.Type, ARG_TYPE_INTEGER)
+	}
+	value, ok := new(big.Int).SetString(this.Value, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer:%s", this.Value)
+	}
+	return value, nil
+}
+
+//ToUint64 return the value of integer argument as uint64
+func (this *DecodedArg) ToUint64() (uint64, error) {
+	value, err := this.ToInteger()
+	if err != nil {
+		return 0, err
+	}
+	if value.Sign() < 0 || !value.IsUint64() {
+		return 0, fmt.Errorf("integer:%s out of uint64 range", this.Value)
+	}
+	return value.Uint64(), nil
+}
+
+//ToBytes return the raw bytes of bytes, pubkey and string argument
+func (this *DecodedArg) ToBytes() ([]byte, error) {
+	switch this.Type {
+	case ARG_TYPE_BYTES, ARG_TYPE_PUBKEY:
+		return hex.DecodeString(this.Value)
+	case ARG_TYPE_STRING:
+		return []byte(this.Value), nil
+	default:
+		return nil, fmt.Errorf("arg type:%s cannot convert to bytes", this.Type)
+	}
+}
+
+//GetItem return the item of struct argument by name
+func (this *DecodedArg) GetItem(name string) *DecodedArg {
+	for _, item := range this.Items {
+		if item.Name == name {
+			return item
+		}
+	}
+	return nil
+}
+
+//InvokeCall is the decoded call description of an InvokeCode payload
+type InvokeCall struct {
+	VMType       string        `json:"vmType"`
+	Contract     string        `json:"contract"`
+	ContractName string        `json:"contractName,omitempty"`
+	Version      byte          `json:"version,omitempty"`
+	Method       string        `json:"method"`
+	Args         []*DecodedArg `json:"args"`
+	ArgsDecoded  bool          `json:"argsDecoded"` //whether args are decoded by the known args of method, with names
+	contract     common.Address
+}
+
+//GetContractAddress return the address of invoked contract
+func (this *InvokeCall) GetContractAddress() common.Address {
+	return this.contract
+}
+
+//GetArg return the argument by name
+func (this *InvokeCall) GetArg(name string) *DecodedArg {
+	for _, arg := range this.Args {
+		if arg.Name == name {
+			return arg
+		}
+	}
+	return nil
+}
+
+//DeployContract is the decoded description of a DeployCode payload
+type DeployContract struct {
+	VMType      string `json:"vmType"`
+	Address     string `json:"address"`
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Author      string `json:"author"`
+	Email       string `json:"email"`
+	Description string `json:"description"`
+	CodeSize    int    `json:"codeSize"`
+}
+
+//DecodedPayload is the decoded payload of transaction, only one of Invoke and Deploy is set
+type DecodedPayload struct {
+	Invoke *InvokeCall     `json:"invoke,omitempty"`
+	Deploy *DeployContract `json:"deploy,omitempty"`
+}
+
+//DecodeTransaction decode the payload of tx
+func DecodeTransaction(tx *types.MutableTransaction) (*DecodedPayload, error) {
+	if tx == nil {
+		return nil, fmt.Errorf("tx should not be nil")
+	}
+	return DecodePayload(tx.TxType, tx.Payload)
+}
+
+//DecodePayload decode the payload of transaction by transaction type.
+//Native and TeoVM invoke share the same tx type, and are distinguished by the code itself.
+func DecodePayload(txType types.TransactionType, pl interface{}) (*DecodedPayload, error) {
+	switch p := pl.(type) {
+	case *payload.InvokeCode:
+		var call *InvokeCall
+		var err error
+		if txType == types.InvokeWasm {
+			call, err = DecodeWasmVMInvokeCode(p.Code)
+		} else {
+			call, err = DecodeInvokeCode(p.Code)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &DecodedPayload{Invoke: call}, nil
+	case *payload.DeployCode:
+		return &DecodedPayload{Deploy: DecodeDeployCode(p)}, nil
+	default:
+		return nil, fmt.Errorf("unsupport payload type:%T", pl)
+	}
+}
+
+//DecodeDeployCode decode DeployCode payload
+func DecodeDeployCode(deploy *payload.DeployCode) *DeployContract {
+	code := deploy.GetRawCode()
+	vmType := VM_TYPE_TEOVM
+	if bytes.HasPrefix(code, []byte("\x00asm")) {
+		vmType = VM_TYPE_WASMVM
+	}
+	return &DeployContract{
+		VMType:      vmType,
+		Address:     common.AddressFromVmCode(code).ToHexString(),
+		Name:        deploy.Name,
+		Version:     deploy.Version,
+		Author:      deploy.Author,
+		Email:       deploy.Email,
+		Description: deploy.Description,
+		CodeSize:    len(code),
+	}
+}
+
+//DecodeInvokeCode decode native or TeoVM invoke code
+func DecodeInvokeCode(code []byte) (*InvokeCall, error) {
+	vm := newTeoVMDecoder(code)
+	err := vm.run()
+	if err != nil {
+		return nil, err
+	}
+	if vm.call == nil {
+		return nil, fmt.Errorf("no contract invoke in code")
+	}
+	return vm.call, nil
+}
+
+//DecodeWasmVMInvokeCode decode WasmVM invoke code. WasmVM args are not self-describing, so
+//only the method name is decoded and the rest args are kept as raw bytes, which can be decoded
+//by DecodeWasmVMArgs with the argument types of contract.
+func DecodeWasmVMInvokeCode(code []byte) (*InvokeCall, error) {
+	source := common.NewZeroCopySource(code)
+	addrData, eof := source.NextBytes(common.ADDR_LEN)
+	if eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+	contract, err := common.AddressParseFromBytes(addrData)
+	if err != nil {
+		return nil, err
+	}
+	args, _, irregular, eof := source.NextVarBytes()
+	if irregular || eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+	argSource := common.NewZeroCopySource(args)
+	method, _, irregular, eof := argSource.NextVarBytes()
+	if irregular || eof {
+		return nil, fmt.Errorf("read wasmvm method name error")
+	}
+	call := &InvokeCall{
+		VMType:   VM_TYPE_WASMVM,
+		Contract: contract.ToHexString(),
+		Method:   string(method),
+		Args:     make([]*DecodedArg, 0),
+		contract: contract,
+	}
+	rest := args[argSource.Pos():]
+	if len(rest) > 0 {
+		call.Args = append(call.Args, &DecodedArg{Name: "rawArgs", Type: ARG_TYPE_BYTES, Value: hex.EncodeToString(rest)})
+	}
+	return call, nil
+}
+
+//DecodeWasmVMArgs decode the raw args of WasmVM invoke by argTypes. Supported types are
+//string, bytes, address, hash, bool, byte, u16, u32, i128 and u128.
+func DecodeWasmVMArgs(rawArgs []byte, argTypes []string) ([]*DecodedArg, error) {
+	source := common.NewZeroCopySource(rawArgs)
+	args := make([]*DecodedArg, 0, len(argTypes))
+	for i, argType := range argTypes {
+		arg := &DecodedArg{}
+		switch argType {
+		case "string":
+			data, _, irregular, eof := source.NextVarBytes()
+			if irregular || eof {
+				return nil, fmt.Errorf("read arg %d as string error", i)
+			}
+			arg.Type, arg.Value = ARG_TYPE_STRING, string(data)
+		case "bytes":
+			data, _, irregular, eof := source.NextVarBytes()
+			if irregular || eof {
+				return nil, fmt.Errorf("read arg %d as bytes error", i)
+			}
+			arg.Type, arg.Value = ARG_TYPE_BYTES, hex.EncodeToString(data)
+		case "address":
+			data, eof := source.NextBytes(common.ADDR_LEN)
+			if eof {
+				return nil, fmt.Errorf("read arg %d as address error", i)
+			}
+			addr, _ := common.AddressParseFromBytes(data)
+			arg.Type, arg.Value = ARG_TYPE_ADDRESS, addr.ToBase58()
+		case "hash":
+			data, eof := source.NextBytes(common.UINT256_SIZE)
+			if eof {
+				return nil, fmt.Errorf("read arg %d as hash error", i)
+			}
+			hash, _ := common.Uint256ParseFromBytes(data)
+			arg.Type, arg.Value = ARG_TYPE_HASH, hash.ToHexString()
+		case "bool", "byte":
+			b, eof := source.NextByte()
+			if eof {
+				return nil, fmt.Errorf("read arg %d as %s error", i, argType)
+			}
+			if argType == "bool" {
+				arg.Type, arg.Value = ARG_TYPE_BOOL, strconv.FormatBool(b != 0)
+			} else {
+				arg.Type, arg.Value = ARG_TYPE_INTEGER, strconv.Itoa(int(b))
+			}
+		case "u16", "u32":
+			size := uint64(2)
+			if argType == "u32" {
+				size = 4
+			}
+			data, eof := source.NextBytes(size)
+			if eof {
+				return nil, fmt.Errorf("read arg %d as %s error", i, argType)
+			}
+			value := uint64(0)
+			for j := len(data) - 1; j >= 0; j-- {
+				value = value<<8 | uint64(data[j])
+			}
+			arg.Type, arg.Value = ARG_TYPE_INTEGER, strconv.FormatUint(value, 10)
+		case "i128", "u128":
+			data, eof := source.NextBytes(16)
+			if eof {
+				return nil, fmt.Errorf("read arg %d as %s error", i, argType)
+			}
+			value := new(big.Int).SetUint64(binary.LittleEndian.Uint64(data[8:]))
+			value.Lsh(value, 64)
+			value.Or(value, new(big.Int).SetUint64(binary.LittleEndian.Uint64(data[:8])))
+			if argType == "i128" && data[15]&0x80 != 0 {
+				value.Sub(value, new(big.Int).Lsh(big.NewInt(1), 128))
+			}
+			arg.Type, arg.Value = ARG_TYPE_INTEGER, value.String()
+		default:
+			return nil, fmt.Errorf("unsupport wasmvm arg type:%s", argType)
+		}
+		args = append(args, arg)
+	}
+	if source.Len() != 0 {
+		return nil, fmt.Errorf("%d bytes left after decode wasmvm args", source.Len())
+	}
+	return args, nil
+}
+
+const (
+	vm_value_bytes   = 0
+	vm_value_integer = 1
+	vm_value_array   = 2
+	vm_value_struct  = 3
+	vm_value_map     = 4
+)
+
+//vmValue is the value on the stack of teoVMDecoder
+type vmValue struct {
+	kind  int
+	data  []byte
+	value *big.Int
+	items []*vmValue
+	keys  []*vmValue
+}
+
+func (this *vmValue) toInteger() (*big.Int, error) {
+	switch this.kind {
+	case vm_value_integer:
+		return this.value, nil
+	case vm_value_bytes:
+		return common.BigIntFromTeoBytes(this.data), nil
+	default:
+		return nil, fmt.Errorf("value is not integer")
+	}
+}
+
+func (this *vmValue) toBytes() ([]byte, error) {
+	switch this.kind {
+	case vm_value_bytes:
+		return this.data, nil
+	case vm_value_integer:
+		return common.BigIntToTeoBytes(this.value), nil
+	default:
+		return nil, fmt.Errorf("value is not byte array")
+	}
+}
+
+const native_invoke_suffix = ".Native.Invoke"
+
+//teoVMDecoder evaluates the TeoVM opcodes used by invoke code builders, to recover the
+//arguments pushed before SYSCALL of native invoke or APPCALL of TeoVM contract
+type teoVMDecoder struct {
+	source   *common.ZeroCopySource
+	stack    []*vmValue
+	altStack []*vmValue
+	call     *InvokeCall
+}
+
+func newTeoVMDecoder(code []byte) *teoVMDecoder {
+	return &teoVMDecoder{
+		source:   common.NewZeroCopySource(code),
+		stack:    make([]*vmValue, 0),
+		altStack: make([]*vmValue, 0),
+	}
+}
+
+func (this *teoVMDecoder) push(v *vmValue) {
+	this.stack = append(this.stack, v)
+}
+
+func (this *teoVMDecoder) pop() (*vmValue, error) {
+	size := len(this.stack)
+	if size == 0 {
+		return nil, fmt.Errorf("stack underflow")
+	}
+	v := this.stack[size-1]
+	this.stack = this.stack[:size-1]
+	return v, nil
+}
+
+func (this *teoVMDecoder) popInt() (int, error) {
+	v, err := this.pop()
+	if err != nil {
+		return 0, err
+	}
+	n, err := v.toInteger()
+	if err != nil {
+		return 0, err
+	}
+	if !n.IsInt64() || n.Int64() < 0 || n.Int64() > int64(len(this.stack)+1024) {
+		return 0, fmt.Errorf("invalid count:%s", n.String())
+	}
+	return int(n.Int64()), nil
+}
+
+func (this *teoVMDecoder) run() error {
+	for this.source.Len() > 0 {
+		if this.call != nil {
+			return fmt.Errorf("unexpected code after contract invoke")
+		}
+		op, _ := this.source.NextByte()
+		switch {
+		case op == 0x00: //PUSH0
+			this.push(&vmValue{kind: vm_value_bytes, data: []byte{}})
+		case op >= 0x01 && op <= 0x4B: //PUSHBYTES1-75
+			data, eof := this.source.NextBytes(uint64(op))
+			if eof {
+				return io.ErrUnexpectedEOF
+			}
+			this.push(&vmValue{kind: vm_value_bytes, data: data})
+		case op >= 0x4C && op <= 0x4E: //PUSHDATA1, PUSHDATA2, PUSHDATA4
+			sizeLen := uint64(1) << (op - 0x4C)
+			sizeData, eof := this.source.NextBytes(sizeLen)
+			if eof {
+				return io.ErrUnexpectedEOF
+			}
+			size := uint64(0)
+			for i := len(sizeData) - 1; i >= 0; i-- {
+				size = size<<8 | uint64(sizeData[i])
+			}
+			data, eof := this.source.NextBytes(size)
+			if eof {
+				return io.ErrUnexpectedEOF
+			}
+			this.push(&vmValue{kind: vm_value_bytes, data: data})
+		case op == 0x4F: //PUSHM1
+			this.push(&vmValue{kind: vm_value_integer, value: big.NewInt(-1)})
+		case op >= 0x51 && op <= 0x60: //PUSH1-16
+			this.push(&vmValue{kind: vm_value_integer, value: big.NewInt(int64(op - 0x50))})
+		case op == 0x61: //NOP
+		case op == 0x66: //RET
+			return nil
+		case op == 0x67 || op == 0x69: //APPCALL, TAILCALL
+			err := this.appCall()
+			if err != nil {
+				return err
+			}
+		case op == 0x68: //SYSCALL
+			err := this.sysCall()
+			if err != nil {
+				return err
+			}
+		case op == 0x6A: //DUPFROMALTSTACK
+			size := len(this.altStack)
+			if size == 0 {
+				return fmt.Errorf("alt stack underflow")
+			}
+			this.push(this.altStack[size-1])
+		case op == 0x6B: //TOALTSTACK
+			v, err := this.pop()
+			if err != nil {
+				return err
+			}
+			this.altStack = append(this.altStack, v)
+		case op == 0x6C: //FROMALTSTACK
+			size := len(this.altStack)
+			if size == 0 {
+				return fmt.Errorf("alt stack underflow")
+			}
+			this.push(this.altStack[size-1])
+			this.altStack = this.altStack[:size-1]
+		case op == 0x75: //DROP
+			_, err := this.pop()
+			if err != nil {
+				return err
+			}
+		case op == 0x76: //DUP
+			size := len(this.stack)
+			if size == 0 {
+				return fmt.Errorf("stack underflow")
+			}
+			this.push(this.stack[size-1])
+		case op == 0x7C: //SWAP
+			size := len(this.stack)
+			if size < 2 {
+				return fmt.Errorf("stack underflow")
+			}
+			this.stack[size-1], this.stack[size-2] = this.stack[size-2], this.stack[size-1]
+		case op == 0xC1: //PACK
+			n, err := this.popInt()
+			if err != nil {
+				return err
+			}
+			items := make([]*vmValue, 0, n)
+			for i := 0; i < n; i++ {
+				v, err := this.pop()
+				if err != nil {
+					return err
+				}
+				items = append(items, v)
+			}
+			this.push(&vmValue{kind: vm_value_array, items: items})
+		case op == 0xC4: //SETITEM
+			value, err := this.pop()
+			if err != nil {
+				return err
+			}
+			key, err := this.pop()
+			if err != nil {
+				return err
+			}
+			m, err := this.pop()
+			if err != nil {
+				return err
+			}
+			if m.kind != vm_value_map {
+				return fmt.Errorf("SETITEM on non map value")
+			}
+			m.keys = append(m.keys, key)
+			m.items = append(m.items, value)
+		case op == 0xC5 || op == 0xC6: //NEWARRAY, NEWSTRUCT
+			n, err := this.popInt()
+			if err != nil {
+				return err
+			}
+			kind := vm_value_array
+			if op == 0xC6 {
+				kind = vm_value_struct
+			}
+			items := make([]*vmValue, 0, n)
+			for i := 0; i < n; i++ {
+				items = append(items, &vmValue{kind: vm_value_integer, value: big.NewInt(0)})
+			}
+			this.push(&vmValue{kind: kind, items: items})
+		case op == 0xC7: //NEWMAP
+			this.push(&vmValue{kind: vm_value_map})
+		case op == 0xC8: //APPEND
+			item, err := this.pop()
+			if err != nil {
+				return err
+			}
+			arr, err := this.pop()
+			if err != nil {
+				return err
+			}
+			if arr.kind != vm_value_array && arr.kind != vm_value_struct {
+				return fmt.Errorf("APPEND on non array value")
+			}
+			arr.items = append(arr.items, item)
+		default:
+			return fmt.Errorf("unsupport opcode:0x%02x at position:%d", op, this.source.Pos()-1)
+		}
+	}
+	return nil
+}
+
+//sysCall decode native invoke: args..., method, contract address, version, SYSCALL "*.Native.Invoke"
+func (this *teoVMDecoder) sysCall() error {
+	name, _, irregular, eof := this.source.NextVarBytes()
+	if irregular || eof {
+		return io.ErrUnexpectedEOF
+	}
+	if !strings.HasSuffix(string(name), native_invoke_suffix) {
+		return fmt.Errorf("unsupport syscall:%s", name)
+	}
+	version, err := this.popInt()
+	if err != nil {
+		return fmt.Errorf("read native contract version error:%s", err)
+	}
+	addrValue, err := this.pop()
+	if err != nil {
+		return err
+	}
+	addrData, err := addrValue.toBytes()
+	if err != nil {
+		return fmt.Errorf("read native contract address error:%s", err)
+	}
+	contract, err := common.AddressParseFromBytes(addrData)
+	if err != nil {
+		return fmt.Errorf("read native contract address error:%s", err)
+	}
+	methodValue, err := this.pop()
+	if err != nil {
+		return err
+	}
+	method, err := methodValue.toBytes()
+	if err != nil {
+		return fmt.Errorf("read native method error:%s", err)
+	}
+	params := this.popAll()
+	call := &InvokeCall{
+		VMType:       VM_TYPE_NATIVE,
+		Contract:     contract.ToHexString(),
+		ContractName: nativeContractNames[contract],
+		Version:      byte(version),
+		Method:       string(method),
+		contract:     contract,
+	}
+	specs, ok := nativeMethodSpecs[contract][call.Method]
+	call.Args, call.ArgsDecoded = decodeArgs(params, specs, ok)
+	this.call = call
+	return nil
+}
+
+//appCall decode TeoVM invoke: args array, method, APPCALL contract address
+func (this *teoVMDecoder) appCall() error {
+	addrData, eof := this.source.NextBytes(common.ADDR_LEN)
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	if bytes.Equal(addrData, common.ADDRESS_EMPTY[:]) {
+		//dynamic call, contract address is on the stack
+		addrValue, err := this.pop()
+		if err != nil {
+			return err
+		}
+		addrData, err = addrValue.toBytes()
+		if err != nil {
+			return fmt.Errorf("read contract address error:%s", err)
+		}
+	}
+	contract, err := common.AddressParseFromBytes(addrData)
+	if err != nil {
+		return fmt.Errorf("read contract address error:%s", err)
+	}
+	params := this.popAll()
+	call := &InvokeCall{
+		VMType:   VM_TYPE_TEOVM,
+		Contract: contract.ToHexString(),
+		contract: contract,
+	}
+	if len(params) > 0 && params[0].kind == vm_value_bytes {
+		call.Method = string(params[0].data)
+		params = params[1:]
+		//TeoVM contract args are packed into one array after method name
+		if len(params) == 1 && params[0].kind == vm_value_array {
+			params = params[0].items
+		}
+	}
+	specs, ok := teoVMMethodSpecs[call.Method]
+	call.Args, call.ArgsDecoded = decodeArgs(params, specs, ok && len(specs) == len(params))
+	this.call = call
+	return nil
+}
+
+//popAll pop all of the values on stack, the first one is the top of stack
+func (this *teoVMDecoder) popAll() []*vmValue {
+	params := make([]*vmValue, 0, len(this.stack))
+	for i := len(this.stack) - 1; i >= 0; i-- {
+		params = append(params, this.stack[i])
+	}
+	this.stack = this.stack[:0]
+	return params
+}
+
+//argSpec describe the name and type of contract argument
+type argSpec struct {
+	name   string
+	typ    string
+	fields []*argSpec
+	elem   *argSpec
+}
+
+func arg(name, typ string) *argSpec {
+	return &argSpec{name: name, typ: typ}
+}
+
+func structArg(name string, fields ...*argSpec) *argSpec {
+	return &argSpec{name: name, typ: ARG_TYPE_STRUCT, fields: fields}
+}
+
+func arrayArg(name string, elem *argSpec) *argSpec {
+	return &argSpec{name: name, typ: ARG_TYPE_ARRAY, elem: elem}
+}
+
+//decodeArgs decode params by specs. Params may be passed one by one, or wrapped in one struct.
+//Fallback to generic decoding if params do not match specs, and return false.
+func decodeArgs(params []*vmValue, specs []*argSpec, hasSpec bool) ([]*DecodedArg, bool) {
+	if hasSpec {
+		if len(specs) == 0 {
+			//native method without params is invoked with an empty byte array
+			return make([]*DecodedArg, 0), true
+		}
+		values := params
+		if len(params) == 1 && len(specs) > 1 && (params[0].kind == vm_value_struct || params[0].kind == vm_value_array) {
+			values = params[0].items
+		}
+		if len(values) == len(specs) {
+			args := make([]*DecodedArg, 0, len(specs))
+			for i, spec := range specs {
+				arg, err := decodeValue(values[i], spec)
+				if err != nil {
+					args = nil
+					break
+				}
+				args = append(args, arg)
+			}
+			if args != nil {
+				return args, true
+			}
+		}
+	}
+	args := make([]*DecodedArg, 0, len(params))
+	for _, p := range params {
+		args = append(args, decodeGenericValue(p))
+	}
+	return args, false
+}
+
+func decodeValue(v *vmValue, spec *argSpec) (*DecodedArg, error) {
+	arg := &DecodedArg{Name: spec.name, Type: spec.typ}
+	switch spec.typ {
+	case ARG_TYPE_ADDRESS:
+		data, err := v.toBytes()
+		if err != nil {
+			return nil, err
+		}
+		addr, err := common.AddressParseFromBytes(data)
+		if err != nil {
+			return nil, err
+		}
+		arg.Value = addr.ToBase58()
+	case ARG_TYPE_INTEGER:
+		n, err := v.toInteger()
+		if err != nil {
+			return nil, err
+		}
+		arg.Value = n.String()
+	case ARG_TYPE_BOOL:
+		n, err := v.toInteger()
+		if err != nil {
+			return nil, err
+		}
+		arg.Value = strconv.FormatBool(n.Sign() != 0)
+	case ARG_TYPE_STRING:
+		data, err := v.toBytes()
+		if err != nil {
+			return nil, err
+		}
+		arg.Value = string(data)
+	case ARG_TYPE_BYTES, ARG_TYPE_PUBKEY, ARG_TYPE_HASH:
+		data, err := v.toBytes()
+		if err != nil {
+			return nil, err
+		}
+		arg.Value = hex.EncodeToString(data)
+	case ARG_TYPE_STRUCT:
+		if v.kind != vm_value_struct && v.kind != vm_value_array || len(v.items) != len(spec.fields) {
+			return nil, fmt.Errorf("value is not struct of %d fields", len(spec.fields))
+		}
+		arg.Items = make([]*DecodedArg, 0, len(spec.fields))
+		for i, field := range spec.fields {
+			item, err := decodeValue(v.items[i], field)
+			if err != nil {
+				return nil, err
+			}
+			arg.Items = append(arg.Items, item)
+		}
+	case ARG_TYPE_ARRAY:
+		if v.kind == vm_value_bytes && len(v.data) == 0 {
+			//empty array may be pushed as PUSH0
+			arg.Items = make([]*DecodedArg, 0)
+			break
+		}
+		if v.kind != vm_value_array {
+			return nil, fmt.Errorf("value is not array")
+		}
+		arg.Items = make([]*DecodedArg, 0, len(v.items))
+		for _, it := range v.items {
+			item, err := decodeValue(it, spec.elem)
+			if err != nil {
+				return nil, err
+			}
+			arg.Items = append(arg.Items, item)
+		}
+	default:
+		return nil, fmt.Errorf("unknown arg type:%s", spec.typ)
+	}
+	return arg, nil
+}
+
+func decodeGenericValue(v *vmValue) *DecodedArg {
+	switch v.kind {
+	case vm_value_integer:
+		return &DecodedArg{Type: ARG_TYPE_INTEGER, Value: v.value.String()}
+	case vm_value_array, vm_value_struct:
+		arg := &DecodedArg{Type: ARG_TYPE_ARRAY, Items: make([]*DecodedArg, 0, len(v.items))}
+		if v.kind == vm_value_struct {
+			arg.Type = ARG_TYPE_STRUCT
+		}
+		for _, item := range v.items {
+			arg.Items = append(arg.Items, decodeGenericValue(item))
+		}
+		return arg
+	case vm_value_map:
+		arg := &DecodedArg{Type: ARG_TYPE_MAP, Items: make([]*DecodedArg, 0, len(v.items))}
+		for i := range v.items {
+			key := decodeGenericValue(v.keys[i])
+			key.Name = "key"
+			value := decodeGenericValue(v.items[i])
+			value.Name = "value"
+			arg.Items = append(arg.Items, &DecodedArg{Type: ARG_TYPE_STRUCT, Items: []*DecodedArg{key, value}})
+		}
+		return arg
+	default:
+		return &DecodedArg{Type: ARG_TYPE_BYTES, Value: hex.EncodeToString(v.data)}
+	}
+}
+
+var nativeContractNames = map[common.Address]string{
+	TSR_CONTRACT_ADDRESS:           "TSR",
+	TSG_CONTRACT_ADDRESS:           "TSG",
+	TSR_ID_CONTRACT_ADDRESS:        "TsrId",
+	GLOABL_PARAMS_CONTRACT_ADDRESS: "GlobalParams",
+	AUTH_CONTRACT_ADDRESS:          "Auth",
+	GOVERNANCE_CONTRACT_ADDRESS:    "Governance",
+}
+
+var tokenMethodSpecs = map[string][]*argSpec{
+	"transfer": {arrayArg("states", structArg("",
+		arg("from", ARG_TYPE_ADDRESS), arg("to", ARG_TYPE_ADDRESS), arg("value", ARG_TYPE_INTEGER)))},
+	"transferFrom": {arg("sender", ARG_TYPE_ADDRESS), arg("from", ARG_TYPE_ADDRESS), arg("to", ARG_TYPE_ADDRESS),
+		arg("value", ARG_TYPE_INTEGER)},
+	"approve":     {arg("from", ARG_TYPE_ADDRESS), arg("to", ARG_TYPE_ADDRESS), arg("value", ARG_TYPE_INTEGER)},
+	"allowance":   {arg("from", ARG_TYPE_ADDRESS), arg("to", ARG_TYPE_ADDRESS)},
+	"balanceOf":   {arg("address", ARG_TYPE_ADDRESS)},
+	"name":        {},
+	"symbol":      {},
+	"decimals":    {},
+	"totalSupply": {},
+}
+
+var ddoAttributeSpec = structArg("", arg("key", ARG_TYPE_STRING), arg("valueType", ARG_TYPE_STRING),
+	arg("value", ARG_TYPE_BYTES))
+
+var tsrIdMethodSpecs = map[string][]*argSpec{
+	"regIDWithPublicKey": {arg("tsrId", ARG_TYPE_STRING), arg("pubKey", ARG_TYPE_PUBKEY)},
+	"regIDWithAttributes": {arg("tsrId", ARG_TYPE_STRING), arg("pubKey", ARG_TYPE_PUBKEY),
+		arrayArg("attributes", ddoAttributeSpec)},
+	"addKey":    {arg("tsrId", ARG_TYPE_STRING), arg("newPubKey", ARG_TYPE_PUBKEY), arg("pubKey", ARG_TYPE_PUBKEY)},
+	"removeKey": {arg("tsrId", ARG_TYPE_STRING), arg("removedKey", ARG_TYPE_PUBKEY), arg("pubKey", ARG_TYPE_PUBKEY)},
+	"addRecovery": {arg("tsrId", ARG_TYPE_STRING), arg("recovery", ARG_TYPE_ADDRESS),
+		arg("pubKey", ARG_TYPE_PUBKEY)},
+	"changeRecovery": {arg("tsrId", ARG_TYPE_STRING), arg("newRecovery", ARG_TYPE_ADDRESS),
+		arg("oldRecovery", ARG_TYPE_ADDRESS)},
+	"addAttributes": {arg("tsrId", ARG_TYPE_STRING), arrayArg("attributes", ddoAttributeSpec),
+		arg("pubKey", ARG_TYPE_PUBKEY)},
+	"removeAttribute": {arg("tsrId", ARG_TYPE_STRING), arg("key", ARG_TYPE_STRING), arg("pubKey", ARG_TYPE_PUBKEY)},
+	"verifySignature": {arg("tsrId", ARG_TYPE_STRING), arg("keyIndex", ARG_TYPE_INTEGER)},
+	"getKeyState":     {arg("tsrId", ARG_TYPE_STRING), arg("keyIndex", ARG_TYPE_INTEGER)},
+	"getDDO":          {arg("tsrId", ARG_TYPE_STRING)},
+	"getAttributes":   {arg("tsrId", ARG_TYPE_STRING)},
+	"getPublicKeys":   {arg("tsrId", ARG_TYPE_STRING)},
+}
+
+var globalParamsMethodSpecs = map[string][]*argSpec{
+	"setGlobalParam": {arrayArg("params", structArg("", arg("key", ARG_TYPE_STRING), arg("value", ARG_TYPE_STRING)))},
+	"getGlobalParam": {arrayArg("names", arg("", ARG_TYPE_STRING))},
+	"transferAdmin":  {arg("newAdmin", ARG_TYPE_ADDRESS)},
+	"acceptAdmin":    {arg("admin", ARG_TYPE_ADDRESS)},
+	"setOperator":    {arg("operator", ARG_TYPE_ADDRESS)},
+	"createSnapshot": {},
+}
+
+var authMethodSpecs = map[string][]*argSpec{
+	"assignFuncsToRole": {arg("contract", ARG_TYPE_ADDRESS), arg("adminId", ARG_TYPE_STRING), arg("role", ARG_TYPE_STRING),
+		arrayArg("funcNames", arg("", ARG_TYPE_STRING)), arg("keyIndex", ARG_TYPE_INTEGER)},
+	"assignTesraIDsToRole": {arg("contract", ARG_TYPE_ADDRESS), arg("adminId", ARG_TYPE_STRING), arg("role", ARG_TYPE_STRING),
+		arrayArg("persons", arg("", ARG_TYPE_STRING)), arg("keyIndex", ARG_TYPE_INTEGER)},
+	"delegate": {arg("contract", ARG_TYPE_ADDRESS), arg("from", ARG_TYPE_STRING), arg("to", ARG_TYPE_STRING),
+		arg("role", ARG_TYPE_STRING), arg("period", ARG_TYPE_INTEGER), arg("level", ARG_TYPE_INTEGER),
+		arg("keyIndex", ARG_TYPE_INTEGER)},
+	"withdraw": {arg("contract", ARG_TYPE_ADDRESS), arg("initiator", ARG_TYPE_STRING), arg("delegate", ARG_TYPE_STRING),
+		arg("role", ARG_TYPE_STRING), arg("keyIndex", ARG_TYPE_INTEGER)},
+	"transfer": {arg("contract", ARG_TYPE_ADDRESS), arg("newAdminId", ARG_TYPE_STRING), arg("keyIndex", ARG_TYPE_INTEGER)},
+	"verifyToken": {arg("contract", ARG_TYPE_ADDRESS), arg("caller", ARG_TYPE_STRING), arg("funcName", ARG_TYPE_STRING),
+		arg("keyIndex", ARG_TYPE_INTEGER)},
+}
+
+var peerPubkeyListSpec = arrayArg("peerPubkeyList", arg("", ARG_TYPE_STRING))
+
+var governanceMethodSpecs = map[string][]*argSpec{
+	"registerCandidate": {arg("peerPubkey", ARG_TYPE_STRING), arg("address", ARG_TYPE_ADDRESS),
+		arg("initPos", ARG_TYPE_INTEGER), arg("caller", ARG_TYPE_STRING), arg("keyNo", ARG_TYPE_INTEGER)},
+	"registerCandidateTransferFrom": {arg("peerPubkey", ARG_TYPE_STRING), arg("address", ARG_TYPE_ADDRESS),
+		arg("initPos", ARG_TYPE_INTEGER), arg("caller", ARG_TYPE_STRING), arg("keyNo", ARG_TYPE_INTEGER)},
+	"unRegisterCandidate": {arg("peerPubkey", ARG_TYPE_STRING), arg("address", ARG_TYPE_ADDRESS)},
+	"approveCandidate":    {arg("peerPubkey", ARG_TYPE_STRING)},
+	"rejectCandidate":     {arg("peerPubkey", ARG_TYPE_STRING)},
+	"blackNode":           {peerPubkeyListSpec},
+	"whiteNode":           {arg("peerPubkey", ARG_TYPE_STRING)},
+	"quitNode":            {arg("peerPubkey", ARG_TYPE_STRING), arg("address", ARG_TYPE_ADDRESS)},
+	"voteForPeer": {arg("address", ARG_TYPE_ADDRESS), peerPubkeyListSpec,
+		arrayArg("posList", arg("", ARG_TYPE_INTEGER))},
+	"voteForPeerTransferFrom": {arg("address", ARG_TYPE_ADDRESS), peerPubkeyListSpec,
+		arrayArg("posList", arg("", ARG_TYPE_INTEGER))},
+	"unVoteForPeer": {arg("address", ARG_TYPE_ADDRESS), peerPubkeyListSpec,
+		arrayArg("posList", arg("", ARG_TYPE_INTEGER))},
+	"withdraw": {arg("address", ARG_TYPE_ADDRESS), peerPubkeyListSpec,
+		arrayArg("withdrawList", arg("", ARG_TYPE_INTEGER))},
+	"authorizeForPeer": {arg("address", ARG_TYPE_ADDRESS), peerPubkeyListSpec,
+		arrayArg("posList", arg("", ARG_TYPE_INTEGER))},
+	"unAuthorizeForPeer": {arg("address", ARG_TYPE_ADDRESS), peerPubkeyListSpec,
+		arrayArg("posList", arg("", ARG_TYPE_INTEGER))},
+	"commitDpos": {},
+	"updateConfig": {arg("n", ARG_TYPE_INTEGER), arg("c", ARG_TYPE_INTEGER), arg("k", ARG_TYPE_INTEGER),
+		arg("l", ARG_TYPE_INTEGER), arg("blockMsgDelay", ARG_TYPE_INTEGER), arg("hashMsgDelay", ARG_TYPE_INTEGER),
+		arg("peerHandshakeTimeout", ARG_TYPE_INTEGER), arg("maxBlockChangeView", ARG_TYPE_INTEGER)},
+	"updateGlobalParam": {arg("candidateFee", ARG_TYPE_INTEGER), arg("minInitStake", ARG_TYPE_INTEGER),
+		arg("candidateNum", ARG_TYPE_INTEGER), arg("posLimit", ARG_TYPE_INTEGER), arg("a", ARG_TYPE_INTEGER),
+		arg("b", ARG_TYPE_INTEGER), arg("yita", ARG_TYPE_INTEGER), arg("penalty", ARG_TYPE_INTEGER)},
+	"updateSplitCurve":       {arrayArg("yi", arg("", ARG_TYPE_INTEGER))},
+	"transferPenalty":        {arg("peerPubkey", ARG_TYPE_STRING), arg("address", ARG_TYPE_ADDRESS)},
+	"withdrawTsg":            {arg("address", ARG_TYPE_ADDRESS)},
+	"withdrawFee":            {arg("address", ARG_TYPE_ADDRESS)},
+	"changeMaxAuthorization": {arg("peerPubkey", ARG_TYPE_STRING), arg("address", ARG_TYPE_ADDRESS), arg("maxAuthorize", ARG_TYPE_INTEGER)},
+	"setPeerCost":            {arg("peerPubkey", ARG_TYPE_STRING), arg("address", ARG_TYPE_ADDRESS), arg("peerCost", ARG_TYPE_INTEGER)},
+	"addInitPos":             {arg("peerPubkey", ARG_TYPE_STRING), arg("address", ARG_TYPE_ADDRESS), arg("pos", ARG_TYPE_INTEGER)},
+	"reduceInitPos":          {arg("peerPubkey", ARG_TYPE_STRING), arg("address", ARG_TYPE_ADDRESS), arg("pos", ARG_TYPE_INTEGER)},
+	"setPromisePos":          {arg("peerPubkey", ARG_TYPE_STRING), arg("promisePos", ARG_TYPE_INTEGER)},
+}
+
+var nativeMethodSpecs = map[common.Address]map[string][]*argSpec{
+	TSR_CONTRACT_ADDRESS:           tokenMethodSpecs,
+	TSG_CONTRACT_ADDRESS:           tokenMethodSpecs,
+	TSR_ID_CONTRACT_ADDRESS:        tsrIdMethodSpecs,
+	GLOABL_PARAMS_CONTRACT_ADDRESS: globalParamsMethodSpecs,
+	AUTH_CONTRACT_ADDRESS:          authMethodSpecs,
+	GOVERNANCE_CONTRACT_ADDRESS:    governanceMethodSpecs,
+}
+
+//teoVMMethodSpecs is the args of TEP1 token methods, used when the method name and args count match
+var teoVMMethodSpecs = map[string][]*argSpec{
+	"transfer": {arg("from", ARG_TYPE_ADDRESS), arg("to", ARG_TYPE_ADDRESS), arg("amount", ARG_TYPE_INTEGER)},
+	"transferMulti": {arrayArg("states", structArg("",
+		arg("from", ARG_TYPE_ADDRESS), arg("to", ARG_TYPE_ADDRESS), arg("amount", ARG_TYPE_INTEGER)))},
+	"transferFrom": {arg("spender", ARG_TYPE_ADDRESS), arg("from", ARG_TYPE_ADDRESS), arg("to", ARG_TYPE_ADDRESS),
+		arg("amount", ARG_TYPE_INTEGER)},
+	"approve":     {arg("owner", ARG_TYPE_ADDRESS), arg("spender", ARG_TYPE_ADDRESS), arg("amount", ARG_TYPE_INTEGER)},
+	"allowance":   {arg("owner", ARG_TYPE_ADDRESS), arg("spender", ARG_TYPE_ADDRESS)},
+	"balanceOf":   {arg("address", ARG_TYPE_ADDRESS)},
+	"name":        {},
+	"symbol":      {},
+	"decimals":    {},
+	"totalSupply": {},
+}
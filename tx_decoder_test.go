@@ -0,0 +1,139 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/core/payload"
+	"github.com/TesraSupernet/Tesra/smartcontract/service/native/tsr"
+	sdkcom "github.com/TesraSupernet/tesrasdk/common"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDecodeTransaction_NativeTransfer(t *testing.T) {
+	sdk := NewTesraSdk()
+	acc1 := NewAccount()
+	acc2 := NewAccount()
+	states := []*tsr.State{
+		{From: acc1.Address, To: acc2.Address, Value: 10},
+		{From: acc2.Address, To: acc1.Address, Value: 1000000000000},
+	}
+	//version larger than 15 is pushed as byte array
+	tx, err := sdk.Native.NewNativeInvokeTransaction(500, 20000, 20, TSG_CONTRACT_ADDRESS,
+		tsr.TRANSFER_NAME, []interface{}{states})
+	assert.Nil(t, err)
+
+	decoded, err := DecodeTransaction(tx)
+	assert.Nil(t, err)
+	call := decoded.Invoke
+	assert.Equal(t, VM_TYPE_NATIVE, call.VMType)
+	assert.Equal(t, "TSG", call.ContractName)
+	assert.Equal(t, byte(20), call.Version)
+	assert.Equal(t, TSG_CONTRACT_ADDRESS, call.GetContractAddress())
+	assert.Equal(t, tsr.TRANSFER_NAME, call.Method)
+	items := call.GetArg("states").Items
+	assert.Equal(t, 2, len(items))
+	from, err := items[0].GetItem("from").ToAddress()
+	assert.Nil(t, err)
+	assert.Equal(t, acc1.Address, from)
+	value, err := items[1].GetItem("value").ToUint64()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1000000000000), value)
+
+	invokeCode := tx.Payload.(*payload.InvokeCode)
+	res, err := ParsePayload(invokeCode.Code)
+	assert.Nil(t, err)
+	assert.Equal(t, "tsg", res["asset"])
+	param := res["param"].([]sdkcom.StateInfo)
+	assert.Equal(t, acc2.Address.ToBase58(), param[1].From)
+	assert.Equal(t, uint64(1000000000000), param[1].Value)
+}
+
+func TestDecodeTransaction_NativeTransferFrom(t *testing.T) {
+	sdk := NewTesraSdk()
+	sender := NewAccount()
+	from := NewAccount()
+	to := NewAccount()
+	tx, err := sdk.Native.Tsg.NewTransferFromTransaction(500, 20000, sender.Address, from.Address, to.Address, 100)
+	assert.Nil(t, err)
+
+	decoded, err := DecodeTransaction(tx)
+	assert.Nil(t, err)
+	call := decoded.Invoke
+	assert.Equal(t, "transferFrom", call.Method)
+	assert.Equal(t, sender.Address.ToBase58(), call.GetArg("sender").Value)
+	assert.Equal(t, to.Address.ToBase58(), call.GetArg("to").Value)
+	assert.Equal(t, "100", call.GetArg("value").Value)
+}
+
+func TestDecodeTransaction_TeoVM(t *testing.T) {
+	sdk := NewTesraSdk()
+	contract, err := common.AddressFromHexString("1ddbb682743e9d9e2b71ff419e97a9358c5c4ee9")
+	assert.Nil(t, err)
+	acc1 := NewAccount()
+	acc2 := NewAccount()
+	tx, err := sdk.TeoVM.NewTeoVMInvokeTransaction(500, 20000, contract,
+		[]interface{}{"transfer", []interface{}{acc1.Address, acc2.Address, 300}})
+	assert.Nil(t, err)
+
+	decoded, err := DecodeTransaction(tx)
+	assert.Nil(t, err)
+	call := decoded.Invoke
+	assert.Equal(t, VM_TYPE_TEOVM, call.VMType)
+	assert.Equal(t, contract, call.GetContractAddress())
+	assert.Equal(t, "transfer", call.Method)
+	assert.Equal(t, acc1.Address.ToBase58(), call.GetArg("from").Value)
+	assert.Equal(t, "300", call.GetArg("amount").Value)
+
+	//unknown method is decoded generically
+	tx, err = sdk.TeoVM.NewTeoVMInvokeTransaction(500, 20000, contract,
+		[]interface{}{"put", []interface{}{"key", []byte{1, 2}}})
+	assert.Nil(t, err)
+	decoded, err = DecodeTransaction(tx)
+	assert.Nil(t, err)
+	assert.Equal(t, "put", decoded.Invoke.Method)
+	assert.Equal(t, 2, len(decoded.Invoke.Args))
+	assert.Equal(t, "0102", decoded.Invoke.Args[1].Value)
+}
+
+func TestDecodeTransaction_WasmVM(t *testing.T) {
+	sdk := NewTesraSdk()
+	contract, err := common.AddressFromHexString("1ddbb682743e9d9e2b71ff419e97a9358c5c4ee9")
+	assert.Nil(t, err)
+	acc := NewAccount()
+	tx, err := sdk.WasmVM.NewInvokeWasmVmTransaction(500, 20000, contract, "transfer",
+		[]interface{}{acc.Address, "memo", []byte{7}})
+	assert.Nil(t, err)
+
+	decoded, err := DecodeTransaction(tx)
+	assert.Nil(t, err)
+	call := decoded.Invoke
+	assert.Equal(t, VM_TYPE_WASMVM, call.VMType)
+	assert.Equal(t, "transfer", call.Method)
+	raw, err := call.GetArg("rawArgs").ToBytes()
+	assert.Nil(t, err)
+	args, err := DecodeWasmVMArgs(raw, []string{"address", "string", "bytes"})
+	assert.Nil(t, err)
+	assert.Equal(t, acc.Address.ToBase58(), args[0].Value)
+	assert.Equal(t, "memo", args[1].Value)
+	assert.Equal(t, "07", args[2].Value)
+
+	_, err = DecodeWasmVMArgs(raw, []string{"address", "string"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,268 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/core/payload"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	"strconv"
+	"strings"
+)
+
+//TxSigJson is the json representation of transaction signature
+type TxSigJson struct {
+	Address string   `json:"address"`
+	M       uint16   `json:"m"`
+	PubKeys []string `json:"pubKeys"`
+	SigData []string `json:"sigData"`
+}
+
+//TransactionJson is the json representation of transaction. PayloadData is the serialized payload
+//in hex, and Payload is the decoded payload for human review, which is ignored when parsing.
+type TransactionJson struct {
+	Hash        string          `json:"hash"`
+	Version     byte            `json:"version"`
+	TxType      string          `json:"txType"`
+	Nonce       uint32          `json:"nonce"`
+	GasPrice    uint64          `json:"gasPrice"`
+	GasLimit    uint64          `json:"gasLimit"`
+	Payer       string          `json:"payer"`
+	Payload     *DecodedPayload `json:"payload,omitempty"`
+	PayloadData string          `json:"payloadData"`
+	Sigs        []*TxSigJson    `json:"sigs"`
+}
+
+//NewTransactionJson return the json representation of tx
+func NewTransactionJson(tx *types.MutableTransaction) (*TransactionJson, error) {
+	if tx == nil {
+		return nil, fmt.Errorf("tx should not be nil")
+	}
+	sink := common.NewZeroCopySink(nil)
+	switch pl := tx.Payload.(type) {
+	case *payload.InvokeCode:
+		pl.Serialization(sink)
+	case *payload.DeployCode:
+		pl.Serialization(sink)
+	default:
+		return nil, fmt.Errorf("unsupport payload type:%T", tx.Payload)
+	}
+	txHash := tx.Hash()
+	txJson := &TransactionJson{
+		Hash:        txHash.ToHexString(),
+		Version:     tx.Version,
+		TxType:      GetTxTypeString(tx.TxType),
+		Nonce:       tx.Nonce,
+		GasPrice:    tx.GasPrice,
+		GasLimit:    tx.GasLimit,
+		Payer:       tx.Payer.ToBase58(),
+		PayloadData: hex.EncodeToString(sink.Bytes()),
+		Sigs:        make([]*TxSigJson, 0, len(tx.Sigs)),
+	}
+	decoded, err := DecodeTransaction(tx)
+	if err == nil {
+		txJson.Payload = decoded
+	}
+	for _, sig := range tx.Sigs {
+		sigJson := &TxSigJson{
+			M:       sig.M,
+			PubKeys: make([]string, 0, len(sig.PubKeys)),
+			SigData: make([]string, 0, len(sig.SigData)),
+		}
+		for _, pk := range sig.PubKeys {
+			sigJson.PubKeys = append(sigJson.PubKeys, hex.EncodeToString(keypair.SerializePublicKey(pk)))
+		}
+		for _, data := range sig.SigData {
+			sigJson.SigData = append(sigJson.SigData, hex.EncodeToString(data))
+		}
+		addr, err := getSigAddress(sig)
+		if err == nil {
+			sigJson.Address = addr.ToBase58()
+		}
+		txJson.Sigs = append(txJson.Sigs, sigJson)
+	}
+	return txJson, nil
+}
+
+//getSigAddress return the address of signer, which is a normal address for one pubkey, and multi-sign address for more
+func getSigAddress(sig types.Sig) (common.Address, error) {
+	switch len(sig.PubKeys) {
+	case 0:
+		return common.ADDRESS_EMPTY, fmt.Errorf("no pubkey in sig")
+	case 1:
+		return types.AddressFromPubKey(sig.PubKeys[0]), nil
+	default:
+		return types.AddressFromMultiPubKeys(sig.PubKeys, int(sig.M))
+	}
+}
+
+//ToMutableTransaction parse the json representation into transaction. The computed hash must match Hash
+//if it is not empty, and the signer address must match Address if it is not empty.
+func (this *TransactionJson) ToMutableTransaction() (*types.MutableTransaction, error) {
+	txType, err := GetTxType(this.TxType)
+	if err != nil {
+		return nil, err
+	}
+	payer, err := common.AddressFromBase58(this.Payer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payer:%s error:%s", this.Payer, err)
+	}
+	payloadData, err := hex.DecodeString(this.PayloadData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payloadData error:%s", err)
+	}
+	tx := &types.MutableTransaction{
+		Version:  this.Version,
+		TxType:   txType,
+		Nonce:    this.Nonce,
+		GasPrice: this.GasPrice,
+		GasLimit: this.GasLimit,
+		Payer:    payer,
+		Sigs:     make([]types.Sig, 0, len(this.Sigs)),
+	}
+	source := common.NewZeroCopySource(payloadData)
+	switch txType {
+	case types.InvokeTeo, types.InvokeWasm:
+		invokeCode := &payload.InvokeCode{}
+		err = invokeCode.Deserialization(source)
+		tx.Payload = invokeCode
+	case types.Deploy:
+		deployCode := &payload.DeployCode{}
+		err = deployCode.Deserialization(source)
+		tx.Payload = deployCode
+	default:
+		return nil, fmt.Errorf("unsupport tx type:%s", this.TxType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("deserialize payload error:%s", err)
+	}
+	if source.Len() != 0 {
+		return nil, fmt.Errorf("%d bytes left after deserialize payload", source.Len())
+	}
+	for i, sigJson := range this.Sigs {
+		sig := types.Sig{
+			M:       sigJson.M,
+			PubKeys: make([]keypair.PublicKey, 0, len(sigJson.PubKeys)),
+			SigData: make([][]byte, 0, len(sigJson.SigData)),
+		}
+		for _, pkStr := range sigJson.PubKeys {
+			pkData, err := hex.DecodeString(pkStr)
+			if err != nil {
+				return nil, fmt.Errorf("sig %d invalid pubkey:%s error:%s", i, pkStr, err)
+			}
+			pk, err := keypair.DeserializePublicKey(pkData)
+			if err != nil {
+				return nil, fmt.Errorf("sig %d invalid pubkey:%s error:%s", i, pkStr, err)
+			}
+			sig.PubKeys = append(sig.PubKeys, pk)
+		}
+		for _, dataStr := range sigJson.SigData {
+			data, err := hex.DecodeString(dataStr)
+			if err != nil {
+				return nil, fmt.Errorf("sig %d invalid sigData:%s error:%s", i, dataStr, err)
+			}
+			sig.SigData = append(sig.SigData, data)
+		}
+		if sigJson.Address != "" {
+			addr, err := getSigAddress(sig)
+			if err != nil {
+				return nil, fmt.Errorf("sig %d error:%s", i, err)
+			}
+			if addr.ToBase58() != sigJson.Address {
+				return nil, fmt.Errorf("sig %d address:%s does not match pubkeys:%s", i, sigJson.Address, addr.ToBase58())
+			}
+		}
+		tx.Sigs = append(tx.Sigs, sig)
+	}
+	if this.Hash != "" {
+		txHash := tx.Hash()
+		if txHash.ToHexString() != this.Hash {
+			return nil, fmt.Errorf("tx hash:%s does not match computed hash:%s", this.Hash, txHash.ToHexString())
+		}
+	}
+	return tx, nil
+}
+
+//ToTransaction parse the json representation into immutable transaction
+func (this *TransactionJson) ToTransaction() (*types.Transaction, error) {
+	tx, err := this.ToMutableTransaction()
+	if err != nil {
+		return nil, err
+	}
+	return tx.IntoImmutable()
+}
+
+//GetTxType return transaction type by the name returned by GetTxTypeString
+func GetTxType(name string) (types.TransactionType, error) {
+	switch name {
+	case "Deploy":
+		return types.Deploy, nil
+	case "InvokeTeo":
+		return types.InvokeTeo, nil
+	case "InvokeWasm":
+		return types.InvokeWasm, nil
+	}
+	if strings.HasPrefix(name, "0x") {
+		txType, err := strconv.ParseUint(name[2:], 16, 8)
+		if err == nil {
+			return types.TransactionType(txType), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown tx type:%s", name)
+}
+
+//MutableTxToJson return the indented json representation of tx
+func MutableTxToJson(tx *types.MutableTransaction) ([]byte, error) {
+	txJson, err := NewTransactionJson(tx)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(txJson, "", "  ")
+}
+
+//MutableTxFromJson parse tx from the json returned by MutableTxToJson
+func MutableTxFromJson(data []byte) (*types.MutableTransaction, error) {
+	txJson := &TransactionJson{}
+	err := json.Unmarshal(data, txJson)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal TransactionJson error:%s", err)
+	}
+	return txJson.ToMutableTransaction()
+}
+
+//TxToJson return the indented json representation of tx
+func TxToJson(tx *types.Transaction) ([]byte, error) {
+	mutTx, err := tx.IntoMutable()
+	if err != nil {
+		return nil, err
+	}
+	return MutableTxToJson(mutTx)
+}
+
+//TxFromJson parse tx from the json returned by TxToJson
+func TxFromJson(data []byte) (*types.Transaction, error) {
+	mutTx, err := MutableTxFromJson(data)
+	if err != nil {
+		return nil, err
+	}
+	return mutTx.IntoImmutable()
+}
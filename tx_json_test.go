@@ -0,0 +1,57 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"encoding/json"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMutableTxJson_RoundTrip(t *testing.T) {
+	sdk := NewTesraSdk()
+	acc1 := NewAccount()
+	acc2 := NewAccount()
+	tx, err := sdk.Native.Tsg.NewTransferTransaction(500, 20000, acc1.Address, acc2.Address, 100)
+	assert.Nil(t, err)
+	sdk.SetPayer(tx, acc1.Address)
+	assert.Nil(t, sdk.SignToTransaction(tx, acc1))
+	assert.Nil(t, sdk.MultiSignToTransaction(tx, 1, []keypair.PublicKey{acc1.PublicKey, acc2.PublicKey}, acc2))
+
+	data, err := MutableTxToJson(tx)
+	assert.Nil(t, err)
+	txJson := &TransactionJson{}
+	assert.Nil(t, json.Unmarshal(data, txJson))
+	assert.Equal(t, acc1.Address.ToBase58(), txJson.Payer)
+	assert.Equal(t, acc1.Address.ToBase58(), txJson.Sigs[0].Address)
+	assert.Equal(t, "transfer", txJson.Payload.Invoke.Method)
+
+	tx2, err := MutableTxFromJson(data)
+	assert.Nil(t, err)
+	raw1, err := sdk.GetTxData(tx)
+	assert.Nil(t, err)
+	raw2, err := sdk.GetTxData(tx2)
+	assert.Nil(t, err)
+	assert.Equal(t, raw1, raw2)
+
+	//tampered field is detected by hash
+	txJson.GasPrice = 0
+	_, err = txJson.ToMutableTransaction()
+	assert.NotNil(t, err)
+}
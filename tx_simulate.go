@@ -0,0 +1,219 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/Tesra/smartcontract/event"
+	sdkcom "github.com/TesraSupernet/tesrasdk/common"
+	"github.com/TesraSupernet/tesrasdk/utils"
+	"math/big"
+	"sort"
+)
+
+//SimulatedTransfer is a transfer decoded from the notify of simulated transaction
+type SimulatedTransfer struct {
+	Asset    string //PAYMENT_ASSET_TSR, PAYMENT_ASSET_TSG or PAYMENT_ASSET_TEP1
+	Contract string //contract address in hex
+	From     string
+	To       string
+	Amount   *big.Int
+}
+
+//SimulatedNotify is the notify emitted by simulated transaction, Transfer is nil if it is not a transfer event
+type SimulatedNotify struct {
+	ContractAddress string
+	States          interface{}
+	Transfer        *SimulatedTransfer
+}
+
+//BalanceDelta is the balance change of address on asset, negative means decrease
+type BalanceDelta struct {
+	Address  string
+	Asset    string
+	Contract string
+	Delta    *big.Int
+}
+
+//SimulationReport is the preview of what transaction will do if it is sent. GasFee is in the smallest unit of TSG
+//and is charged to payer besides the deltas, since pre-execution does not charge gas.
+type SimulationReport struct {
+	TxHash    string
+	State     byte
+	Gas       uint64
+	GasFee    uint64
+	Payer     string
+	Result    *sdkcom.ResultItem
+	Payload   *DecodedPayload
+	Notify    []*SimulatedNotify
+	Transfers []*SimulatedTransfer
+	Deltas    []*BalanceDelta
+}
+
+//IsSuccess return whether transaction executed successfully in simulation
+func (this *SimulationReport) IsSuccess() bool {
+	return this.State == 1
+}
+
+//GetDelta return the balance delta of address on contract, zero if unchanged
+func (this *SimulationReport) GetDelta(address, contract common.Address) *big.Int {
+	addr := address.ToBase58()
+	contractAddr := contract.ToHexString()
+	for _, delta := range this.Deltas {
+		if delta.Address == addr && delta.Contract == contractAddr {
+			return new(big.Int).Set(delta.Delta)
+		}
+	}
+	return new(big.Int)
+}
+
+//GetAddressDeltas return all the balance deltas of address
+func (this *SimulationReport) GetAddressDeltas(address common.Address) []*BalanceDelta {
+	addr := address.ToBase58()
+	deltas := make([]*BalanceDelta, 0)
+	for _, delta := range this.Deltas {
+		if delta.Address == addr {
+			deltas = append(deltas, delta)
+		}
+	}
+	return deltas
+}
+
+//SimulateTransaction pre-execute tx and return the report with decoded notifies and balance deltas.
+//tx needs not to be signed.
+func (this *TesraSdk) SimulateTransaction(tx *types.MutableTransaction) (*SimulationReport, error) {
+	preResult, err := this.PreExecTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+	return this.NewSimulationReport(tx, preResult)
+}
+
+//NewSimulationReport return the report of tx from the result of PreExecTransaction
+func (this *TesraSdk) NewSimulationReport(tx *types.MutableTransaction, preResult *sdkcom.PreExecResult) (*SimulationReport, error) {
+	if preResult == nil {
+		return nil, fmt.Errorf("pre-execute result is nil")
+	}
+	txHash := tx.Hash()
+	report := &SimulationReport{
+		TxHash:    txHash.ToHexString(),
+		State:     preResult.State,
+		Gas:       preResult.Gas,
+		GasFee:    preResult.Gas * tx.GasPrice,
+		Payer:     tx.Payer.ToBase58(),
+		Result:    preResult.Result,
+		Notify:    make([]*SimulatedNotify, 0, len(preResult.Notify)),
+		Transfers: make([]*SimulatedTransfer, 0),
+	}
+	decoded, err := DecodeTransaction(tx)
+	if err == nil {
+		report.Payload = decoded
+	}
+	for _, notify := range preResult.Notify {
+		simNotify := &SimulatedNotify{
+			ContractAddress: notify.ContractAddress,
+			States:          notify.States,
+			Transfer:        this.decodeSimulatedTransfer(notify),
+		}
+		report.Notify = append(report.Notify, simNotify)
+		if simNotify.Transfer != nil {
+			report.Transfers = append(report.Transfers, simNotify.Transfer)
+		}
+	}
+	report.Deltas = getBalanceDeltas(report.Transfers)
+	return report, nil
+}
+
+//decodeSimulatedTransfer decode notify by ParseNaitveTransferEvent or ParseTep1TransferEvent, return nil if notify is not a transfer
+func (this *TesraSdk) decodeSimulatedTransfer(notify *sdkcom.NotifyEventInfo) *SimulatedTransfer {
+	contract, err := utils.AddressFromHexString(notify.ContractAddress)
+	if err != nil {
+		return nil
+	}
+	if contract == TSR_CONTRACT_ADDRESS || contract == TSG_CONTRACT_ADDRESS {
+		evt, err := this.ParseNaitveTransferEvent(&event.NotifyEventInfo{
+			ContractAddress: contract,
+			States:          notify.States,
+		})
+		if err != nil {
+			return nil
+		}
+		asset := PAYMENT_ASSET_TSR
+		if contract == TSG_CONTRACT_ADDRESS {
+			asset = PAYMENT_ASSET_TSG
+		}
+		return &SimulatedTransfer{
+			Asset:    asset,
+			Contract: contract.ToHexString(),
+			From:     evt.From,
+			To:       evt.To,
+			Amount:   new(big.Int).SetUint64(evt.Amount),
+		}
+	}
+	evt, err := this.ParseTep1TransferEvent(notify)
+	if err != nil || evt.Name != "transfer" {
+		return nil
+	}
+	return &SimulatedTransfer{
+		Asset:    PAYMENT_ASSET_TEP1,
+		Contract: contract.ToHexString(),
+		From:     evt.From.ToBase58(),
+		To:       evt.To.ToBase58(),
+		Amount:   evt.Amount,
+	}
+}
+
+//getBalanceDeltas sum transfers to balance deltas, sorted by address and contract. Zero deltas are omitted.
+func getBalanceDeltas(transfers []*SimulatedTransfer) []*BalanceDelta {
+	deltaMap := make(map[string]*BalanceDelta)
+	getDelta := func(address string, transfer *SimulatedTransfer) *BalanceDelta {
+		key := address + ":" + transfer.Contract
+		delta, ok := deltaMap[key]
+		if !ok {
+			delta = &BalanceDelta{
+				Address:  address,
+				Asset:    transfer.Asset,
+				Contract: transfer.Contract,
+				Delta:    new(big.Int),
+			}
+			deltaMap[key] = delta
+		}
+		return delta
+	}
+	for _, transfer := range transfers {
+		from := getDelta(transfer.From, transfer)
+		from.Delta.Sub(from.Delta, transfer.Amount)
+		to := getDelta(transfer.To, transfer)
+		to.Delta.Add(to.Delta, transfer.Amount)
+	}
+	deltas := make([]*BalanceDelta, 0, len(deltaMap))
+	for _, delta := range deltaMap {
+		if delta.Delta.Sign() != 0 {
+			deltas = append(deltas, delta)
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		if deltas[i].Address != deltas[j].Address {
+			return deltas[i].Address < deltas[j].Address
+		}
+		return deltas[i].Contract < deltas[j].Contract
+	})
+	return deltas
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	sdkcom "github.com/TesraSupernet/tesrasdk/common"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestNewSimulationReport(t *testing.T) {
+	sdk := NewTesraSdk()
+	from := NewAccount().Address
+	to := NewAccount().Address
+	tx, err := sdk.Native.Tsg.NewTransferTransaction(500, 20000, from, to, 100)
+	assert.Nil(t, err)
+	sdk.SetPayer(tx, from)
+
+	contract, _ := common.AddressFromHexString("1ddbb682743e9d9e2b71ff419e97a9358c5c4ee9")
+	tep1States := fmt.Sprintf(`["%s","%s","%s","%s"]`, hex.EncodeToString([]byte("transfer")),
+		hex.EncodeToString(to[:]), hex.EncodeToString(from[:]), hex.EncodeToString(common.BigIntToTeoBytes(big.NewInt(7))))
+	data := fmt.Sprintf(`{"State":1,"Gas":20000,"Result":"01","Notify":[`+
+		`{"ContractAddress":"%s","States":["transfer","%s","%s",100]},`+
+		`{"ContractAddress":"%s","States":%s},`+
+		`{"ContractAddress":"%s","States":"0a0b"}]}`,
+		TSG_CONTRACT_ADDRESS.ToHexString(), from.ToBase58(), to.ToBase58(),
+		contract.ToHexString(), tep1States, contract.ToHexString())
+	preResult := &sdkcom.PreExecResult{}
+	assert.Nil(t, json.Unmarshal([]byte(data), preResult))
+	assert.Equal(t, 3, len(preResult.Notify))
+
+	report, err := sdk.NewSimulationReport(tx, preResult)
+	assert.Nil(t, err)
+	assert.True(t, report.IsSuccess())
+	assert.Equal(t, uint64(20000*500), report.GasFee)
+	assert.Equal(t, "transfer", report.Payload.Invoke.Method)
+	assert.Equal(t, 3, len(report.Notify))
+	assert.Nil(t, report.Notify[2].Transfer)
+	assert.Equal(t, 2, len(report.Transfers))
+	assert.Equal(t, PAYMENT_ASSET_TEP1, report.Transfers[1].Asset)
+	assert.Equal(t, 4, len(report.Deltas))
+	assert.Equal(t, int64(-100), report.GetDelta(from, TSG_CONTRACT_ADDRESS).Int64())
+	assert.Equal(t, int64(100), report.GetDelta(to, TSG_CONTRACT_ADDRESS).Int64())
+	assert.Equal(t, int64(7), report.GetDelta(from, contract).Int64())
+	assert.Equal(t, int64(-7), report.GetDelta(to, contract).Int64())
+	assert.Equal(t, int64(0), report.GetDelta(from, TSR_CONTRACT_ADDRESS).Int64())
+	assert.Equal(t, 2, len(report.GetAddressDeltas(from)))
+}
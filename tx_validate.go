@@ -0,0 +1,207 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/common/constants"
+	"github.com/TesraSupernet/Tesra/core/payload"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	"math"
+	"strings"
+)
+
+const (
+	MAX_TX_SIZE      = 1024 * 1024 //max size of serialized transaction accepted by node
+	MIN_TX_GAS_LIMIT = 20000       //min gas limit of transaction
+)
+
+//TxProblem is a problem of transaction found by ValidateTransaction
+type TxProblem struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (this *TxProblem) Error() string {
+	return fmt.Sprintf("%s: %s", this.Field, this.Message)
+}
+
+//TxProblems is the list of problems of transaction
+type TxProblems []*TxProblem
+
+func (this TxProblems) Error() string {
+	msgs := make([]string, 0, len(this))
+	for _, p := range this {
+		msgs = append(msgs, p.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+//ToError return nil if there is no problem, or TxProblems as error
+func (this TxProblems) ToError() error {
+	if len(this) == 0 {
+		return nil
+	}
+	return this
+}
+
+func (this *TxProblems) add(field, format string, args ...interface{}) {
+	*this = append(*this, &TxProblem{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+//ValidateTransaction check tx locally before sending it to Tesra. It checks the structure,
+//gas and size limits, multi-sign thresholds, verifies every signature against tx hash,
+//and confirms that payer has signed. All of the problems found are returned.
+func ValidateTransaction(tx *types.MutableTransaction) TxProblems {
+	problems := make(TxProblems, 0)
+	if tx == nil {
+		problems.add("tx", "tx should not be nil")
+		return problems
+	}
+	if tx.Version != 0 {
+		problems.add("version", "unsupport version:%d", tx.Version)
+	}
+	switch pl := tx.Payload.(type) {
+	case *payload.InvokeCode:
+		if tx.TxType != types.InvokeTeo && tx.TxType != types.InvokeWasm {
+			problems.add("txType", "tx type:%s does not match invoke payload", GetTxTypeString(tx.TxType))
+		}
+		if len(pl.Code) == 0 {
+			problems.add("payload", "invoke code is empty")
+		}
+	case *payload.DeployCode:
+		if tx.TxType != types.Deploy {
+			problems.add("txType", "tx type:%s does not match deploy payload", GetTxTypeString(tx.TxType))
+		}
+		if len(pl.GetRawCode()) == 0 {
+			problems.add("payload", "deploy code is empty")
+		}
+	default:
+		problems.add("payload", "unsupport payload type:%T", tx.Payload)
+	}
+	if tx.GasPrice == 0 {
+		problems.add("gasPrice", "gas price is zero")
+	}
+	if tx.GasLimit < MIN_TX_GAS_LIMIT {
+		problems.add("gasLimit", "gas limit:%d is less than %d", tx.GasLimit, MIN_TX_GAS_LIMIT)
+	}
+	if tx.GasPrice != 0 && tx.GasLimit > math.MaxUint64/tx.GasPrice {
+		problems.add("gasLimit", "gas price:%d * gas limit:%d overflow", tx.GasPrice, tx.GasLimit)
+	}
+	if tx.Payer == common.ADDRESS_EMPTY {
+		problems.add("payer", "payer is empty")
+	}
+	if len(tx.Sigs) == 0 {
+		problems.add("sigs", "tx has no signature")
+	}
+	if len(tx.Sigs) > constants.TX_MAX_SIG_SIZE {
+		problems.add("sigs", "too many signatures:%d, max:%d", len(tx.Sigs), constants.TX_MAX_SIG_SIZE)
+	}
+
+	txHash := tx.Hash()
+	signers := make(map[common.Address]int, len(tx.Sigs))
+	for i, sig := range tx.Sigs {
+		field := fmt.Sprintf("sigs[%d]", i)
+		if !validateSig(&problems, field, txHash.ToArray(), sig) {
+			continue
+		}
+		addr, err := getSigAddress(sig)
+		if err != nil {
+			problems.add(field, "get signer address error:%s", err)
+			continue
+		}
+		if j, ok := signers[addr]; ok {
+			problems.add(field, "duplicate signer:%s, already in sigs[%d]", addr.ToBase58(), j)
+			continue
+		}
+		signers[addr] = i
+	}
+	if tx.Payer != common.ADDRESS_EMPTY {
+		if _, ok := signers[tx.Payer]; !ok {
+			problems.add("payer", "payer:%s has not signed", tx.Payer.ToBase58())
+		}
+	}
+
+	immutTx, err := tx.IntoImmutable()
+	if err != nil {
+		problems.add("tx", "serialize error:%s", err)
+	} else if len(immutTx.Raw) > MAX_TX_SIZE {
+		problems.add("tx", "tx size:%d exceed max size:%d", len(immutTx.Raw), MAX_TX_SIZE)
+	}
+	return problems
+}
+
+//validateSig check the thresholds of sig and verify sig data. Return false if sig is invalid.
+func validateSig(problems *TxProblems, field string, data []byte, sig types.Sig) bool {
+	pkSize := len(sig.PubKeys)
+	valid := true
+	if pkSize == 0 {
+		problems.add(field, "no pubkey")
+		return false
+	}
+	if pkSize > constants.MULTI_SIG_MAX_PUBKEY_SIZE {
+		problems.add(field, "too many pubkeys:%d, max:%d", pkSize, constants.MULTI_SIG_MAX_PUBKEY_SIZE)
+		valid = false
+	}
+	if pkSize > 1 && (sig.M == 0 || int(sig.M) > pkSize) {
+		problems.add(field, "m:%d should be between 1 and pubkey count:%d", sig.M, pkSize)
+		valid = false
+	}
+	pks := make(map[string]bool, pkSize)
+	for _, pk := range sig.PubKeys {
+		key := string(keypair.SerializePublicKey(pk))
+		if pks[key] {
+			problems.add(field, "duplicate pubkey:%x", keypair.SerializePublicKey(pk))
+			valid = false
+		}
+		pks[key] = true
+	}
+	m := int(sig.M)
+	if pkSize == 1 {
+		m = 1
+	}
+	if len(sig.SigData) < m {
+		problems.add(field, "not enough signatures:%d, need:%d", len(sig.SigData), m)
+		valid = false
+	}
+	if len(sig.SigData) > pkSize {
+		problems.add(field, "too many signatures:%d, pubkey count:%d", len(sig.SigData), pkSize)
+		valid = false
+	}
+	used := make([]bool, pkSize)
+	for i, sigData := range sig.SigData {
+		verified := false
+		for j, pk := range sig.PubKeys {
+			if used[j] {
+				continue
+			}
+			if VerifySignature(pk, data, sigData) == nil {
+				used[j] = true
+				verified = true
+				break
+			}
+		}
+		if !verified {
+			problems.add(field, "signature %d does not match any pubkey", i)
+			valid = false
+		}
+	}
+	return valid
+}
@@ -0,0 +1,165 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"github.com/TesraSupernet/Tesra/common/constants"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestValidateTransaction(t *testing.T) {
+	sdk := NewTesraSdk()
+	acc1 := NewAccount()
+	acc2 := NewAccount()
+	newTx := func(gasPrice uint64) *types.MutableTransaction {
+		tx, err := sdk.Native.Tsg.NewTransferTransaction(gasPrice, 20000, acc1.Address, acc2.Address, 1)
+		assert.Nil(t, err)
+		sdk.SetPayer(tx, acc1.Address)
+		return tx
+	}
+	newMultiSig := func(tx *types.MutableTransaction, m uint16, accs ...*Account) types.Sig {
+		txHash := tx.Hash()
+		sig := types.Sig{M: m}
+		for _, acc := range accs {
+			sigData, err := acc.Sign(txHash.ToArray())
+			assert.Nil(t, err)
+			sig.PubKeys = append(sig.PubKeys, acc.PublicKey)
+			sig.SigData = append(sig.SigData, sigData)
+		}
+		return sig
+	}
+
+	testCases := []struct {
+		name    string
+		build   func() *types.MutableTransaction
+		field   string
+		message string
+	}{
+		{
+			name: "valid",
+			build: func() *types.MutableTransaction {
+				tx := newTx(500)
+				assert.Nil(t, sdk.SignToTransaction(tx, acc1))
+				return tx
+			},
+		},
+		{
+			name: "missing payer signature",
+			build: func() *types.MutableTransaction {
+				tx := newTx(500)
+				assert.Nil(t, sdk.SignToTransaction(tx, acc2))
+				return tx
+			},
+			field:   "payer",
+			message: "has not signed",
+		},
+		{
+			name: "m bigger than pubkey count",
+			build: func() *types.MutableTransaction {
+				tx := newTx(500)
+				assert.Nil(t, sdk.SignToTransaction(tx, acc1))
+				tx.Sigs = append(tx.Sigs, newMultiSig(tx, 3, NewAccount(), NewAccount()))
+				return tx
+			},
+			field:   "sigs[1]",
+			message: "m:3 should be between 1 and pubkey count:2",
+		},
+		{
+			name: "too many pubkeys",
+			build: func() *types.MutableTransaction {
+				tx := newTx(500)
+				assert.Nil(t, sdk.SignToTransaction(tx, acc1))
+				accs := make([]*Account, 0, constants.MULTI_SIG_MAX_PUBKEY_SIZE+1)
+				for i := 0; i <= constants.MULTI_SIG_MAX_PUBKEY_SIZE; i++ {
+					accs = append(accs, NewAccount())
+				}
+				sig := newMultiSig(tx, 1, accs...)
+				sig.SigData = sig.SigData[:1]
+				tx.Sigs = append(tx.Sigs, sig)
+				return tx
+			},
+			field:   "sigs[1]",
+			message: "too many pubkeys",
+		},
+		{
+			name: "zero gas price",
+			build: func() *types.MutableTransaction {
+				tx := newTx(0)
+				assert.Nil(t, sdk.SignToTransaction(tx, acc1))
+				return tx
+			},
+			field:   "gasPrice",
+			message: "gas price is zero",
+		},
+		{
+			name: "oversized payload",
+			build: func() *types.MutableTransaction {
+				tx := sdk.NewInvokeTransaction(500, 20000, make([]byte, MAX_TX_SIZE))
+				sdk.SetPayer(tx, acc1.Address)
+				assert.Nil(t, sdk.SignToTransaction(tx, acc1))
+				return tx
+			},
+			field:   "tx",
+			message: "exceed max size",
+		},
+		{
+			name: "duplicate signer",
+			build: func() *types.MutableTransaction {
+				tx := newTx(500)
+				assert.Nil(t, sdk.SignToTransaction(tx, acc1))
+				tx.Sigs = append(tx.Sigs, tx.Sigs[0])
+				return tx
+			},
+			field:   "sigs[1]",
+			message: "duplicate signer",
+		},
+		{
+			name: "bad signature",
+			build: func() *types.MutableTransaction {
+				tx := newTx(500)
+				sigData, err := acc1.Sign([]byte("other data"))
+				assert.Nil(t, err)
+				tx.Sigs = []types.Sig{{PubKeys: []keypair.PublicKey{acc1.PublicKey}, M: 1, SigData: [][]byte{sigData}}}
+				return tx
+			},
+			field:   "sigs[0]",
+			message: "does not match any pubkey",
+		},
+	}
+	for _, tc := range testCases {
+		problems := ValidateTransaction(tc.build())
+		if tc.field == "" {
+			assert.Equal(t, 0, len(problems), tc.name)
+			assert.Nil(t, problems.ToError(), tc.name)
+			continue
+		}
+		found := false
+		for _, p := range problems {
+			if p.Field == tc.field && strings.Contains(p.Message, tc.message) {
+				found = true
+				break
+			}
+		}
+		assert.True(t, found, "%s: %s", tc.name, problems.Error())
+		assert.NotNil(t, problems.ToError(), tc.name)
+	}
+}
@@ -0,0 +1,233 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"errors"
+	"fmt"
+	"github.com/TesraSupernet/tesracrypto/ec"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	s "github.com/TesraSupernet/tesracrypto/signature"
+	"golang.org/x/crypto/ed25519"
+	"math/big"
+	"sync"
+	"time"
+)
+
+var ERR_ACCOUNT_LOCKED = errors.New("account is locked")
+var ERR_ACCOUNT_NOT_UNLOCKED = errors.New("account is not unlocked")
+
+//UnlockOptions limit the use of unlocked account, account is locked when either limit is reached.
+//Zero means no limit
+type UnlockOptions struct {
+	Duration time.Duration //how long account keeps unlocked
+	MaxSigns int           //how many signatures can be made
+}
+
+//UnlockManager decrypt account of wallet once, and sign with it until it is locked or the limit of
+//UnlockOptions is reached. Private key is wiped from memory when account is locked. Account with
+//AccountData.Lock set cannot be unlocked or used to sign.
+type UnlockManager struct {
+	wallet   *Wallet
+	sessions map[string]*unlockSession
+	lock     sync.Mutex
+}
+
+//NewUnlockManager return UnlockManager of accounts in wallet
+func NewUnlockManager(wallet *Wallet) *UnlockManager {
+	return &UnlockManager{
+		wallet:   wallet,
+		sessions: make(map[string]*unlockSession),
+	}
+}
+
+//Unlock decrypt account of address with passwd, and return Signer of it. If account has been unlocked,
+//the previous Signer is locked. opts nil means no limit
+func (this *UnlockManager) Unlock(address string, passwd []byte, opts *UnlockOptions) (Signer, error) {
+	accData, err := this.wallet.GetAccountDataByAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if accData.Lock {
+		return nil, ERR_ACCOUNT_LOCKED
+	}
+	acc, err := accData.GetAccount(passwd)
+	if err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &UnlockOptions{}
+	}
+	if opts.Duration < 0 || opts.MaxSigns < 0 {
+		WipePrivateKey(acc.PrivateKey)
+		return nil, fmt.Errorf("invalid unlock options")
+	}
+	session := &unlockSession{
+		manager:   this,
+		address:   address,
+		account:   acc,
+		publicKey: acc.PublicKey,
+		sigScheme: acc.SigScheme,
+		signsLeft: opts.MaxSigns,
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	old, ok := this.sessions[address]
+	if ok {
+		old.wipe()
+	}
+	this.sessions[address] = session
+	if opts.Duration > 0 {
+		session.timer = time.AfterFunc(opts.Duration, func() {
+			this.lockSession(session)
+		})
+	}
+	return session, nil
+}
+
+//GetSigner return Signer of account unlocked, ERR_ACCOUNT_NOT_UNLOCKED if account is not unlocked or locked already
+func (this *UnlockManager) GetSigner(address string) (Signer, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	session, ok := this.sessions[address]
+	if !ok {
+		return nil, ERR_ACCOUNT_NOT_UNLOCKED
+	}
+	return session, nil
+}
+
+//IsUnlocked return whether account of address is unlocked
+func (this *UnlockManager) IsUnlocked(address string) bool {
+	_, err := this.GetSigner(address)
+	return err == nil
+}
+
+//Lock lock account of address and wipe its private key
+func (this *UnlockManager) Lock(address string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	session, ok := this.sessions[address]
+	if !ok {
+		return
+	}
+	session.wipe()
+	delete(this.sessions, address)
+}
+
+//LockAll lock all of the accounts unlocked
+func (this *UnlockManager) LockAll() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for address, session := range this.sessions {
+		session.wipe()
+		delete(this.sessions, address)
+	}
+}
+
+//lockSession lock session if it is still the session of its address
+func (this *UnlockManager) lockSession(session *unlockSession) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	session.wipe()
+	if this.sessions[session.address] == session {
+		delete(this.sessions, session.address)
+	}
+}
+
+//unlockSession is the Signer returned by UnlockManager, private key is not exposed
+type unlockSession struct {
+	manager   *UnlockManager
+	address   string
+	account   *Account //nil after locked
+	publicKey keypair.PublicKey
+	sigScheme s.SignatureScheme
+	signsLeft int //0 means no limit
+	timer     *time.Timer
+	lock      sync.Mutex
+}
+
+func (this *unlockSession) Sign(data []byte) ([]byte, error) {
+	accData, err := this.manager.wallet.GetAccountDataByAddress(this.address)
+	if err != nil || accData.Lock {
+		this.manager.lockSession(this)
+		return nil, ERR_ACCOUNT_LOCKED
+	}
+	this.lock.Lock()
+	if this.account == nil {
+		this.lock.Unlock()
+		return nil, ERR_ACCOUNT_NOT_UNLOCKED
+	}
+	sigData, err := this.account.Sign(data)
+	exhausted := false
+	if err == nil && this.signsLeft > 0 {
+		this.signsLeft--
+		exhausted = this.signsLeft == 0
+	}
+	this.lock.Unlock()
+	if exhausted {
+		this.manager.lockSession(this)
+	}
+	return sigData, err
+}
+
+func (this *unlockSession) GetPublicKey() keypair.PublicKey {
+	return this.publicKey
+}
+
+func (this *unlockSession) GetPrivateKey() keypair.PrivateKey {
+	return nil
+}
+
+func (this *unlockSession) GetSigScheme() s.SignatureScheme {
+	return this.sigScheme
+}
+
+func (this *unlockSession) wipe() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.timer != nil {
+		this.timer.Stop()
+	}
+	if this.account == nil {
+		return
+	}
+	WipePrivateKey(this.account.PrivateKey)
+	this.account = nil
+}
+
+//WipePrivateKey overwrite the bytes of private key with zero, private key cannot be used after wiped
+func WipePrivateKey(privateKey keypair.PrivateKey) {
+	switch key := privateKey.(type) {
+	case *ec.PrivateKey:
+		if key.PrivateKey != nil && key.D != nil {
+			wipeBigInt(key.D)
+		}
+	case ed25519.PrivateKey:
+		for i := range key {
+			key[i] = 0
+		}
+	}
+}
+
+func wipeBigInt(n *big.Int) {
+	words := n.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	n.SetInt64(0)
+}
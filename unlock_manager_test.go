@@ -0,0 +1,91 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"github.com/TesraSupernet/tesracrypto/ec"
+	s "github.com/TesraSupernet/tesracrypto/signature"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+	"testing"
+	"time"
+)
+
+func TestUnlockManager(t *testing.T) {
+	passwd := []byte("123456")
+	wallet := NewWalletWithKeyStore(NewMemoryKeyStore(nil))
+	acc, err := wallet.NewDefaultSettingAccount(passwd)
+	assert.Nil(t, err)
+	address := acc.Address.ToBase58()
+	manager := NewUnlockManager(wallet)
+
+	_, err = manager.GetSigner(address)
+	assert.Equal(t, ERR_ACCOUNT_NOT_UNLOCKED, err)
+	_, err = manager.Unlock(address, []byte("wrong"), nil)
+	assert.NotNil(t, err)
+
+	signer, err := manager.Unlock(address, passwd, &UnlockOptions{MaxSigns: 2})
+	assert.Nil(t, err)
+	assert.Nil(t, signer.GetPrivateKey())
+	assert.Equal(t, acc.PublicKey, signer.GetPublicKey())
+	data := []byte("hello")
+	for i := 0; i < 2; i++ {
+		sigData, err := signer.Sign(data)
+		assert.Nil(t, err)
+		sig, err := s.Deserialize(sigData)
+		assert.Nil(t, err)
+		assert.True(t, s.Verify(acc.PublicKey, data, sig))
+	}
+	_, err = signer.Sign(data)
+	assert.Equal(t, ERR_ACCOUNT_NOT_UNLOCKED, err)
+	assert.False(t, manager.IsUnlocked(address))
+
+	signer, err = manager.Unlock(address, passwd, &UnlockOptions{Duration: 50 * time.Millisecond})
+	assert.Nil(t, err)
+	_, err = signer.Sign(data)
+	assert.Nil(t, err)
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, manager.IsUnlocked(address))
+	_, err = signer.Sign(data)
+	assert.Equal(t, ERR_ACCOUNT_NOT_UNLOCKED, err)
+
+	signer, err = manager.Unlock(address, passwd, nil)
+	assert.Nil(t, err)
+	manager.Lock(address)
+	_, err = signer.Sign(data)
+	assert.Equal(t, ERR_ACCOUNT_NOT_UNLOCKED, err)
+
+	signer, err = manager.Unlock(address, passwd, nil)
+	assert.Nil(t, err)
+	wallet.accAddressMap[address].Lock = true
+	_, err = signer.Sign(data)
+	assert.Equal(t, ERR_ACCOUNT_LOCKED, err)
+	assert.False(t, manager.IsUnlocked(address))
+	_, err = manager.Unlock(address, passwd, nil)
+	assert.Equal(t, ERR_ACCOUNT_LOCKED, err)
+}
+
+func TestWipePrivateKey(t *testing.T) {
+	acc := NewAccount()
+	WipePrivateKey(acc.PrivateKey)
+	assert.Equal(t, 0, acc.PrivateKey.(*ec.PrivateKey).D.Sign())
+
+	acc = NewAccount(s.SHA512withEDDSA)
+	WipePrivateKey(acc.PrivateKey)
+	assert.Equal(t, make([]byte, ed25519.PrivateKeySize), []byte(acc.PrivateKey.(ed25519.PrivateKey)))
+}
@@ -24,10 +24,8 @@ import (
 	"fmt"
 	"github.com/TesraSupernet/tesracrypto/keypair"
 	s "github.com/TesraSupernet/tesracrypto/signature"
-	"github.com/TesraSupernet/Tesra/common"
 	"github.com/TesraSupernet/Tesra/core/types"
 	"io/ioutil"
-	"os"
 	"sync"
 )
 
@@ -50,12 +48,21 @@ type Wallet struct {
 	identityMap      map[string]*Identity
 	identityLabelMap map[string]*Identity
 	defIdentity      *Identity
-	path             string
+	hdSeed           *HDSeedData
+	multiSigAccounts []*MultiSigAccountData
+	store            KeyStore
 	tesraSdk           *TesraSdk
+	extraFields      map[string]json.RawMessage
 	lock             sync.RWMutex
 }
 
+//NewWallet return an empty wallet which is saved to wallet file of path
 func NewWallet(path string) *Wallet {
+	return NewWalletWithKeyStore(NewFileKeyStore(path))
+}
+
+//NewWalletWithKeyStore return an empty wallet which is saved to store
+func NewWalletWithKeyStore(store KeyStore) *Wallet {
 	return &Wallet{
 		Name:             DEFAULT_WALLET_NAME,
 		Version:          DEFAULT_WALLET_VERSION,
@@ -66,21 +73,25 @@ func NewWallet(path string) *Wallet {
 		identities:       make([]*Identity, 0),
 		identityMap:      make(map[string]*Identity),
 		identityLabelMap: make(map[string]*Identity),
-		path:             path,
+		store:            store,
 	}
 }
 
+//OpenWallet load wallet file, wallet of older version or written by other Tesra/Ontology-family tools is migrated,
+//see ParseWalletData
 func OpenWallet(path string) (*Wallet, error) {
-	walletData := &WalletData{}
-	err := walletData.Load(path)
-	if err != nil {
-		return nil, err
-	}
-	wallet := NewWallet(path)
+	return OpenWalletWithKeyStore(NewFileKeyStore(path))
+}
+
+func newWalletFromWalletData(store KeyStore, walletData *WalletData) (*Wallet, error) {
+	var err error
+	wallet := NewWalletWithKeyStore(store)
+	wallet.extraFields = walletData.extraFields
 	wallet.Name = walletData.Name
 	wallet.Version = walletData.Version
 	wallet.Scrypt = walletData.Scrypt
 	wallet.Extra = walletData.Extra
+	wallet.hdSeed = walletData.HDSeed
 	for _, accountData := range walletData.Accounts {
 		accountData.scrypt = wallet.Scrypt
 		if accountData.IsDefault {
@@ -102,9 +113,18 @@ func OpenWallet(path string) (*Wallet, error) {
 	if wallet.defAcc == nil && len(walletData.Accounts) > 0 {
 		wallet.defAcc = walletData.Accounts[0]
 	}
+	for _, msAcc := range walletData.MultiSigAccounts {
+		err = wallet.AddMultiSigAccountData(msAcc)
+		if err != nil {
+			return nil, fmt.Errorf("load multi-sig account:%s error:%s", msAcc.Address, err)
+		}
+	}
 
 	for _, identityData := range walletData.Identities {
 		identityData.scrypt = wallet.Scrypt
+		for _, ctrData := range identityData.Control {
+			ctrData.scrypt = wallet.Scrypt
+		}
 		identity, err := NewIdentityFromIdentityData(identityData)
 		if err != nil {
 			return nil, fmt.Errorf("NewIdentityFromIdentityData error:%s", err)
@@ -208,6 +228,7 @@ func (this *Wallet) AddAccountData(accountData *AccountData) error {
 	}
 	this.accAddressMap[accountData.Address] = accountData
 	this.accounts = append(this.accounts, accountData)
+	this.updateMultiSigCoSigners()
 	return nil
 }
 
@@ -237,6 +258,7 @@ func (this *Wallet) DeleteAccount(address string) error {
 		}
 		break
 	}
+	this.updateMultiSigCoSigners()
 	return nil
 }
 
@@ -390,6 +412,9 @@ func (this *Wallet) ChangeAccountPassword(address string, oldPassword, newPasswo
 	if !ok {
 		return ERR_ACCOUNT_NOT_FOUND
 	}
+	if accData.WatchOnly {
+		return ERR_WATCH_ONLY_ACCOUNT
+	}
 	protectedKey, err := keypair.ReencryptPrivateKey(&accData.ProtectedKey, oldPassword, newPassword, this.Scrypt, this.Scrypt)
 	if err != nil {
 		return err
@@ -404,9 +429,13 @@ func (this *Wallet) ImportAccounts(accountDatas []*AccountData, passwds [][]byte
 	}
 	for i := 0; i < len(accountDatas); i++ {
 		accData := accountDatas[i]
-		protectedkey, err := keypair.ReencryptPrivateKey(&accData.ProtectedKey, passwds[i], passwds[i], accData.GetScrypt(), this.Scrypt)
-		if err != nil {
-			return fmt.Errorf("ReencryptPrivateKey address:%s error:%s", accData.Address, err)
+		protectedkey := accData.GetKeyPair()
+		var err error
+		if !accData.WatchOnly {
+			protectedkey, err = keypair.ReencryptPrivateKey(&accData.ProtectedKey, passwds[i], passwds[i], accData.GetScrypt(), this.Scrypt)
+			if err != nil {
+				return fmt.Errorf("ReencryptPrivateKey address:%s error:%s", accData.Address, err)
+			}
 		}
 		newAccData := &AccountData{
 			PubKey:    accData.PubKey,
@@ -414,8 +443,10 @@ func (this *Wallet) ImportAccounts(accountDatas []*AccountData, passwds [][]byte
 			Lock:      accData.Lock,
 			IsDefault: false,
 			Label:     accData.Label,
+			WatchOnly: accData.WatchOnly,
 		}
 		newAccData.SetKeyPair(protectedkey)
+		newAccData.SetScript(this.Scrypt)
 		_, err = this.GetAccountDataByLabel(accData.Label)
 		if err != nil {
 			//duplicate label, rename
@@ -443,9 +474,13 @@ func (this *Wallet) ExportAccounts(path string, accountDatas []*AccountData, pas
 	newWallet.Scrypt = &newScrypt
 	for i := 0; i < len(accountDatas); i++ {
 		accData := accountDatas[i]
-		protectedkey, err := keypair.ReencryptPrivateKey(&accData.ProtectedKey, passwds[i], passwds[i], this.Scrypt, &newScrypt)
-		if err != nil {
-			return nil, fmt.Errorf("ReencryptPrivateKey address:%s error:%s", accData.Address, err)
+		protectedkey := accData.GetKeyPair()
+		var err error
+		if !accData.WatchOnly {
+			protectedkey, err = keypair.ReencryptPrivateKey(&accData.ProtectedKey, passwds[i], passwds[i], this.Scrypt, &newScrypt)
+			if err != nil {
+				return nil, fmt.Errorf("ReencryptPrivateKey address:%s error:%s", accData.Address, err)
+			}
 		}
 		newAccData := &AccountData{
 			PubKey:    accData.PubKey,
@@ -453,8 +488,10 @@ func (this *Wallet) ExportAccounts(path string, accountDatas []*AccountData, pas
 			Lock:      accData.Lock,
 			IsDefault: false,
 			Label:     accData.Label,
+			WatchOnly: accData.WatchOnly,
 		}
 		newAccData.SetKeyPair(protectedkey)
+		newAccData.SetScript(&newScrypt)
 		err = newWallet.AddAccountData(newAccData)
 		if err != nil {
 			return nil, fmt.Errorf("export account:%s error:%s", accData.Address, err)
@@ -617,8 +654,19 @@ func (this *Wallet) GetIdentityCount() int {
 	return len(this.identities)
 }
 
+//Save write wallet to file, ERR_WALLET_MODIFIED is returned if wallet file is modified externally since loaded
 func (this *Wallet) Save() error {
+	return this.save(false)
+}
+
+func (this *Wallet) getWalletData() *WalletData {
 	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.toWalletData()
+}
+
+//toWalletData return the wallet data to save, caller should hold the lock of wallet
+func (this *Wallet) toWalletData() *WalletData {
 	walletData := &WalletData{
 		Name:       this.Name,
 		Version:    this.Version,
@@ -627,23 +675,32 @@ func (this *Wallet) Save() error {
 		Accounts:   make([]*AccountData, 0),
 		Extra:      this.Extra,
 	}
+	walletData.extraFields = this.extraFields
+	if this.hdSeed != nil {
+		walletData.HDSeed = this.hdSeed.Clone()
+	}
 	for _, identity := range this.identities {
 		walletData.Identities = append(walletData.Identities, identity.ToIdentityData())
 	}
 	for _, acc := range this.accounts {
 		walletData.Accounts = append(walletData.Accounts, acc)
 	}
-	this.lock.RUnlock()
-	return walletData.Save(this.path)
+	for _, msAcc := range this.multiSigAccounts {
+		walletData.MultiSigAccounts = append(walletData.MultiSigAccounts, msAcc.Clone())
+	}
+	return walletData
 }
 
 type WalletData struct {
-	Name       string               `json:"name"`
-	Version    string               `json:"version"`
-	Scrypt     *keypair.ScryptParam `json:"scrypt"`
-	Identities []*IdentityData      `json:"identities,omitempty"`
-	Accounts   []*AccountData       `json:"accounts,omitempty"`
-	Extra      string               `json:"extra,omitempty"`
+	Name             string                 `json:"name"`
+	Version          string                 `json:"version"`
+	Scrypt           *keypair.ScryptParam   `json:"scrypt"`
+	Identities       []*IdentityData        `json:"identities,omitempty"`
+	Accounts         []*AccountData         `json:"accounts,omitempty"`
+	Extra            string                 `json:"extra,omitempty"`
+	HDSeed           *HDSeedData            `json:"hdSeed,omitempty"`
+	MultiSigAccounts []*MultiSigAccountData `json:"multiSigAccounts,omitempty"`
+	extraFields      map[string]json.RawMessage
 }
 
 func NewWalletData() *WalletData {
@@ -671,32 +728,40 @@ func (this *WalletData) Clone() *WalletData {
 	}
 	w.Identities = this.Identities
 	w.Extra = this.Extra
+	if this.HDSeed != nil {
+		w.HDSeed = this.HDSeed.Clone()
+	}
+	for _, msAcc := range this.MultiSigAccounts {
+		w.MultiSigAccounts = append(w.MultiSigAccounts, msAcc.Clone())
+	}
+	w.extraFields = this.extraFields
 	return &w
 }
 
+func (this *WalletData) marshal() ([]byte, error) {
+	return json.Marshal(this)
+}
+
 func (this *WalletData) Save(path string) error {
-	data, err := json.Marshal(this)
+	data, err := this.marshal()
 	if err != nil {
 		return err
 	}
-	if common.FileExisted(path) {
-		filename := path + "~"
-		err := ioutil.WriteFile(filename, data, 0644)
-		if err != nil {
-			return err
-		}
-		return os.Rename(filename, path)
-	} else {
-		return ioutil.WriteFile(path, data, 0644)
-	}
+	return writeWalletFile(path, data, DEFAULT_WALLET_BACKUP_COUNT)
 }
 
+//Load read wallet file, migrate and validate it, see ParseWalletData
 func (this *WalletData) Load(path string) error {
 	msh, err := ioutil.ReadFile(path)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(msh, this)
+	walletData, err := ParseWalletData(msh)
+	if err != nil {
+		return err
+	}
+	*this = *walletData
+	return nil
 }
 
 func ScryptEqual(s1, s2 *keypair.ScryptParam) bool {
@@ -0,0 +1,350 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+//WALLET_FILE_MODE is the permission of wallet file and its backups
+var WALLET_FILE_MODE os.FileMode = 0600
+
+//DEFAULT_WALLET_BACKUP_COUNT is the count of rotating backups kept when wallet is saved, the newest is path.bak.1
+var DEFAULT_WALLET_BACKUP_COUNT = 3
+
+var ERR_WALLET_LOCKED = errors.New("wallet file is locked by another process")
+var ERR_WALLET_MODIFIED = errors.New("wallet file is modified externally since loaded")
+
+//GetWalletBackupPath return the path of backup index of wallet file, index start from 1
+func GetWalletBackupPath(path string, index int) string {
+	return fmt.Sprintf("%s.bak.%d", path, index)
+}
+
+//FileKeyStore is the KeyStore of a single wallet file. Wallet file is written with restrictive permission, synced
+//to disk and replaced by rename, with rotating backups. Saving is refused if the file is modified externally
+//since it was loaded or saved by this store
+type FileKeyStore struct {
+	path        string
+	fileLock    *walletFileLock
+	fileHash    []byte
+	backupCount int
+	lock        sync.Mutex
+}
+
+//NewFileKeyStore return the KeyStore of wallet file of path
+func NewFileKeyStore(path string) *FileKeyStore {
+	return &FileKeyStore{
+		path:        path,
+		backupCount: DEFAULT_WALLET_BACKUP_COUNT,
+	}
+}
+
+//OpenFileKeyStoreForWrite return the KeyStore of wallet file which holds the advisory lock of wallet file until
+//Close, so that other process cannot open it for write
+func OpenFileKeyStoreForWrite(path string) (*FileKeyStore, error) {
+	fileLock, err := lockWalletFile(path)
+	if err != nil {
+		return nil, err
+	}
+	store := NewFileKeyStore(path)
+	store.fileLock = fileLock
+	return store, nil
+}
+
+//GetPath return the path of wallet file
+func (this *FileKeyStore) GetPath() string {
+	return this.path
+}
+
+//SetBackupCount set the count of rotating backups kept when wallet is saved, 0 means no backup
+func (this *FileKeyStore) SetBackupCount(count int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.backupCount = count
+}
+
+//IsModified return whether wallet file is modified externally since it was loaded or saved by this store
+func (this *FileKeyStore) IsModified() (bool, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.isModified()
+}
+
+func (this *FileKeyStore) isModified() (bool, error) {
+	hash, err := getWalletFileHash(this.path)
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(hash, this.fileHash), nil
+}
+
+//IsAtomic return true, wallet file is replaced by rename
+func (this *FileKeyStore) IsAtomic() bool {
+	return true
+}
+
+//Load read wallet file, see ParseWalletData
+func (this *FileKeyStore) Load() (*WalletData, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	data, err := ioutil.ReadFile(this.path)
+	if err != nil {
+		return nil, err
+	}
+	walletData, err := ParseWalletData(data)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(data)
+	this.fileHash = hash[:]
+	return walletData, nil
+}
+
+//Save write wallet file, ERR_WALLET_MODIFIED is returned if wallet file is modified externally
+func (this *FileKeyStore) Save(walletData *WalletData) error {
+	return this.save(walletData, false)
+}
+
+//ForceSave write wallet file even if wallet file is modified externally
+func (this *FileKeyStore) ForceSave(walletData *WalletData) error {
+	return this.save(walletData, true)
+}
+
+func (this *FileKeyStore) save(walletData *WalletData, force bool) error {
+	data, err := walletData.marshal()
+	if err != nil {
+		return err
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.fileLock == nil {
+		fileLock, err := lockWalletFile(this.path)
+		if err != nil {
+			return err
+		}
+		defer fileLock.Unlock()
+	}
+	if !force {
+		modified, err := this.isModified()
+		if err != nil {
+			return err
+		}
+		if modified {
+			return ERR_WALLET_MODIFIED
+		}
+	}
+	err = writeWalletFile(this.path, data, this.backupCount)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(data)
+	this.fileHash = hash[:]
+	return nil
+}
+
+//RemoveBackups remove the rotating backups of wallet file, such as after rekey, since backups keep the keys
+//encrypted by old passwords
+func (this *FileKeyStore) RemoveBackups() error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.fileLock == nil {
+		fileLock, err := lockWalletFile(this.path)
+		if err != nil {
+			return err
+		}
+		defer fileLock.Unlock()
+	}
+	return removeWalletBackups(this.path, this.backupCount)
+}
+
+//Close release the lock of wallet file held by OpenFileKeyStoreForWrite
+func (this *FileKeyStore) Close() error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.fileLock == nil {
+		return nil
+	}
+	err := this.fileLock.Unlock()
+	this.fileLock = nil
+	return err
+}
+
+//OpenWalletForWrite open wallet and hold the advisory lock of wallet file until Close, so that other process cannot
+//open it for write
+func OpenWalletForWrite(path string) (*Wallet, error) {
+	store, err := OpenFileKeyStoreForWrite(path)
+	if err != nil {
+		return nil, err
+	}
+	wallet, err := OpenWalletWithKeyStore(store)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	return wallet, nil
+}
+
+//SetBackupCount set the count of rotating backups kept when wallet is saved to file, 0 means no backup
+func (this *Wallet) SetBackupCount(count int) {
+	store, ok := this.store.(*FileKeyStore)
+	if ok {
+		store.SetBackupCount(count)
+	}
+}
+
+//IsModified return whether wallet file is modified externally since it was loaded or saved by this wallet,
+//always false if wallet is not saved to file
+func (this *Wallet) IsModified() (bool, error) {
+	store, ok := this.store.(*FileKeyStore)
+	if !ok {
+		return false, nil
+	}
+	return store.IsModified()
+}
+
+//ForceSave save wallet even if wallet file is modified externally
+func (this *Wallet) ForceSave() error {
+	return this.save(true)
+}
+
+func (this *Wallet) save(force bool) error {
+	walletData := this.getWalletData()
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.writeWalletData(walletData, force)
+}
+
+//writeWalletData save walletData to key store, caller should hold the lock of wallet
+func (this *Wallet) writeWalletData(walletData *WalletData, force bool) error {
+	if force {
+		store, ok := this.store.(*FileKeyStore)
+		if ok {
+			return store.ForceSave(walletData)
+		}
+	}
+	return this.store.Save(walletData)
+}
+
+//getWalletFileHash return the sha256 of wallet file, nil if file does not exist
+func getWalletFileHash(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
+
+//writeWalletFile write data to a synced temp file in the same directory, rotate backups, then rename the temp file
+//to path and sync the directory, so that path is either the old or the new wallet after crash
+func writeWalletFile(path string, data []byte, backupCount int) error {
+	dir := filepath.Dir(path)
+	tmp, err := ioutil.TempFile(dir, filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file error:%s", err)
+	}
+	tmpName := tmp.Name()
+	err = writeAndSync(tmp, data)
+	if err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if backupCount > 0 && common.FileExisted(path) {
+		err = rotateWalletBackups(path, backupCount)
+		if err != nil {
+			os.Remove(tmpName)
+			return err
+		}
+	}
+	err = os.Rename(tmpName, path)
+	if err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("rename wallet file error:%s", err)
+	}
+	return syncDir(dir)
+}
+
+func writeAndSync(file *os.File, data []byte) error {
+	err := file.Chmod(WALLET_FILE_MODE)
+	if err == nil {
+		_, err = file.Write(data)
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("write wallet file error:%s", err)
+	}
+	return nil
+}
+
+//rotateWalletBackups move backup i to i+1 and copy current wallet file to backup 1, the oldest is dropped
+func rotateWalletBackups(path string, count int) error {
+	os.Remove(GetWalletBackupPath(path, count))
+	for i := count - 1; i >= 1; i-- {
+		backup := GetWalletBackupPath(path, i)
+		if !common.FileExisted(backup) {
+			continue
+		}
+		err := os.Rename(backup, GetWalletBackupPath(path, i+1))
+		if err != nil {
+			return fmt.Errorf("rotate wallet backup error:%s", err)
+		}
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read wallet file error:%s", err)
+	}
+	file, err := os.OpenFile(GetWalletBackupPath(path, 1), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, WALLET_FILE_MODE)
+	if err != nil {
+		return fmt.Errorf("create wallet backup error:%s", err)
+	}
+	return writeAndSync(file, data)
+}
+
+//removeWalletBackups remove backups of wallet file up to count, and the older ones left by a larger count before
+func removeWalletBackups(path string, count int) error {
+	for i := 1; ; i++ {
+		err := os.Remove(GetWalletBackupPath(path, i))
+		if os.IsNotExist(err) {
+			if i > count {
+				break
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("remove wallet backup error:%s", err)
+		}
+	}
+	return syncDir(filepath.Dir(path))
+}
@@ -0,0 +1,53 @@
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly,!windows
+
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"fmt"
+	"os"
+)
+
+//walletFileLock is the exclusively created path.lock, it is left if process crashes and should be removed by hand
+type walletFileLock struct {
+	file *os.File
+	path string
+}
+
+func lockWalletFile(path string) (*walletFileLock, error) {
+	lockPath := path + ".lock"
+	file, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, WALLET_FILE_MODE)
+	if os.IsExist(err) {
+		return nil, ERR_WALLET_LOCKED
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create lock file error:%s", err)
+	}
+	return &walletFileLock{file: file, path: lockPath}, nil
+}
+
+func (this *walletFileLock) Unlock() error {
+	this.file.Close()
+	return os.Remove(this.path)
+}
+
+//syncDir is not supported, rename is flushed by the file system
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWallet_SaveWithBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet_file_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "wallet.dat")
+	passwd := []byte("123456")
+
+	wallet := NewWallet(path)
+	wallet.SetBackupCount(2)
+	for i := 0; i < 4; i++ {
+		_, err = wallet.NewDefaultSettingAccount(passwd)
+		assert.Nil(t, err)
+		assert.Nil(t, wallet.Save())
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		assert.Nil(t, err)
+		assert.Equal(t, WALLET_FILE_MODE, info.Mode().Perm())
+	}
+	backup, err := OpenWallet(GetWalletBackupPath(path, 1))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, backup.GetAccountCount())
+	backup, err = OpenWallet(GetWalletBackupPath(path, 2))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, backup.GetAccountCount())
+	_, err = os.Stat(GetWalletBackupPath(path, 3))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestWallet_SaveModified(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet_file_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "wallet.dat")
+	passwd := []byte("123456")
+
+	wallet := NewWallet(path)
+	_, err = wallet.NewDefaultSettingAccount(passwd)
+	assert.Nil(t, err)
+	assert.Nil(t, wallet.Save())
+
+	other, err := OpenWallet(path)
+	assert.Nil(t, err)
+	_, err = other.NewDefaultSettingAccount(passwd)
+	assert.Nil(t, err)
+	assert.Nil(t, other.Save())
+
+	modified, err := wallet.IsModified()
+	assert.Nil(t, err)
+	assert.True(t, modified)
+	assert.Equal(t, ERR_WALLET_MODIFIED, wallet.Save())
+	assert.Nil(t, wallet.ForceSave())
+	wallet, err = OpenWallet(path)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, wallet.GetAccountCount())
+}
+
+func TestOpenWalletForWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet_file_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "wallet.dat")
+	assert.Nil(t, NewWallet(path).Save())
+
+	wallet, err := OpenWalletForWrite(path)
+	assert.Nil(t, err)
+	_, err = OpenWalletForWrite(path)
+	assert.Equal(t, ERR_WALLET_LOCKED, err)
+	_, err = wallet.NewDefaultSettingAccount([]byte("123456"))
+	assert.Nil(t, err)
+	assert.Nil(t, wallet.Save())
+	assert.Nil(t, wallet.Close())
+
+	wallet, err = OpenWalletForWrite(path)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, wallet.GetAccountCount())
+	assert.Nil(t, wallet.Close())
+}
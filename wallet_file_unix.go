@@ -0,0 +1,69 @@
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+//walletFileLock is the flock of path.lock, the wallet file itself is replaced by rename so it cannot be locked
+type walletFileLock struct {
+	file *os.File
+}
+
+func lockWalletFile(path string) (*walletFileLock, error) {
+	file, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, WALLET_FILE_MODE)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file error:%s", err)
+	}
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err != nil {
+		file.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, ERR_WALLET_LOCKED
+		}
+		return nil, fmt.Errorf("lock wallet file error:%s", err)
+	}
+	return &walletFileLock{file: file}, nil
+}
+
+func (this *walletFileLock) Unlock() error {
+	err := syscall.Flock(int(this.file.Fd()), syscall.LOCK_UN)
+	closeErr := this.file.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("open dir error:%s", err)
+	}
+	defer file.Close()
+	err = file.Sync()
+	if err != nil {
+		return fmt.Errorf("sync dir error:%s", err)
+	}
+	return nil
+}
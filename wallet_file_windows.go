@@ -0,0 +1,78 @@
+// +build windows
+
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	lockfileFailImmediately = 0x00000001
+	lockfileExclusiveLock   = 0x00000002
+
+	errorLockViolation syscall.Errno = 33
+)
+
+var (
+	modkernel32      = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = modkernel32.NewProc("LockFileEx")
+	procUnlockFileEx = modkernel32.NewProc("UnlockFileEx")
+)
+
+//walletFileLock is the LockFileEx lock of path.lock, the wallet file itself is replaced by rename so it cannot be locked
+type walletFileLock struct {
+	file *os.File
+}
+
+func lockWalletFile(path string) (*walletFileLock, error) {
+	file, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, WALLET_FILE_MODE)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file error:%s", err)
+	}
+	ol := new(syscall.Overlapped)
+	r1, _, e1 := procLockFileEx.Call(file.Fd(), uintptr(lockfileExclusiveLock|lockfileFailImmediately),
+		0, 1, 0, uintptr(unsafe.Pointer(ol)))
+	if r1 == 0 {
+		file.Close()
+		if e1 == errorLockViolation {
+			return nil, ERR_WALLET_LOCKED
+		}
+		return nil, fmt.Errorf("lock wallet file error:%s", e1)
+	}
+	return &walletFileLock{file: file}, nil
+}
+
+func (this *walletFileLock) Unlock() error {
+	ol := new(syscall.Overlapped)
+	r1, _, e1 := procUnlockFileEx.Call(this.file.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(ol)))
+	closeErr := this.file.Close()
+	if r1 == 0 {
+		return e1
+	}
+	return closeErr
+}
+
+//syncDir is not supported on windows, rename is flushed by the file system
+func syncDir(dir string) error {
+	return nil
+}
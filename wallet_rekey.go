@@ -0,0 +1,156 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"fmt"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+)
+
+//HD_SEED_REKEY_KEY is the key of WalletRekeyParam.Passwords for the HD seed of wallet
+const HD_SEED_REKEY_KEY = "hdSeed"
+
+//RekeyPassword is the old and new password of one key, nil means the default password of WalletRekeyParam
+type RekeyPassword struct {
+	Old []byte
+	New []byte
+}
+
+//WalletRekeyParam is the param of Wallet.Rekey
+type WalletRekeyParam struct {
+	OldPassword []byte
+	NewPassword []byte
+	Passwords   map[string]*RekeyPassword //passwords keyed by account address, identity id or HD_SEED_REKEY_KEY
+	Scrypt      *keypair.ScryptParam      //new scrypt param, nil keeps the scrypt of wallet
+	KeepBackups bool                      //keep the backups of wallet file, which are encrypted by old passwords
+}
+
+func (this *WalletRekeyParam) getPasswords(key string) ([]byte, []byte, error) {
+	oldPasswd, newPasswd := this.OldPassword, this.NewPassword
+	passwords, ok := this.Passwords[key]
+	if ok && passwords.Old != nil {
+		oldPasswd = passwords.Old
+	}
+	if ok && passwords.New != nil {
+		newPasswd = passwords.New
+	}
+	if len(newPasswd) == 0 {
+		return nil, nil, fmt.Errorf("new password of:%s cannot empty", key)
+	}
+	return oldPasswd, newPasswd, nil
+}
+
+//Rekey re-encrypt all of the private keys of accounts, controllers of identities and the HD seed with new
+//passwords and scrypt param, and save wallet. It is atomic: if any key fails to re-encrypt or wallet fails to save,
+//both wallet file and wallet in memory are untouched. After rekey, the backups of wallet file are removed unless
+//param.KeepBackups, an error is returned if they cannot be removed, though wallet has been rekeyed.
+//Key store of wallet must be AtomicKeyStore, otherwise keys may be saved partly with old passwords
+func (this *Wallet) Rekey(param *WalletRekeyParam) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if store, ok := this.store.(AtomicKeyStore); !ok || !store.IsAtomic() {
+		return fmt.Errorf("key store:%T cannot save atomically, rekey is not supported", this.store)
+	}
+	newScrypt := this.Scrypt
+	if param.Scrypt != nil {
+		sp := *param.Scrypt
+		newScrypt = &sp
+	}
+	accounts := make([]*AccountData, 0, len(this.accounts))
+	for _, accData := range this.accounts {
+		newAccData := accData.Clone()
+		newAccData.SetScript(newScrypt)
+		if !accData.WatchOnly {
+			oldPasswd, newPasswd, err := param.getPasswords(accData.Address)
+			if err != nil {
+				return err
+			}
+			protectedKey, err := keypair.ReencryptPrivateKey(&accData.ProtectedKey, oldPasswd, newPasswd, this.Scrypt, newScrypt)
+			if err != nil {
+				return fmt.Errorf("rekey account:%s error:%s", accData.Address, err)
+			}
+			newAccData.SetKeyPair(protectedKey)
+		}
+		accounts = append(accounts, newAccData)
+	}
+	identities := make([]*IdentityData, 0, len(this.identities))
+	for _, identity := range this.identities {
+		identityData := identity.ToIdentityData()
+		identityData.scrypt = newScrypt
+		if len(identityData.Control) > 0 {
+			oldPasswd, newPasswd, err := param.getPasswords(identity.ID)
+			if err != nil {
+				return err
+			}
+			for _, ctrData := range identityData.Control {
+				protectedKey, err := keypair.ReencryptPrivateKey(&ctrData.ProtectedKey, oldPasswd, newPasswd, this.Scrypt, newScrypt)
+				if err != nil {
+					return fmt.Errorf("rekey identity:%s controller:%s error:%s", identity.ID, ctrData.ID, err)
+				}
+				ctrData.SetKeyPair(protectedKey)
+				ctrData.scrypt = newScrypt
+			}
+		}
+		identities = append(identities, identityData)
+	}
+	var hdSeed *HDSeedData
+	if this.hdSeed != nil {
+		oldPasswd, newPasswd, err := param.getPasswords(HD_SEED_REKEY_KEY)
+		if err != nil {
+			return err
+		}
+		hdSeed, err = this.hdSeed.Reencrypt(oldPasswd, newPasswd, this.Scrypt, newScrypt)
+		if err != nil {
+			return fmt.Errorf("rekey HD seed error:%s", err)
+		}
+	}
+
+	walletData := this.toWalletData()
+	walletData.Scrypt = newScrypt
+	walletData.Accounts = accounts
+	walletData.Identities = identities
+	if hdSeed != nil {
+		walletData.HDSeed = hdSeed.Clone()
+	}
+	err := this.writeWalletData(walletData, false)
+	if err != nil {
+		return err
+	}
+
+	for i, accData := range this.accounts {
+		accData.SetKeyPair(accounts[i].GetKeyPair())
+		accData.SetScript(newScrypt)
+	}
+	for i, identity := range this.identities {
+		identity.scrypt = newScrypt
+		for j, ctrData := range identity.controllers {
+			ctrData.SetKeyPair(identities[i].Control[j].GetKeyPair())
+			ctrData.scrypt = newScrypt
+		}
+	}
+	this.hdSeed = hdSeed
+	this.Scrypt = newScrypt
+
+	if store, ok := this.store.(*FileKeyStore); ok && !param.KeepBackups {
+		err = store.RemoveBackups()
+		if err != nil {
+			return fmt.Errorf("wallet is rekeyed, but %s", err)
+		}
+	}
+	return nil
+}
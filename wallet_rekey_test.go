@@ -0,0 +1,107 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWallet_Rekey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet_rekey_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "wallet.dat")
+	oldPasswd := []byte("123456")
+	newPasswd := []byte("654321")
+	otherPasswd := []byte("abcdef")
+
+	wallet := NewWallet(path)
+	wallet.Scrypt = &keypair.ScryptParam{N: 4096, R: 8, P: 8, DKLen: 64}
+	mnemonic, err := wallet.InitHDWithOptions(DEFAULT_MNEMONIC_WORD_COUNT, MNEMONIC_LANG_ENGLISH, "TREZOR", oldPasswd)
+	assert.Nil(t, err)
+	acc1, err := wallet.NewHDAccount(oldPasswd)
+	assert.Nil(t, err)
+	acc2, err := wallet.NewDefaultSettingAccount(otherPasswd)
+	assert.Nil(t, err)
+	_, err = wallet.AddWatchOnlyAccount(NewAccount().PublicKey, acc1.SigScheme, "watch")
+	assert.Nil(t, err)
+	identity, err := wallet.NewDefaultSettingIdentity(oldPasswd)
+	assert.Nil(t, err)
+	assert.Nil(t, wallet.Save())
+
+	newScrypt := &keypair.ScryptParam{N: 16384, R: 8, P: 8, DKLen: 64}
+	param := &WalletRekeyParam{
+		OldPassword: oldPasswd,
+		NewPassword: newPasswd,
+		Scrypt:      newScrypt,
+	}
+	//wrong password of acc2, nothing changed
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.NotNil(t, wallet.Rekey(param))
+	data2, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, data, data2)
+	assert.Equal(t, 4096, wallet.Scrypt.N)
+	_, err = wallet.GetAccountByAddress(acc1.Address.ToBase58(), oldPasswd)
+	assert.Nil(t, err)
+
+	assert.Nil(t, wallet.Save())
+	_, err = os.Stat(GetWalletBackupPath(path, 1))
+	assert.Nil(t, err)
+	param.Passwords = map[string]*RekeyPassword{acc2.Address.ToBase58(): {Old: otherPasswd}}
+	assert.Nil(t, wallet.Rekey(param))
+	assert.Equal(t, 16384, wallet.Scrypt.N)
+	//backups encrypted by old passwords are removed
+	for i := 1; i <= DEFAULT_WALLET_BACKUP_COUNT; i++ {
+		_, err = os.Stat(GetWalletBackupPath(path, i))
+		assert.True(t, os.IsNotExist(err))
+	}
+	_, err = wallet.GetAccountByAddress(acc1.Address.ToBase58(), newPasswd)
+	assert.Nil(t, err)
+
+	wallet, err = OpenWallet(path)
+	assert.Nil(t, err)
+	assert.Equal(t, newScrypt.N, wallet.Scrypt.N)
+	acc, err := wallet.GetAccountByAddress(acc1.Address.ToBase58(), newPasswd)
+	assert.Nil(t, err)
+	assert.Equal(t, acc1.PrivateKey, acc.PrivateKey)
+	_, err = wallet.GetAccountByAddress(acc2.Address.ToBase58(), newPasswd)
+	assert.Nil(t, err)
+	_, err = wallet.GetAccountByAddress(acc1.Address.ToBase58(), oldPasswd)
+	assert.NotNil(t, err)
+	hdMnemonic, err := wallet.GetHDMnemonic(newPasswd)
+	assert.Nil(t, err)
+	assert.Equal(t, mnemonic, hdMnemonic)
+	acc, err = wallet.NewHDAccount(newPasswd)
+	assert.Nil(t, err)
+	assert.NotEqual(t, acc1.Address, acc.Address)
+	identity, err = wallet.GetIdentityById(identity.ID)
+	assert.Nil(t, err)
+	_, err = identity.GetControllerByIndex(1, newPasswd)
+	assert.Nil(t, err)
+
+	assert.Nil(t, wallet.Rekey(&WalletRekeyParam{OldPassword: newPasswd, NewPassword: oldPasswd, KeepBackups: true}))
+	_, err = os.Stat(GetWalletBackupPath(path, 1))
+	assert.Nil(t, err)
+}
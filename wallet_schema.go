@@ -0,0 +1,592 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	s "github.com/TesraSupernet/tesracrypto/signature"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//WALLET_VERSION_1_0 is the version of wallet written by early Ontology-family tools, wallet without version is 1.0 too
+const WALLET_VERSION_1_0 = "1.0"
+
+const MAX_WALLET_MIGRATIONS = 32 //max migrations applied when loading a wallet, to break cycles of migration
+
+//WalletMigration upgrade the raw json object of wallet from version From to version To
+type WalletMigration struct {
+	From    string
+	To      string
+	Migrate func(wallet map[string]interface{}) error
+}
+
+var walletMigrations = []*WalletMigration{
+	{From: WALLET_VERSION_1_0, To: "1.1", Migrate: migrateWalletV1_0},
+}
+
+//RegisterWalletMigration add migration to the chain used by ParseWalletData, migration of the same From is replaced
+func RegisterWalletMigration(migration *WalletMigration) {
+	for i, m := range walletMigrations {
+		if m.From == migration.From {
+			walletMigrations[i] = migration
+			return
+		}
+	}
+	walletMigrations = append(walletMigrations, migration)
+}
+
+//ParseWalletData parse wallet file content written by this SDK or other Tesra/Ontology-family tools.
+//Legacy field layouts are normalized, older versions are migrated to DEFAULT_WALLET_VERSION, and the result
+//is validated. Wallet of newer version is kept as it is. Unknown fields are preserved and written back on save.
+func ParseWalletData(data []byte) (*WalletData, error) {
+	wallet := make(map[string]interface{})
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	err := dec.Decode(&wallet)
+	if err != nil {
+		return nil, fmt.Errorf("parse wallet error:%s", jsonErrorWithPosition(data, err))
+	}
+	err = normalizeWalletLayout(wallet)
+	if err != nil {
+		return nil, err
+	}
+	err = migrateWallet(wallet)
+	if err != nil {
+		return nil, err
+	}
+	data, err = json.Marshal(wallet)
+	if err != nil {
+		return nil, err
+	}
+	walletData := &WalletData{}
+	err = json.Unmarshal(data, walletData)
+	if err != nil {
+		return nil, fmt.Errorf("parse wallet error:%s", err)
+	}
+	err = walletData.Validate().ToError()
+	if err != nil {
+		return nil, fmt.Errorf("invalid wallet:%s", err)
+	}
+	return walletData, nil
+}
+
+//NewWalletFromData create wallet from wallet file content, such as a wallet exported by other tools. Wallet is
+//saved to path on Save
+func NewWalletFromData(path string, data []byte) (*Wallet, error) {
+	walletData, err := ParseWalletData(data)
+	if err != nil {
+		return nil, err
+	}
+	return newWalletFromWalletData(NewFileKeyStore(path), walletData)
+}
+
+func jsonErrorWithPosition(data []byte, err error) error {
+	syntaxErr, ok := err.(*json.SyntaxError)
+	if !ok {
+		return err
+	}
+	offset := int(syntaxErr.Offset)
+	if offset > len(data) {
+		offset = len(data)
+	}
+	line := 1 + bytes.Count(data[:offset], []byte("\n"))
+	column := offset - bytes.LastIndex(data[:offset], []byte("\n"))
+	return fmt.Errorf("%s at line %d column %d", err, line, column)
+}
+
+//normalizeWalletLayout rename fields of legacy Ontology-family layouts to the fields of this SDK
+func normalizeWalletLayout(wallet map[string]interface{}) error {
+	identities, err := getJsonObjects(wallet, "identities")
+	if err != nil {
+		return err
+	}
+	for _, identity := range identities {
+		if _, ok := identity["tsrid"]; ok {
+			continue
+		}
+		if id, ok := identity["ontid"]; ok {
+			identity["tsrid"] = id
+			delete(identity, "ontid")
+		}
+	}
+	defIdentity := ""
+	for _, key := range []string{"defaultTsrid", "defaultOntid"} {
+		if id, ok := wallet[key].(string); ok && defIdentity == "" {
+			defIdentity = id
+		}
+		delete(wallet, key)
+	}
+	if defIdentity != "" {
+		for _, identity := range identities {
+			identity["isDefault"] = identity["tsrid"] == defIdentity
+		}
+	}
+	accounts, err := getJsonObjects(wallet, "accounts")
+	if err != nil {
+		return err
+	}
+	if defAddress, ok := wallet["defaultAccountAddress"].(string); ok && defAddress != "" {
+		for _, account := range accounts {
+			account["isDefault"] = account["address"] == defAddress
+		}
+	}
+	delete(wallet, "defaultAccountAddress")
+	return nil
+}
+
+func migrateWallet(wallet map[string]interface{}) error {
+	version := WALLET_VERSION_1_0
+	if v, ok := wallet["version"]; ok {
+		version, ok = v.(string)
+		if !ok {
+			return fmt.Errorf("invalid wallet:version: should be string")
+		}
+	}
+	for i := 0; i < MAX_WALLET_MIGRATIONS; i++ {
+		cmp, err := compareWalletVersion(version, DEFAULT_WALLET_VERSION)
+		if err != nil {
+			return fmt.Errorf("invalid wallet:version: %s", err)
+		}
+		if cmp >= 0 {
+			wallet["version"] = version
+			return nil
+		}
+		migration := getWalletMigration(version)
+		if migration == nil {
+			return fmt.Errorf("unsupported wallet version:%s", version)
+		}
+		err = migration.Migrate(wallet)
+		if err != nil {
+			return fmt.Errorf("migrate wallet from version:%s to:%s error:%s", migration.From, migration.To, err)
+		}
+		version = migration.To
+	}
+	return fmt.Errorf("migrate wallet error:too many migrations")
+}
+
+func getWalletMigration(version string) *WalletMigration {
+	for _, migration := range walletMigrations {
+		if migration.From == version {
+			return migration
+		}
+	}
+	return nil
+}
+
+//compareWalletVersion compare version in major.minor format, return -1 if v1 < v2, 1 if v1 > v2, otherwise 0
+func compareWalletVersion(v1, v2 string) (int, error) {
+	parts1 := strings.Split(v1, ".")
+	parts2 := strings.Split(v2, ".")
+	for len(parts1) < len(parts2) {
+		parts1 = append(parts1, "0")
+	}
+	for len(parts2) < len(parts1) {
+		parts2 = append(parts2, "0")
+	}
+	for i := range parts1 {
+		n1, err := strconv.ParseUint(parts1[i], 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid version:%s", v1)
+		}
+		n2, err := strconv.ParseUint(parts2[i], 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid version:%s", v2)
+		}
+		if n1 < n2 {
+			return -1, nil
+		}
+		if n1 > n2 {
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+//migrateWalletV1_0 fill the fields omitted by 1.0 wallet: dkLen of scrypt, and signature scheme of accounts and
+//controllers, which is the default scheme of key algorithm
+func migrateWalletV1_0(wallet map[string]interface{}) error {
+	if scrypt, ok := wallet["scrypt"].(map[string]interface{}); ok {
+		if _, ok := scrypt["dkLen"]; !ok {
+			scrypt["dkLen"] = keypair.GetScryptParameters().DKLen
+		}
+	}
+	keys, err := getJsonObjects(wallet, "accounts")
+	if err != nil {
+		return err
+	}
+	identities, err := getJsonObjects(wallet, "identities")
+	if err != nil {
+		return err
+	}
+	for i, identity := range identities {
+		controls, err := getJsonObjects(identity, "controls")
+		if err != nil {
+			return fmt.Errorf("identities[%d].%s", i, err)
+		}
+		keys = append(keys, controls...)
+	}
+	for _, key := range keys {
+		if _, ok := key["signatureScheme"]; ok {
+			continue
+		}
+		switch key["algorithm"] {
+		case "ECDSA":
+			key["signatureScheme"] = s.SHA256withECDSA.Name()
+		case "SM2":
+			key["signatureScheme"] = s.SM3withSM2.Name()
+		case "Ed25519":
+			key["signatureScheme"] = s.SHA512withEDDSA.Name()
+		}
+	}
+	return nil
+}
+
+//getJsonObjects return the array of objects of key, nil if key does not exist
+func getJsonObjects(obj map[string]interface{}, key string) ([]map[string]interface{}, error) {
+	value, ok := obj[key]
+	if !ok || value == nil {
+		return nil, nil
+	}
+	array, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: should be array", key)
+	}
+	objs := make([]map[string]interface{}, 0, len(array))
+	for i, item := range array {
+		o, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s[%d]: should be object", key, i)
+		}
+		objs = append(objs, o)
+	}
+	return objs, nil
+}
+
+//WalletProblem is a problem of wallet found by WalletData.Validate
+type WalletProblem struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (this *WalletProblem) Error() string {
+	return fmt.Sprintf("%s: %s", this.Field, this.Message)
+}
+
+//WalletProblems is the list of problems of wallet
+type WalletProblems []*WalletProblem
+
+func (this WalletProblems) Error() string {
+	msgs := make([]string, 0, len(this))
+	for _, p := range this {
+		msgs = append(msgs, p.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+//ToError return nil if there is no problem, or WalletProblems as error
+func (this WalletProblems) ToError() error {
+	if len(this) == 0 {
+		return nil
+	}
+	return this
+}
+
+func (this *WalletProblems) add(field, format string, args ...interface{}) {
+	*this = append(*this, &WalletProblem{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+//Validate check the schema of wallet, all of the problems found are returned with the path of field,
+//such as accounts[1].publicKey
+func (this *WalletData) Validate() WalletProblems {
+	problems := make(WalletProblems, 0)
+	if this.Version == "" {
+		problems.add("version", "should not be empty")
+	}
+	if this.Scrypt == nil {
+		problems.add("scrypt", "should not be empty")
+	} else {
+		if this.Scrypt.N <= 1 || this.Scrypt.N&(this.Scrypt.N-1) != 0 {
+			problems.add("scrypt.n", "should be power of 2, got:%d", this.Scrypt.N)
+		}
+		if this.Scrypt.R <= 0 {
+			problems.add("scrypt.r", "should be positive, got:%d", this.Scrypt.R)
+		}
+		if this.Scrypt.P <= 0 {
+			problems.add("scrypt.p", "should be positive, got:%d", this.Scrypt.P)
+		}
+		if this.Scrypt.DKLen <= 0 {
+			problems.add("scrypt.dkLen", "should be positive, got:%d", this.Scrypt.DKLen)
+		}
+	}
+	addresses := make(map[string]int)
+	labels := make(map[string]int)
+	defAccount := -1
+	for i, accData := range this.Accounts {
+		field := fmt.Sprintf("accounts[%d]", i)
+		if accData == nil {
+			problems.add(field, "should not be null")
+			continue
+		}
+		validateKeyData(&problems, field, accData.Address, accData.PubKey, accData.SigSch, &accData.ProtectedKey, accData.WatchOnly)
+		if index, ok := addresses[accData.Address]; ok {
+			problems.add(field+".address", "duplicate with accounts[%d]", index)
+		}
+		addresses[accData.Address] = i
+		if accData.Label != "" {
+			if index, ok := labels[accData.Label]; ok {
+				problems.add(field+".label", "duplicate with accounts[%d]", index)
+			}
+			labels[accData.Label] = i
+		}
+		if accData.IsDefault {
+			if defAccount >= 0 {
+				problems.add(field+".isDefault", "duplicate with accounts[%d]", defAccount)
+			}
+			defAccount = i
+		}
+	}
+	ids := make(map[string]int)
+	labels = make(map[string]int)
+	defIdentity := -1
+	for i, identityData := range this.Identities {
+		field := fmt.Sprintf("identities[%d]", i)
+		if identityData == nil {
+			problems.add(field, "should not be null")
+			continue
+		}
+		if identityData.ID == "" {
+			problems.add(field+".tsrid", "should not be empty")
+		} else if index, ok := ids[identityData.ID]; ok {
+			problems.add(field+".tsrid", "duplicate with identities[%d]", index)
+		}
+		ids[identityData.ID] = i
+		if identityData.Label != "" {
+			if index, ok := labels[identityData.Label]; ok {
+				problems.add(field+".label", "duplicate with identities[%d]", index)
+			}
+			labels[identityData.Label] = i
+		}
+		if identityData.IsDefault {
+			if defIdentity >= 0 {
+				problems.add(field+".isDefault", "duplicate with identities[%d]", defIdentity)
+			}
+			defIdentity = i
+		}
+		ctrIds := make(map[string]int)
+		for j, ctrData := range identityData.Control {
+			ctrField := fmt.Sprintf("%s.controls[%d]", field, j)
+			if ctrData == nil {
+				problems.add(ctrField, "should not be null")
+				continue
+			}
+			if ctrData.ID == "" {
+				problems.add(ctrField+".id", "should not be empty")
+			} else if index, ok := ctrIds[ctrData.ID]; ok {
+				problems.add(ctrField+".id", "duplicate with controls[%d]", index)
+			}
+			ctrIds[ctrData.ID] = j
+			validateKeyData(&problems, ctrField, ctrData.Address, ctrData.Public, ctrData.SigSch, &ctrData.ProtectedKey, false)
+		}
+	}
+	if this.HDSeed != nil {
+		if len(this.HDSeed.Key) == 0 {
+			problems.add("hdSeed.key", "should not be empty")
+		}
+		if len(this.HDSeed.Salt) == 0 {
+			problems.add("hdSeed.salt", "should not be empty")
+		}
+	}
+	for i, msAcc := range this.MultiSigAccounts {
+		field := fmt.Sprintf("multiSigAccounts[%d]", i)
+		if msAcc == nil {
+			problems.add(field, "should not be null")
+			continue
+		}
+		err := msAcc.Validate()
+		if err != nil {
+			problems.add(field, "%s", err)
+		}
+	}
+	return problems
+}
+
+//validateKeyData check the public key, address, signature scheme and encrypted private key of account or controller
+func validateKeyData(problems *WalletProblems, field, address, pubKey, sigSch string, protectedKey *keypair.ProtectedKey, watchOnly bool) {
+	addr, addrErr := common.AddressFromBase58(address)
+	if addrErr != nil {
+		problems.add(field+".address", "invalid address:%s", address)
+	}
+	data, err := hex.DecodeString(pubKey)
+	if err != nil {
+		problems.add(field+".publicKey", "should be hex string")
+	} else {
+		pk, err := keypair.DeserializePublicKey(data)
+		if err != nil {
+			problems.add(field+".publicKey", "invalid public key:%s", err)
+		} else if addrErr == nil && addr != types.AddressFromPubKey(pk) {
+			problems.add(field+".address", "does not match public key")
+		}
+	}
+	if _, err = s.GetScheme(sigSch); err != nil {
+		problems.add(field+".signatureScheme", "unknown signature scheme:%s", sigSch)
+	}
+	if watchOnly {
+		return
+	}
+	if len(protectedKey.Key) == 0 {
+		problems.add(field+".key", "should not be empty")
+	}
+	if protectedKey.EncAlg == "" {
+		problems.add(field+".enc-alg", "should not be empty")
+	}
+}
+
+//unmarshalWithExtra unmarshal data into v, which is a pointer to struct, and return the fields of data that
+//are unknown to v, nil if there is not any
+func unmarshalWithExtra(data []byte, v interface{}) (map[string]json.RawMessage, error) {
+	err := json.Unmarshal(data, v)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool)
+	getJsonFieldNames(reflect.TypeOf(v).Elem(), known)
+	for name := range fields {
+		if known[strings.ToLower(name)] {
+			delete(fields, name)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+//marshalWithExtra marshal v and append the unknown fields kept by unmarshalWithExtra
+func marshalWithExtra(v interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+	fields := make(map[string]json.RawMessage)
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range extra {
+		if _, ok := fields[name]; !ok {
+			fields[name] = value
+		}
+	}
+	return json.Marshal(fields)
+}
+
+//getJsonFieldNames collect the lower case json names of the fields of struct type t, including embedded struct
+func getJsonFieldNames(t reflect.Type, names map[string]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			getJsonFieldNames(field.Type, names)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[strings.ToLower(name)] = true
+	}
+}
+
+type walletDataJson WalletData
+
+func (this WalletData) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(walletDataJson(this), this.extraFields)
+}
+
+func (this *WalletData) UnmarshalJSON(data []byte) error {
+	extra, err := unmarshalWithExtra(data, (*walletDataJson)(this))
+	if err != nil {
+		return err
+	}
+	this.extraFields = extra
+	return nil
+}
+
+type accountDataJson AccountData
+
+func (this AccountData) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(accountDataJson(this), this.extraFields)
+}
+
+func (this *AccountData) UnmarshalJSON(data []byte) error {
+	extra, err := unmarshalWithExtra(data, (*accountDataJson)(this))
+	if err != nil {
+		return err
+	}
+	this.extraFields = extra
+	return nil
+}
+
+type identityDataJson IdentityData
+
+func (this IdentityData) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(identityDataJson(this), this.extraFields)
+}
+
+func (this *IdentityData) UnmarshalJSON(data []byte) error {
+	extra, err := unmarshalWithExtra(data, (*identityDataJson)(this))
+	if err != nil {
+		return err
+	}
+	this.extraFields = extra
+	return nil
+}
+
+type controllerDataJson ControllerData
+
+func (this ControllerData) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(controllerDataJson(this), this.extraFields)
+}
+
+func (this *ControllerData) UnmarshalJSON(data []byte) error {
+	extra, err := unmarshalWithExtra(data, (*controllerDataJson)(this))
+	if err != nil {
+		return err
+	}
+	this.extraFields = extra
+	return nil
+}
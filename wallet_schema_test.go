@@ -0,0 +1,139 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newLegacyWalletJson(t *testing.T, passwd []byte) (map[string]interface{}, *Account, *Identity) {
+	wallet := NewWallet("legacy_wallet.dat")
+	acc, err := wallet.NewDefaultSettingAccount(passwd)
+	assert.Nil(t, err)
+	_, err = wallet.NewDefaultSettingAccount(passwd)
+	assert.Nil(t, err)
+	identity, err := wallet.NewDefaultSettingIdentity(passwd)
+	assert.Nil(t, err)
+	data, err := json.Marshal(wallet.getWalletData())
+	assert.Nil(t, err)
+	walletJson := make(map[string]interface{})
+	assert.Nil(t, json.Unmarshal(data, &walletJson))
+	return walletJson, acc, identity
+}
+
+func TestParseWalletData_Legacy(t *testing.T) {
+	passwd := []byte("123456")
+	walletJson, acc, identity := newLegacyWalletJson(t, passwd)
+	//layout of Ontology 1.0 wallet
+	delete(walletJson, "version")
+	delete(walletJson["scrypt"].(map[string]interface{}), "dkLen")
+	walletJson["defaultAccountAddress"] = acc.Address.ToBase58()
+	walletJson["createTime"] = "2018-08-08T08:08:08.000Z"
+	accounts := walletJson["accounts"].([]interface{})
+	accounts[0].(map[string]interface{})["isDefault"] = false
+	accounts[1].(map[string]interface{})["isDefault"] = true
+	accounts[1].(map[string]interface{})["hardware"] = map[string]interface{}{"vendor": "ledger"}
+	identityJson := walletJson["identities"].([]interface{})[0].(map[string]interface{})
+	identityJson["ontid"] = identityJson["tsrid"]
+	delete(identityJson, "tsrid")
+	control := identityJson["controls"].([]interface{})[0].(map[string]interface{})
+	delete(control, "signatureScheme")
+	data, err := json.Marshal(walletJson)
+	assert.Nil(t, err)
+
+	walletData, err := ParseWalletData(data)
+	assert.Nil(t, err)
+	assert.Equal(t, DEFAULT_WALLET_VERSION, walletData.Version)
+	assert.Equal(t, 64, walletData.Scrypt.DKLen)
+	assert.True(t, walletData.Accounts[0].IsDefault)
+	assert.False(t, walletData.Accounts[1].IsDefault)
+	assert.Equal(t, identity.ID, walletData.Identities[0].ID)
+	assert.Equal(t, "SHA256withECDSA", walletData.Identities[0].Control[0].SigSch)
+
+	dir, err := ioutil.TempDir("", "wallet_schema_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "wallet.dat")
+	wallet, err := NewWalletFromData(path, data)
+	assert.Nil(t, err)
+	defAcc, err := wallet.GetDefaultAccount(passwd)
+	assert.Nil(t, err)
+	assert.Equal(t, acc.Address, defAcc.Address)
+	assert.Nil(t, wallet.Save())
+
+	data, err = ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	saved := make(map[string]interface{})
+	assert.Nil(t, json.Unmarshal(data, &saved))
+	assert.Equal(t, "2018-08-08T08:08:08.000Z", saved["createTime"])
+	assert.Nil(t, saved["defaultAccountAddress"])
+	accounts = saved["accounts"].([]interface{})
+	assert.Equal(t, map[string]interface{}{"vendor": "ledger"}, accounts[1].(map[string]interface{})["hardware"])
+}
+
+func TestParseWalletData_Version(t *testing.T) {
+	walletJson, _, _ := newLegacyWalletJson(t, []byte("123456"))
+	walletJson["version"] = "2.0"
+	walletJson["vault"] = "written by newer tool"
+	data, err := json.Marshal(walletJson)
+	assert.Nil(t, err)
+	walletData, err := ParseWalletData(data)
+	assert.Nil(t, err)
+	assert.Equal(t, "2.0", walletData.Version)
+	data, err = json.Marshal(walletData)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(data), `"vault":"written by newer tool"`))
+
+	walletJson["version"] = "0.9"
+	data, err = json.Marshal(walletJson)
+	assert.Nil(t, err)
+	_, err = ParseWalletData(data)
+	assert.NotNil(t, err)
+
+	_, err = ParseWalletData([]byte("{\n\"name\":\"MyWallet\",\n\"version\" \"1.1\"}"))
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "line 3"))
+}
+
+func TestWalletData_Validate(t *testing.T) {
+	walletJson, _, _ := newLegacyWalletJson(t, []byte("123456"))
+	accounts := walletJson["accounts"].([]interface{})
+	accounts[0].(map[string]interface{})["publicKey"] = "zz"
+	accounts[0].(map[string]interface{})["isDefault"] = true
+	accounts[1].(map[string]interface{})["signatureScheme"] = "unknown"
+	accounts[1].(map[string]interface{})["isDefault"] = true
+	walletJson["scrypt"].(map[string]interface{})["n"] = 1000
+	data, err := json.Marshal(walletJson)
+	assert.Nil(t, err)
+	walletData := &WalletData{}
+	assert.Nil(t, json.Unmarshal(data, walletData))
+
+	fields := make([]string, 0)
+	for _, problem := range walletData.Validate() {
+		fields = append(fields, problem.Field)
+	}
+	assert.Equal(t, []string{"scrypt.n", "accounts[0].publicKey", "accounts[1].signatureScheme", "accounts[1].isDefault"}, fields)
+	_, err = ParseWalletData(data)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/Tesra/core/types"
+	"github.com/TesraSupernet/go-bip32"
+	"github.com/TesraSupernet/tesracrypto/ec"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	s "github.com/TesraSupernet/tesracrypto/signature"
+	"github.com/TesraSupernet/tesrasdk/bip44"
+)
+
+var ERR_WATCH_ONLY_ACCOUNT = errors.New("watch-only account cannot sign")
+
+//NewWatchOnlyAccountData return the AccountData of public key without private key, it can be saved in wallet
+//and used to query balance or build unsigned transaction, but GetAccount always return ERR_WATCH_ONLY_ACCOUNT
+func NewWatchOnlyAccountData(pubKey keypair.PublicKey, sigScheme s.SignatureScheme, scrypt *keypair.ScryptParam) (*AccountData, error) {
+	keyType := keypair.GetKeyType(pubKey)
+	if !CheckSigScheme(keyType, sigScheme) {
+		return nil, fmt.Errorf("sigScheme:%s does not match with KeyType:%s", sigScheme.Name(), GetKeyTypeString(keyType))
+	}
+	curve := ""
+	switch key := pubKey.(type) {
+	case *ec.PublicKey:
+		curve = key.Params().Name
+	default:
+		if keyType == keypair.PK_EDDSA {
+			curve = "ed25519"
+		}
+	}
+	accData := &AccountData{}
+	accData.SetKeyPair(&keypair.ProtectedKey{
+		Address: types.AddressFromPubKey(pubKey).ToBase58(),
+		Alg:     GetKeyTypeString(keyType),
+		Param:   map[string]string{"curve": curve},
+	})
+	accData.SigSch = sigScheme.Name()
+	accData.PubKey = hex.EncodeToString(keypair.SerializePublicKey(pubKey))
+	accData.WatchOnly = true
+	accData.SetScript(scrypt)
+	return accData, nil
+}
+
+//GetPublicKey return the public key of account
+func (this *AccountData) GetPublicKey() (keypair.PublicKey, error) {
+	data, err := hex.DecodeString(this.PubKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode public key error:%s", err)
+	}
+	return keypair.DeserializePublicKey(data)
+}
+
+//GetAddress return the address of account
+func (this *AccountData) GetAddress() (common.Address, error) {
+	return common.AddressFromBase58(this.Address)
+}
+
+//AddWatchOnlyAccount add the watch-only account of public key into wallet
+func (this *Wallet) AddWatchOnlyAccount(pubKey keypair.PublicKey, sigScheme s.SignatureScheme, label string) (*AccountData, error) {
+	accData, err := NewWatchOnlyAccountData(pubKey, sigScheme, this.Scrypt)
+	if err != nil {
+		return nil, err
+	}
+	accData.Label = label
+	err = this.AddAccountData(accData)
+	if err != nil {
+		return nil, err
+	}
+	return accData, nil
+}
+
+//ExportHDAccountXPub return the extended public key of BIP44 account level m/44'/1024'/account' of HD wallet,
+//so that server without private key can derive the receiving addresses by ImportXPubAccounts
+func (this *Wallet) ExportHDAccountXPub(account uint32, passwd []byte) (string, error) {
+	seed, err := this.GetHDSeed(passwd)
+	if err != nil {
+		return "", err
+	}
+	masterKey, err := bip32.NewMasterKey(seed)
+	if err != nil {
+		return "", fmt.Errorf("NewMasterKey error:%s", err)
+	}
+	return bip44.ExportAccountXPub(masterKey, hd_coin_type_hardened, hd_hardened_offset+account)
+}
+
+//DeriveXPubAccountData return the watch-only AccountData of address index under the external chain of account
+//level xpub, with the derivation path m/44'/1024'/account'/0/index
+func DeriveXPubAccountData(xpub *bip32.Key, index uint32, scrypt *keypair.ScryptParam) (*AccountData, error) {
+	key, err := bip44.NewPublicKeyFromXPub(xpub, 0, index)
+	if err != nil {
+		return nil, fmt.Errorf("derive public key of index:%d error:%s", index, err)
+	}
+	pubKey, err := bip44.GetPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	accData, err := NewWatchOnlyAccountData(pubKey, s.SHA256withECDSA, scrypt)
+	if err != nil {
+		return nil, err
+	}
+	account := binary.BigEndian.Uint32(xpub.ChildNumber)
+	accData.DerivationPath = FormatDerivationPath([]uint32{bip44.Purpose, hd_coin_type_hardened, account, 0, index})
+	return accData, nil
+}
+
+//ImportXPubAccounts derive the watch-only accounts of address index from start to start+count-1 from account
+//level xpub, and add them into wallet
+func (this *Wallet) ImportXPubAccounts(xpub string, start, count uint32) ([]*AccountData, error) {
+	key, err := bip44.ImportXPub(xpub)
+	if err != nil {
+		return nil, err
+	}
+	accDatas := make([]*AccountData, 0, count)
+	for index := start; index-start < count; index++ {
+		accData, err := DeriveXPubAccountData(key, index, this.Scrypt)
+		if err != nil {
+			return nil, err
+		}
+		err = this.AddAccountData(accData)
+		if err != nil {
+			return nil, fmt.Errorf("add account:%s error:%s", accData.Address, err)
+		}
+		accDatas = append(accDatas, accData)
+	}
+	return accDatas, nil
+}
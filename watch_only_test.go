@@ -0,0 +1,113 @@
+/*
+ * Copyright (C) 2019 The TesraSupernet Authors
+ * This file is part of The TesraSupernet library.
+ *
+ * The TesraSupernet is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The TesraSupernet is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The TesraSupernet.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package tesra_go_sdk
+
+import (
+	"github.com/TesraSupernet/Tesra/common"
+	"github.com/TesraSupernet/go-bip32"
+	"github.com/TesraSupernet/tesracrypto/keypair"
+	"github.com/TesraSupernet/tesrasdk/bip44"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWallet_ImportXPubAccounts(t *testing.T) {
+	passwd := []byte("123456")
+	hdWallet := NewWallet("hd_wallet.dat")
+	assert.Nil(t, hdWallet.InitHDFromMnemonic(testMnemonic, passwd))
+	xpub, err := hdWallet.ExportHDAccountXPub(0, passwd)
+	assert.Nil(t, err)
+
+	watchWallet := NewWallet("watch_wallet.dat")
+	accDatas, err := watchWallet.ImportXPubAccounts(xpub, 0, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, watchWallet.GetAccountCount())
+	seed, err := MnemonicToSeed(testMnemonic, "")
+	assert.Nil(t, err)
+	for i, accData := range accDatas {
+		acc, err := DeriveAccountFromSeed(seed, GetHDAccountPath(uint32(i)))
+		assert.Nil(t, err)
+		assert.Equal(t, acc.Address.ToBase58(), accData.Address)
+		assert.Equal(t, GetHDAccountPath(uint32(i)), accData.DerivationPath)
+		assert.True(t, accData.WatchOnly)
+		address, err := accData.GetAddress()
+		assert.Nil(t, err)
+		assert.Equal(t, acc.Address, address)
+
+		_, err = watchWallet.GetAccountByAddress(accData.Address, passwd)
+		assert.Equal(t, ERR_WATCH_ONLY_ACCOUNT, err)
+		assert.Equal(t, ERR_WATCH_ONLY_ACCOUNT, watchWallet.ChangeAccountPassword(accData.Address, passwd, passwd))
+	}
+
+	masterKey, err := bip32.NewMasterKey(seed)
+	assert.Nil(t, err)
+	_, err = bip44.ImportXPub(masterKey.B58Serialize())
+	assert.NotNil(t, err)
+	_, err = bip44.ImportXPub(masterKey.PublicKey().B58Serialize())
+	assert.NotNil(t, err)
+}
+
+func TestWallet_AddWatchOnlyAccount(t *testing.T) {
+	acc := NewAccount()
+	wallet := NewWallet("watch_wallet.dat")
+	accData, err := wallet.AddWatchOnlyAccount(acc.PublicKey, acc.SigScheme, "deposit")
+	assert.Nil(t, err)
+	assert.Equal(t, acc.Address.ToBase58(), accData.Address)
+	pubKey, err := accData.GetPublicKey()
+	assert.Nil(t, err)
+	assert.True(t, keypair.ComparePublicKey(acc.PublicKey, pubKey))
+
+	clone := accData.Clone()
+	assert.True(t, clone.WatchOnly)
+	_, err = clone.GetAccount([]byte("123456"))
+	assert.Equal(t, ERR_WATCH_ONLY_ACCOUNT, err)
+
+	exported, err := wallet.ExportAccounts("export_wallet.dat", []*AccountData{accData}, [][]byte{nil})
+	assert.Nil(t, err)
+	exportedData, err := exported.GetAccountDataByLabel("deposit")
+	assert.Nil(t, err)
+	assert.True(t, exportedData.WatchOnly)
+
+	sdk := NewTesraSdk()
+	tx, err := sdk.Native.Tsg.NewTransferTransaction(500, 20000, acc.Address, acc.Address, 1)
+	assert.Nil(t, err)
+	sdk.SetPayer(tx, acc.Address)
+	_, err = wallet.GetAccountByLabel("deposit", []byte("123456"))
+	assert.Equal(t, ERR_WATCH_ONLY_ACCOUNT, err)
+
+	//signing by wallet is refused for watch-only account
+	req, err := NewOfflineSignRequest(tx, nil)
+	assert.Nil(t, err)
+	_, err = NewOfflineSigner(wallet).Sign(req, accData.Address, []byte("123456"))
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(req.Tx.Signers))
+
+	//watch-only account is not a co-signer of multi-signature account
+	pubKeys := []keypair.PublicKey{acc.PublicKey, NewAccount().PublicKey}
+	msAcc, err := wallet.AddMultiSigAccount("multi", 1, pubKeys)
+	assert.Nil(t, err)
+	assert.False(t, msAcc.IsCoSigner(accData.Address))
+	msAddress, err := common.AddressFromBase58(msAcc.Address)
+	assert.Nil(t, err)
+	tx, err = sdk.Native.Tsg.NewTransferTransaction(500, 20000, msAddress, acc.Address, 1)
+	assert.Nil(t, err)
+	count, err := sdk.SignToTransactionByWallet(tx, wallet, msAcc.Address, []byte("123456"))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 0, len(tx.Sigs))
+}